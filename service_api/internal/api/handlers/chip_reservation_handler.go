@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/ahmetkoprulu/rtrp/internal/services"
+	"github.com/ahmetkoprulu/rtrp/models"
+	"github.com/gin-gonic/gin"
+)
+
+type ChipReservationHandler struct {
+	chipReservationService *services.ChipReservationService
+}
+
+func NewChipReservationHandler(chipReservationService *services.ChipReservationService) *ChipReservationHandler {
+	return &ChipReservationHandler{chipReservationService: chipReservationService}
+}
+
+func (h *ChipReservationHandler) RegisterRoutes(router *gin.RouterGroup, serverToServerAuthMiddleware gin.HandlerFunc) {
+	reservations := router.Group("/chip-reservations")
+	{
+		reservations.POST("/release-stale", serverToServerAuthMiddleware, h.ReleaseStale)
+		reservations.PUT("/:id", serverToServerAuthMiddleware, h.Reserve)
+		reservations.POST("/:id/release", serverToServerAuthMiddleware, h.Release)
+		reservations.POST("/:id/settle", serverToServerAuthMiddleware, h.Settle)
+	}
+}
+
+// @Summary Reserve chips for a seat
+// @Description Takes chips from the player's wallet to their seat at a table. Repeating the call with the same ID and amount does not take the chips twice.
+// @Tags chip-reservations
+// @Accept json
+// @Produce json
+// @Param id path string true "Reservation ID, chosen by the game server"
+// @Param request body ReserveChipsRequest true "Reservation"
+// @Success 200 {object} models.ChipReservation
+// @Failure 400 {object} ErrorResponse
+// @Router /chip-reservations/{id} [put]
+func (h *ChipReservationHandler) Reserve(c *gin.Context) {
+	model := BindModel[ReserveChipsRequest](c)
+	if model == nil {
+		return
+	}
+
+	reservation, err := h.chipReservationService.Reserve(c.Request.Context(), c.Param("id"), model.PlayerID, model.RoomID, model.Owner, model.Session, model.Type, model.Amount)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Ok(c, reservation)
+}
+
+// @Summary Release reserved chips
+// @Description Gives all the reserved chips back to the player's wallet. Releasing a closed reservation returns it unchanged.
+// @Tags chip-reservations
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} models.ChipReservation
+// @Failure 404 {object} ErrorResponse
+// @Router /chip-reservations/{id}/release [post]
+func (h *ChipReservationHandler) Release(c *gin.Context) {
+	reservation, err := h.chipReservationService.Release(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Ok(c, reservation)
+}
+
+// @Summary Settle reserved chips
+// @Description Closes the reservation with the stack the player left the table with, or their tournament prize, and credits it to their wallet. Settling a closed reservation returns it unchanged.
+// @Tags chip-reservations
+// @Accept json
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Param request body SettleChipsRequest true "Final stack"
+// @Success 200 {object} models.ChipReservation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /chip-reservations/{id}/settle [post]
+func (h *ChipReservationHandler) Settle(c *gin.Context) {
+	model := BindModel[SettleChipsRequest](c)
+	if model == nil {
+		return
+	}
+
+	reservation, err := h.chipReservationService.Settle(c.Request.Context(), c.Param("id"), model.Type, model.Amount)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Ok(c, reservation)
+}
+
+// @Summary Release the reservations of an earlier run
+// @Description Gives back the chips of every open reservation the game server instance made in another session. Game servers call it when they start, for the seats a crashed run never settled.
+// @Tags chip-reservations
+// @Accept json
+// @Produce json
+// @Param request body ReleaseStaleChipsRequest true "Instance and current session"
+// @Success 200 {object} ReleaseStaleChipsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /chip-reservations/release-stale [post]
+func (h *ChipReservationHandler) ReleaseStale(c *gin.Context) {
+	model := BindModel[ReleaseStaleChipsRequest](c)
+	if model == nil {
+		return
+	}
+
+	released, err := h.chipReservationService.ReleaseStale(c.Request.Context(), model.Owner, model.Session)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Ok(c, ReleaseStaleChipsResponse{Released: released})
+}
+
+func (h *ChipReservationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReservationNotFound):
+		NotFound(c, err.Error())
+	case errors.Is(err, services.ErrInsufficientChips),
+		errors.Is(err, services.ErrInvalidReservation),
+		errors.Is(err, services.ErrReservationClosed),
+		errors.Is(err, services.ErrReservationMismatch),
+		errors.Is(err, services.ErrReservationConflict),
+		errors.Is(err, services.ErrReservationIDMissing),
+		errors.Is(err, services.ErrInvalidTransaction),
+		errors.Is(err, services.ErrReservationOwnerMissing):
+		BadRequest(c, err.Error())
+	default:
+		InternalServerError(c, err.Error())
+	}
+}
+
+// ReserveChipsRequest represents a request to hold chips for a seat
+type ReserveChipsRequest struct {
+	// Player ID
+	PlayerID string `json:"player_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	// Room the chips are taken to
+	RoomID string `json:"room_id" binding:"required" example:"1"`
+	// Game server instance holding the seat
+	Owner string `json:"owner" example:"game-1"`
+	// Run of the game server instance
+	Session string `json:"session" example:"5f0c6f1e-8f8a-4d53-9a4b-0a3c1f3f2d11"`
+	// buy_in or top_up
+	Type models.ChipTransactionType `json:"type" binding:"required" example:"buy_in"`
+	// Amount of chips taken from the wallet
+	Amount int64 `json:"amount" binding:"required" example:"2000"`
+}
+
+// SettleChipsRequest represents the stack a player leaves a table with
+type SettleChipsRequest struct {
+	// cash_out or prize
+	Type models.ChipTransactionType `json:"type" binding:"required" example:"cash_out"`
+	// Amount of chips credited to the wallet
+	Amount int64 `json:"amount" example:"3500"`
+}
+
+// ReleaseStaleChipsRequest represents a game server releasing what its
+// earlier runs left reserved
+type ReleaseStaleChipsRequest struct {
+	// Game server instance
+	Owner string `json:"owner" binding:"required" example:"game-1"`
+	// Current run of the instance, its reservations are kept
+	Session string `json:"session" binding:"required" example:"5f0c6f1e-8f8a-4d53-9a4b-0a3c1f3f2d11"`
+}
+
+// ReleaseStaleChipsResponse represents how many reservations were released
+type ReleaseStaleChipsResponse struct {
+	Released int `json:"released" example:"3"`
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultHandHistoryExportRange = 30 * 24 * time.Hour
+
+type HandHistoryHandler struct {
+	handHistoryService *services.HandHistoryService
+}
+
+func NewHandHistoryHandler(handHistoryService *services.HandHistoryService) *HandHistoryHandler {
+	return &HandHistoryHandler{handHistoryService: handHistoryService}
+}
+
+func (h *HandHistoryHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	handHistories := router.Group("/hand-histories")
+	{
+		handHistories.GET("/export", authMiddleware, h.ExportHandHistory)
+	}
+}
+
+// @Summary Download hand history
+// @Description Downloads the player's hands as a zip archive with one PokerStars formatted text file per session
+// @Tags hand-histories
+// @Produce application/zip
+// @Param from query string false "Start of the range (RFC3339), defaults to 30 days before to"
+// @Param to query string false "End of the range (RFC3339), defaults to now"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security Bearer
+// @Router /hand-histories/export [get]
+func (h *HandHistoryHandler) ExportHandHistory(c *gin.Context) {
+	playerID := c.GetString("playerID")
+	if playerID == "" {
+		BadRequest(c, "player_id is required")
+		return
+	}
+
+	to := time.Now().UTC()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			BadRequest(c, "to must be an RFC3339 timestamp")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultHandHistoryExportRange)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			BadRequest(c, "from must be an RFC3339 timestamp")
+			return
+		}
+		from = parsed
+	}
+
+	archive, err := h.handHistoryService.ExportPlayerHistory(c.Request.Context(), playerID, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoHandHistory):
+			NotFound(c, err.Error())
+		case errors.Is(err, services.ErrInvalidExportRange):
+			BadRequest(c, err.Error())
+		default:
+			InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	fileName := fmt.Sprintf("hand_history_%s_%s.zip", from.Format("20060102"), to.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(200, "application/zip", archive)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/ahmetkoprulu/rtrp/internal/services"
+	"github.com/ahmetkoprulu/rtrp/models"
+	"github.com/gin-gonic/gin"
+)
+
+type RoomHandler struct {
+	roomService *services.RoomService
+}
+
+func NewRoomHandler(roomService *services.RoomService) *RoomHandler {
+	return &RoomHandler{roomService: roomService}
+}
+
+func (h *RoomHandler) RegisterRoutes(router *gin.RouterGroup, serverToServerAuthMiddleware gin.HandlerFunc) {
+	rooms := router.Group("/rooms")
+	{
+		rooms.GET("", serverToServerAuthMiddleware, h.GetRooms)
+		rooms.GET("/:id", serverToServerAuthMiddleware, h.GetRoom)
+		rooms.PUT("/:id", serverToServerAuthMiddleware, h.SaveRoom)
+		rooms.PUT("/:id/status", serverToServerAuthMiddleware, h.SetRoomStatus)
+	}
+}
+
+// @Summary List rooms
+// @Description Lists the rooms the game servers load at boot, closed rooms left out
+// @Tags rooms
+// @Produce json
+// @Success 200 {array} models.Room
+// @Router /rooms [get]
+func (h *RoomHandler) GetRooms(c *gin.Context) {
+	rooms, err := h.roomService.GetRooms(c.Request.Context())
+	if err != nil {
+		InternalServerError(c, err.Error())
+		return
+	}
+
+	Ok(c, rooms)
+}
+
+// @Summary Get room
+// @Tags rooms
+// @Produce json
+// @Param id path string true "Room ID"
+// @Success 200 {object} models.Room
+// @Failure 404 {object} ErrorResponse
+// @Router /rooms/{id} [get]
+func (h *RoomHandler) GetRoom(c *gin.Context) {
+	room, err := h.roomService.GetRoom(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Ok(c, room)
+}
+
+// @Summary Save room
+// @Description Creates the room or replaces its definition
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "Room ID"
+// @Param request body models.Room true "Room definition"
+// @Success 200 {object} models.Room
+// @Failure 400 {object} ErrorResponse
+// @Router /rooms/{id} [put]
+func (h *RoomHandler) SaveRoom(c *gin.Context) {
+	model := BindModel[models.Room](c)
+	if model == nil {
+		return
+	}
+
+	model.ID = c.Param("id")
+	room, err := h.roomService.SaveRoom(c.Request.Context(), model)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Ok(c, room)
+}
+
+// @Summary Set room status
+// @Description Pauses, resumes or closes the room
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "Room ID"
+// @Param request body SetRoomStatusRequest true "Status"
+// @Success 200 {object} models.Room
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /rooms/{id}/status [put]
+func (h *RoomHandler) SetRoomStatus(c *gin.Context) {
+	model := BindModel[SetRoomStatusRequest](c)
+	if model == nil {
+		return
+	}
+
+	room, err := h.roomService.SetRoomStatus(c.Request.Context(), c.Param("id"), model.Status)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	Ok(c, room)
+}
+
+func (h *RoomHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound):
+		NotFound(c, err.Error())
+	case errors.Is(err, services.ErrInvalidRoom), errors.Is(err, services.ErrInvalidRoomStatus):
+		BadRequest(c, err.Error())
+	default:
+		InternalServerError(c, err.Error())
+	}
+}
+
+// SetRoomStatusRequest represents a request to pause, resume or close a room
+type SetRoomStatusRequest struct {
+	// active, paused or closed
+	Status models.RoomStatus `json:"status" binding:"required" example:"paused"`
+}
@@ -16,15 +16,18 @@ import (
 )
 
 type Server struct {
-	router              *gin.Engine
-	httpServer          *http.Server
-	authService         *services.AuthService
-	playerService       *services.PlayerService
-	eventService        *services.EventService
-	lobbyService        *services.LobbyService
-	remoteConfigService *services.RemoteConfigService
-	db                  *data.PgDbContext
-	miniGameService     *services.MiniGameService
+	router                 *gin.Engine
+	httpServer             *http.Server
+	authService            *services.AuthService
+	playerService          *services.PlayerService
+	eventService           *services.EventService
+	lobbyService           *services.LobbyService
+	remoteConfigService    *services.RemoteConfigService
+	db                     *data.PgDbContext
+	miniGameService        *services.MiniGameService
+	handHistoryService     *services.HandHistoryService
+	chipReservationService *services.ChipReservationService
+	roomService            *services.RoomService
 }
 
 func NewServer(db *data.PgDbContext) *Server {
@@ -36,16 +39,22 @@ func NewServer(db *data.PgDbContext) *Server {
 	lobbyService := services.NewLobbyService(db, playerService, eventService, battlePassService)
 	remoteConfigService := services.NewRemoteConfigService(db)
 	miniGameService := services.NewMiniGameService(playerService, productService)
+	handHistoryService := services.NewHandHistoryService(db)
+	chipReservationService := services.NewChipReservationService(db)
+	roomService := services.NewRoomService(db)
 
 	server := &Server{
-		router:              gin.Default(),
-		authService:         authService,
-		playerService:       playerService,
-		eventService:        eventService,
-		lobbyService:        lobbyService,
-		db:                  db,
-		remoteConfigService: remoteConfigService,
-		miniGameService:     miniGameService,
+		router:                 gin.Default(),
+		authService:            authService,
+		playerService:          playerService,
+		eventService:           eventService,
+		lobbyService:           lobbyService,
+		db:                     db,
+		remoteConfigService:    remoteConfigService,
+		miniGameService:        miniGameService,
+		handHistoryService:     handHistoryService,
+		chipReservationService: chipReservationService,
+		roomService:            roomService,
 	}
 
 	server.router.Use(middleware.RequestLogger())
@@ -61,6 +70,9 @@ func NewServer(db *data.PgDbContext) *Server {
 	battlePassHandler := handlers.NewBattlePassHandler(battlePassService)
 	lobbyHandler := handlers.NewLobbyHandler(lobbyService)
 	remoteConfigHandler := handlers.NewRemoteConfigHandler(remoteConfigService)
+	handHistoryHandler := handlers.NewHandHistoryHandler(handHistoryService)
+	chipReservationHandler := handlers.NewChipReservationHandler(chipReservationService)
+	roomHandler := handlers.NewRoomHandler(roomService)
 
 	authMiddleware := middleware.AuthMiddleware()
 	serverToServerAuthMiddleware := middleware.ServerToServerAuthMiddleware()
@@ -75,6 +87,9 @@ func NewServer(db *data.PgDbContext) *Server {
 		battlePassHandler.RegisterRoutes(v1, authMiddleware)
 		lobbyHandler.RegisterRoutes(v1, authMiddleware)
 		remoteConfigHandler.RegisterRoutes(v1)
+		handHistoryHandler.RegisterRoutes(v1, authMiddleware)
+		chipReservationHandler.RegisterRoutes(v1, serverToServerAuthMiddleware)
+		roomHandler.RegisterRoutes(v1, serverToServerAuthMiddleware)
 		// Protected routes
 		// protected := v1.Group("", authMiddleware)
 		// {
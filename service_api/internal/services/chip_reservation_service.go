@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ahmetkoprulu/rtrp/common/data"
+	"github.com/ahmetkoprulu/rtrp/models"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrReservationNotFound     = errors.New("reservation not found")
+	ErrReservationClosed       = errors.New("reservation is already released or settled")
+	ErrReservationMismatch     = errors.New("reservation was made for another player, room or amount")
+	ErrReservationConflict     = errors.New("reservation is being created, try again")
+	ErrInvalidReservation      = errors.New("invalid reservation amount")
+	ErrInsufficientChips       = errors.New("insufficient chips")
+	ErrReservationIDMissing    = errors.New("reservation id is required")
+	ErrInvalidTransaction      = errors.New("invalid chip transaction type")
+	ErrReservationOwnerMissing = errors.New("reservation owner is required")
+)
+
+const chipReservationColumns = "id, player_id, room_id, amount, type, status, settled_amount, owner, session, created_at, updated_at"
+
+// ChipReservationService moves chips between players' wallets and the tables
+// they sit at. The chips a player takes to a table are held in a reservation
+// until they leave, so the same chips can not be played at two tables. Every
+// call runs in one transaction and can be retried safely.
+type ChipReservationService struct {
+	db *data.PgDbContext
+}
+
+func NewChipReservationService(db *data.PgDbContext) *ChipReservationService {
+	return &ChipReservationService{db: db}
+}
+
+// Reserve takes amount chips from the player's wallet to their seat at the
+// room for a buy-in or a top-up, held by the owner's session. Calling it again
+// with the same ID and amount returns the reservation without taking the
+// chips twice.
+func (s *ChipReservationService) Reserve(ctx context.Context, id, playerID, roomID, owner, session string, transaction models.ChipTransactionType, amount int64) (*models.ChipReservation, error) {
+	if id == "" {
+		return nil, ErrReservationIDMissing
+	}
+
+	if amount <= 0 {
+		return nil, ErrInvalidReservation
+	}
+
+	if transaction != models.ChipTransactionBuyIn && transaction != models.ChipTransactionTopUp {
+		return nil, ErrInvalidTransaction
+	}
+
+	var reservation *models.ChipReservation
+	err := s.db.WithTransaction(ctx, func(tx data.QueryRunner) error {
+		current, err := s.getReservationForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if current == nil {
+			if err := s.takeFromWallet(ctx, tx, playerID, amount); err != nil {
+				return err
+			}
+
+			reservation, err = scanChipReservation(tx.QueryRow(ctx, `
+				INSERT INTO chip_reservations (id, player_id, room_id, amount, type, status, owner, session)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				ON CONFLICT (id) DO NOTHING
+				RETURNING `+chipReservationColumns,
+				id, playerID, roomID, amount, transaction, models.ChipReservationStatusReserved, owner, session))
+			if err != nil {
+				return err
+			}
+
+			// Created by a concurrent call with the same ID
+			if reservation == nil {
+				return ErrReservationConflict
+			}
+
+			return s.recordTransaction(ctx, tx, reservation, transaction, -amount)
+		}
+
+		// A retry of the call that created it
+		if current.PlayerID != playerID || current.RoomID != roomID || current.Amount != amount || current.Type != transaction {
+			return ErrReservationMismatch
+		}
+
+		if current.Status != models.ChipReservationStatusReserved {
+			return ErrReservationClosed
+		}
+
+		reservation = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// Release gives all the reserved chips back to the wallet, for a seat the
+// player never took.
+func (s *ChipReservationService) Release(ctx context.Context, id string) (*models.ChipReservation, error) {
+	return s.close(ctx, id, models.ChipReservationStatusReleased, models.ChipTransactionRefund, nil)
+}
+
+// Settle closes the reservation when the player leaves the table, or is paid
+// a tournament prize, and gives the chips they left with back to the wallet.
+func (s *ChipReservationService) Settle(ctx context.Context, id string, transaction models.ChipTransactionType, amount int64) (*models.ChipReservation, error) {
+	if amount < 0 {
+		return nil, ErrInvalidReservation
+	}
+
+	if transaction != models.ChipTransactionCashOut && transaction != models.ChipTransactionPrize {
+		return nil, ErrInvalidTransaction
+	}
+
+	return s.close(ctx, id, models.ChipReservationStatusSettled, transaction, &amount)
+}
+
+// ReleaseStale releases the open reservations of the owner that an earlier
+// session made. A game server calls it when it starts, for the seats a run
+// that stopped without settling them left behind. It returns how many were
+// released.
+func (s *ChipReservationService) ReleaseStale(ctx context.Context, owner, session string) (int, error) {
+	if owner == "" {
+		return 0, ErrReservationOwnerMissing
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id FROM chip_reservations
+		WHERE owner = $1 AND session <> $2 AND status = $3`,
+		owner, session, models.ChipReservationStatusReserved)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if _, err := s.Release(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+// close returns the chips to the wallet, all of them when amount is nil, and
+// records the transaction. A reservation that is already closed is returned
+// as it is.
+func (s *ChipReservationService) close(ctx context.Context, id string, status models.ChipReservationStatus, transaction models.ChipTransactionType, amount *int64) (*models.ChipReservation, error) {
+	if id == "" {
+		return nil, ErrReservationIDMissing
+	}
+
+	var reservation *models.ChipReservation
+	err := s.db.WithTransaction(ctx, func(tx data.QueryRunner) error {
+		current, err := s.getReservationForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if current == nil {
+			return ErrReservationNotFound
+		}
+
+		if current.Status != models.ChipReservationStatusReserved {
+			reservation = current
+			return nil
+		}
+
+		returned := current.Amount
+		if amount != nil {
+			returned = *amount
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE players SET chips = chips + $1, updated_at = NOW() WHERE id = $2", returned, current.PlayerID)
+		if err != nil {
+			return err
+		}
+
+		reservation, err = scanChipReservation(tx.QueryRow(ctx, `
+			UPDATE chip_reservations
+			SET status = $2, settled_amount = $3, updated_at = NOW()
+			WHERE id = $1
+			RETURNING `+chipReservationColumns, id, status, returned))
+		if err != nil {
+			return err
+		}
+
+		return s.recordTransaction(ctx, tx, reservation, transaction, returned)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// recordTransaction adds the chip movement to the player's transactions.
+// Reservations closed with nothing left, like the top-ups of a stack cashed
+// out on its buy-in, move no chips and are not recorded.
+func (s *ChipReservationService) recordTransaction(ctx context.Context, tx data.QueryRunner, reservation *models.ChipReservation, transaction models.ChipTransactionType, change int64) error {
+	if change == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO chip_transactions (reservation_id, player_id, room_id, type, change)
+		VALUES ($1, $2, $3, $4, $5)`,
+		reservation.ID, reservation.PlayerID, reservation.RoomID, transaction, change)
+	return err
+}
+
+func (s *ChipReservationService) takeFromWallet(ctx context.Context, tx data.QueryRunner, playerID string, amount int64) error {
+	tag, err := tx.Exec(ctx, "UPDATE players SET chips = chips - $1, updated_at = NOW() WHERE id = $2 AND chips >= $1", amount, playerID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrInsufficientChips
+	}
+
+	return nil
+}
+
+func (s *ChipReservationService) getReservationForUpdate(ctx context.Context, tx data.QueryRunner, id string) (*models.ChipReservation, error) {
+	return scanChipReservation(tx.QueryRow(ctx, "SELECT "+chipReservationColumns+" FROM chip_reservations WHERE id = $1 FOR UPDATE", id))
+}
+
+func scanChipReservation(row pgx.Row) (*models.ChipReservation, error) {
+	var reservation models.ChipReservation
+	err := row.Scan(&reservation.ID, &reservation.PlayerID, &reservation.RoomID, &reservation.Amount, &reservation.Type, &reservation.Status, &reservation.SettledAmount, &reservation.Owner, &reservation.Session, &reservation.CreatedAt, &reservation.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &reservation, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/common/data"
+	"github.com/ahmetkoprulu/rtrp/internal/services/handhistory"
+)
+
+const MaxHandHistoryExportRange = 90 * 24 * time.Hour
+
+var (
+	ErrInvalidExportRange = errors.New("invalid export range")
+	ErrNoHandHistory      = errors.New("no hand history in range")
+)
+
+type HandHistoryService struct {
+	store handhistory.HandHistoryStore
+}
+
+func NewHandHistoryService(db *data.PgDbContext) *HandHistoryService {
+	return &HandHistoryService{store: handhistory.NewPgHandHistoryStore(db)}
+}
+
+// ExportPlayerHistory returns a zip archive of the player's hands between from
+// and to, one PokerStars formatted file per session.
+func (s *HandHistoryService) ExportPlayerHistory(ctx context.Context, playerID string, from, to time.Time) ([]byte, error) {
+	if playerID == "" {
+		return nil, fmt.Errorf("player id is required")
+	}
+
+	if !from.Before(to) || to.Sub(from) > MaxHandHistoryExportRange {
+		return nil, ErrInvalidExportRange
+	}
+
+	hands, err := s.store.GetPlayerHands(ctx, playerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hands) == 0 {
+		return nil, ErrNoHandHistory
+	}
+
+	return handhistory.ExportSessions(hands, playerID)
+}
@@ -0,0 +1,12 @@
+package handhistory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/models"
+)
+
+type HandHistoryStore interface {
+	GetPlayerHands(ctx context.Context, playerID string, from, to time.Time) ([]*models.HandRecord, error)
+}
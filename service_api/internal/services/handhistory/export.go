@@ -0,0 +1,107 @@
+package handhistory
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/models"
+)
+
+// SessionGap is the longest break between two hands at the same table that
+// still counts as one session.
+const SessionGap = 30 * time.Minute
+
+// Session is a run of consecutive hands a player played at one table.
+type Session struct {
+	RoomID    string
+	RoomName  string
+	StartedAt time.Time
+	EndedAt   time.Time
+	Hands     []*models.HandRecord
+}
+
+// FileName returns the name of the session's export file.
+func (s *Session) FileName() string {
+	name := s.RoomName
+	if name == "" {
+		name = s.RoomID
+	}
+	name = unsafeFileChars.ReplaceAllString(name, "_")
+
+	return fmt.Sprintf("%s_%s.txt", s.StartedAt.UTC().Format("20060102_150405"), strings.Trim(name, "_"))
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// SplitSessions groups hands by table and splits each table's hands where the
+// player took a break longer than SessionGap.
+func SplitSessions(hands []*models.HandRecord) []*Session {
+	sorted := append([]*models.HandRecord{}, hands...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartedAt.Before(sorted[j].StartedAt)
+	})
+
+	sessions := []*Session{}
+	open := make(map[string]*Session)
+	for _, hand := range sorted {
+		session, ok := open[hand.RoomID]
+		if !ok || hand.StartedAt.Sub(session.EndedAt) > SessionGap {
+			session = &Session{
+				RoomID:    hand.RoomID,
+				RoomName:  hand.RoomName,
+				StartedAt: hand.StartedAt,
+			}
+			open[hand.RoomID] = session
+			sessions = append(sessions, session)
+		}
+
+		session.Hands = append(session.Hands, hand)
+		session.EndedAt = hand.EndedAt
+	}
+
+	return sessions
+}
+
+// FormatSession writes every hand of the session from the hero's point of
+// view, separated by blank lines as the PokerStars client does.
+func FormatSession(session *Session, heroID string) string {
+	var sb strings.Builder
+	for _, hand := range session.Hands {
+		sb.WriteString(FormatPokerStars(hand, heroID))
+		sb.WriteString("\n\n")
+	}
+
+	return sb.String()
+}
+
+// ExportSessions builds a zip archive with one text file per session.
+func ExportSessions(hands []*models.HandRecord, heroID string) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, session := range SplitSessions(hands) {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     session.FileName(),
+			Method:   zip.Deflate,
+			Modified: session.StartedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := w.Write([]byte(FormatSession(session, heroID))); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package handhistory
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/common/data"
+	"github.com/ahmetkoprulu/rtrp/models"
+)
+
+type PgHandHistoryStore struct {
+	db *data.PgDbContext
+}
+
+func NewPgHandHistoryStore(db *data.PgDbContext) HandHistoryStore {
+	return &PgHandHistoryStore{db: db}
+}
+
+func (s *PgHandHistoryStore) GetPlayerHands(ctx context.Context, playerID string, from, to time.Time) ([]*models.HandRecord, error) {
+	query := `
+		SELECT hh.hand
+		FROM hand_history_players hhp
+		JOIN hand_histories hh ON hh.id = hhp.hand_id
+		WHERE hhp.player_id = $1 AND hhp.started_at >= $2 AND hhp.started_at < $3
+		ORDER BY hhp.started_at
+	`
+
+	rows, err := s.db.Query(ctx, query, playerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hands := []*models.HandRecord{}
+	for rows.Next() {
+		var handBytes []byte
+		if err := rows.Scan(&handBytes); err != nil {
+			return nil, err
+		}
+
+		var hand models.HandRecord
+		if err := json.Unmarshal(handBytes, &hand); err != nil {
+			return nil, err
+		}
+		hands = append(hands, &hand)
+	}
+
+	return hands, rows.Err()
+}
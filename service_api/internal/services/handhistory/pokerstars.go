@@ -0,0 +1,464 @@
+package handhistory
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ahmetkoprulu/rtrp/models"
+)
+
+// PokerStarsDateFormat is the timestamp layout used in hand headers. Hands are
+// always written in UTC.
+const PokerStarsDateFormat = "2006/01/02 15:04:05"
+
+// FormatPokerStars writes a hand in the PokerStars text format understood by
+// trackers and replayers. Only the hero's hole cards and the hands shown at
+// showdown are included.
+func FormatPokerStars(hand *models.HandRecord, heroID string) string {
+	f := &pokerStarsFormatter{hand: hand, heroID: heroID, names: make(map[string]string)}
+	return f.format()
+}
+
+type pokerStarsFormatter struct {
+	hand   *models.HandRecord
+	heroID string
+	names  map[string]string
+	sb     strings.Builder
+}
+
+func (f *pokerStarsFormatter) format() string {
+	for _, seat := range f.hand.Seats {
+		name := seat.Username
+		if name == "" {
+			name = seat.PlayerID
+		}
+		f.names[seat.PlayerID] = name
+	}
+
+	f.writeHeader()
+	f.writeStreets()
+	f.writeShowdown()
+	f.writeSummary()
+
+	return f.sb.String()
+}
+
+func gameName(hand *models.HandRecord) string {
+	game := "Hold'em"
+	if hand.GameType == models.HandGameTypeOmaha {
+		game = "Omaha"
+	}
+
+	switch hand.BettingStructure {
+	case models.HandBettingPotLimit:
+		return game + " Pot Limit"
+	case models.HandBettingFixedLimit:
+		return game + " Limit"
+	case models.HandBettingNoLimit:
+		return game + " No Limit"
+	}
+
+	// Hands recorded before the structure was stored
+	if hand.GameType == models.HandGameTypeOmaha {
+		return game + " Pot Limit"
+	}
+	return game + " No Limit"
+}
+
+func (f *pokerStarsFormatter) writeHeader() {
+	hand := f.hand
+	f.line("PokerStars Hand #%d:  %s (%d/%d) - %s UTC",
+		HandNumber(hand.HandID), gameName(hand), hand.SmallBlindAmount, hand.BigBlindAmount, hand.StartedAt.UTC().Format(PokerStarsDateFormat))
+
+	tableName := hand.RoomName
+	if tableName == "" {
+		tableName = hand.RoomID
+	}
+	f.line("Table '%s' %d-max Seat #%d is the button", tableName, max(hand.MaxPlayers, len(hand.Seats)), hand.DealerPosition+1)
+
+	for _, seat := range hand.Seats {
+		f.line("Seat %d: %s (%d in chips)", seat.Position+1, f.names[seat.PlayerID], seat.StartingStack)
+	}
+
+	// Antes are posted before the blinds
+	for _, blind := range hand.Blinds {
+		if blind.Type == "ante" {
+			f.line("%s: posts the ante %d", f.names[blind.PlayerID], blind.Amount)
+		}
+	}
+
+	// A missed small blind is posted dead together with the big blind
+	dead := make(map[string]int)
+	for _, blind := range hand.Blinds {
+		if blind.Type == "dead_small_blind" {
+			dead[blind.PlayerID] += blind.Amount
+		}
+	}
+
+	for _, blind := range hand.Blinds {
+		switch blind.Type {
+		case "small_blind":
+			f.line("%s: posts small blind %d", f.names[blind.PlayerID], blind.Amount)
+		case "big_blind":
+			if amount, ok := dead[blind.PlayerID]; ok {
+				f.line("%s: posts small & big blinds %d", f.names[blind.PlayerID], blind.Amount+amount)
+				delete(dead, blind.PlayerID)
+				continue
+			}
+			f.line("%s: posts big blind %d", f.names[blind.PlayerID], blind.Amount)
+		}
+	}
+
+	for _, blind := range hand.Blinds {
+		if amount, ok := dead[blind.PlayerID]; ok {
+			f.line("%s: posts small blind %d", f.names[blind.PlayerID], amount)
+			delete(dead, blind.PlayerID)
+		}
+	}
+}
+
+func (f *pokerStarsFormatter) writeStreets() {
+	hand := f.hand
+
+	// Chips each player has put in on the current street and the bet to call
+	committed := make(map[string]int)
+	currentBet := 0
+	for _, blind := range hand.Blinds {
+		// Dead blinds and antes do not count towards the bet
+		if blind.Type == "dead_small_blind" || blind.Type == "ante" {
+			continue
+		}
+		committed[blind.PlayerID] += blind.Amount
+		currentBet = max(currentBet, committed[blind.PlayerID])
+	}
+
+	f.line("*** HOLE CARDS ***")
+	for _, seat := range hand.Seats {
+		if seat.PlayerID == f.heroID {
+			f.line("Dealt to %s %s", f.names[seat.PlayerID], formatCards(seat.HoleCards))
+		}
+	}
+
+	for round := models.HandRoundPreFlop; round <= models.HandRoundRiver; round++ {
+		if round > models.HandRoundPreFlop {
+			if !f.writeStreetHeader(round) {
+				return
+			}
+			committed = make(map[string]int)
+			currentBet = 0
+		}
+
+		for _, action := range hand.Actions {
+			if action.Round != round {
+				continue
+			}
+
+			name := f.names[action.PlayerID]
+			switch action.Action {
+			case models.HandActionFold:
+				f.line("%s: folds", name)
+			case models.HandActionCheck:
+				f.line("%s: checks", name)
+			case models.HandActionCall:
+				f.line("%s: calls %d", name, action.Amount)
+				committed[action.PlayerID] += action.Amount
+			case models.HandActionBet:
+				f.line("%s: bets %d", name, action.Amount)
+				committed[action.PlayerID] = action.Amount
+				currentBet = action.Amount
+			case models.HandActionRaise:
+				f.line("%s: raises %d to %d", name, action.Amount-currentBet, action.Amount)
+				committed[action.PlayerID] = action.Amount
+				currentBet = action.Amount
+			case models.HandActionAllIn:
+				// The all-in amount is the player's total bet on the street
+				switch {
+				case action.Amount <= currentBet:
+					f.line("%s: calls %d and is all-in", name, action.Amount-committed[action.PlayerID])
+				case currentBet == 0:
+					f.line("%s: bets %d and is all-in", name, action.Amount)
+				default:
+					f.line("%s: raises %d to %d and is all-in", name, action.Amount-currentBet, action.Amount)
+				}
+				committed[action.PlayerID] = action.Amount
+				currentBet = max(currentBet, action.Amount)
+			}
+		}
+	}
+}
+
+// writeStreetHeader writes the street line and reports whether the street was
+// dealt in this hand.
+func (f *pokerStarsFormatter) writeStreetHeader(round models.HandRound) bool {
+	board := f.hand.Board
+	switch round {
+	case models.HandRoundFlop:
+		if len(board) < 3 {
+			return false
+		}
+		f.line("*** FLOP *** %s", formatCards(board[:3]))
+	case models.HandRoundTurn:
+		if len(board) < 4 {
+			return false
+		}
+		f.line("*** TURN *** %s %s", formatCards(board[:3]), formatCards(board[3:4]))
+	case models.HandRoundRiver:
+		if len(board) < 5 {
+			return false
+		}
+		f.line("*** RIVER *** %s %s", formatCards(board[:4]), formatCards(board[4:5]))
+	}
+
+	return true
+}
+
+func (f *pokerStarsFormatter) writeShowdown() {
+	showdown := false
+	for _, seat := range f.hand.Seats {
+		if seat.WentToShowdown {
+			showdown = true
+			break
+		}
+	}
+
+	if showdown {
+		f.line("*** SHOW DOWN ***")
+		for _, seat := range f.hand.Seats {
+			if !seat.WentToShowdown {
+				continue
+			}
+
+			if winner := f.winner(seat.PlayerID); winner != nil {
+				f.line("%s: shows %s (%s)", f.names[seat.PlayerID], formatCards(seat.HoleCards), DescribeHand(*winner))
+			} else {
+				f.line("%s: shows %s", f.names[seat.PlayerID], formatCards(seat.HoleCards))
+			}
+		}
+	}
+
+	if f.hasPotWinners() {
+		// Side pots are collected before the main pot, as dealt out at
+		// the table.
+		for i := len(f.hand.Pots) - 1; i >= 0; i-- {
+			for _, share := range f.hand.Pots[i].Winners {
+				f.line("%s collected %d from %s", f.names[share.PlayerID], share.Amount, f.potName(i))
+			}
+		}
+		return
+	}
+
+	for _, winner := range f.hand.Winners {
+		if winner.Amount > 0 {
+			f.line("%s collected %d from pot", f.names[winner.PlayerID], winner.Amount)
+		}
+	}
+}
+
+func (f *pokerStarsFormatter) hasPotWinners() bool {
+	for _, pot := range f.hand.Pots {
+		if len(pot.Winners) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *pokerStarsFormatter) potName(index int) string {
+	switch {
+	case len(f.hand.Pots) == 1:
+		return "pot"
+	case index == 0:
+		return "main pot"
+	}
+
+	return fmt.Sprintf("side pot-%d", index)
+}
+
+func (f *pokerStarsFormatter) writeSummary() {
+	hand := f.hand
+	f.line("*** SUMMARY ***")
+
+	if len(hand.Pots) > 1 {
+		pots := fmt.Sprintf("Main pot %d.", hand.Pots[0].Amount)
+		for i, pot := range hand.Pots[1:] {
+			pots += fmt.Sprintf(" Side pot-%d %d.", i+1, pot.Amount)
+		}
+		f.line("Total pot %d %s | Rake 0", hand.TotalPot, pots)
+	} else {
+		f.line("Total pot %d | Rake 0", hand.TotalPot)
+	}
+
+	if len(hand.SecondBoard) > 0 {
+		f.line("Hand was run twice")
+		f.line("FIRST Board %s", formatCards(hand.Board))
+		f.line("SECOND Board %s", formatCards(hand.SecondBoard))
+	} else if len(hand.Board) > 0 {
+		f.line("Board %s", formatCards(hand.Board))
+	}
+
+	for _, seat := range hand.Seats {
+		name := f.names[seat.PlayerID]
+		if seat.Position == hand.DealerPosition {
+			name += " (button)"
+		}
+		if seat.Position == hand.SmallBlindPosition {
+			name += " (small blind)"
+		} else if seat.Position == hand.BigBlindPosition {
+			name += " (big blind)"
+		}
+
+		winner := f.winner(seat.PlayerID)
+		switch {
+		case seat.WentToShowdown && winner != nil:
+			f.line("Seat %d: %s showed %s and won (%d) with %s", seat.Position+1, name, formatCards(seat.HoleCards), winner.Amount, DescribeHand(*winner))
+		case seat.WentToShowdown:
+			f.line("Seat %d: %s showed %s and lost", seat.Position+1, name, formatCards(seat.HoleCards))
+		case winner != nil:
+			f.line("Seat %d: %s collected (%d)", seat.Position+1, name, winner.Amount)
+		default:
+			f.line("Seat %d: %s %s", seat.Position+1, name, f.foldedOn(seat.PlayerID))
+		}
+	}
+}
+
+func (f *pokerStarsFormatter) foldedOn(playerID string) string {
+	for _, action := range f.hand.Actions {
+		if action.PlayerID != playerID || action.Action != models.HandActionFold {
+			continue
+		}
+
+		switch action.Round {
+		case models.HandRoundPreFlop:
+			return "folded before Flop"
+		case models.HandRoundFlop:
+			return "folded on the Flop"
+		case models.HandRoundTurn:
+			return "folded on the Turn"
+		default:
+			return "folded on the River"
+		}
+	}
+
+	return "mucked"
+}
+
+func (f *pokerStarsFormatter) winner(playerID string) *models.HandResult {
+	for i := range f.hand.Winners {
+		if f.hand.Winners[i].PlayerID == playerID && f.hand.Winners[i].Amount > 0 {
+			return &f.hand.Winners[i]
+		}
+	}
+
+	return nil
+}
+
+func (f *pokerStarsFormatter) line(format string, args ...any) {
+	fmt.Fprintf(&f.sb, format, args...)
+	f.sb.WriteString("\n")
+}
+
+// HandNumber maps a hand ID to the numeric hand number trackers expect.
+func HandNumber(handID string) uint64 {
+	sum := sha256.Sum256([]byte(handID))
+	return binary.BigEndian.Uint64(sum[:8]) % 1_000_000_000_000
+}
+
+func formatCards(cards []models.Card) string {
+	parts := make([]string, 0, len(cards))
+	for _, card := range cards {
+		// Hidden or malformed cards in a stored record are left out
+		if text, ok := formatCard(card); ok {
+			parts = append(parts, text)
+		}
+	}
+
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// formatCard writes the card as rank and suit, for example "Ah". It reports
+// false for a card without a known rank or a suit.
+func formatCard(card models.Card) (string, bool) {
+	if card.Value < 2 || card.Value > 14 || card.Suit == "" {
+		return "", false
+	}
+
+	return rankSymbol(card.Value) + card.Suit[:1], true
+}
+
+func rankSymbol(value int) string {
+	switch value {
+	case 10:
+		return "T"
+	case 11:
+		return "J"
+	case 12:
+		return "Q"
+	case 13:
+		return "K"
+	case 14:
+		return "A"
+	}
+
+	return fmt.Sprint(value)
+}
+
+var rankNames = map[int][2]string{
+	2:  {"Deuce", "Deuces"},
+	3:  {"Three", "Threes"},
+	4:  {"Four", "Fours"},
+	5:  {"Five", "Fives"},
+	6:  {"Six", "Sixes"},
+	7:  {"Seven", "Sevens"},
+	8:  {"Eight", "Eights"},
+	9:  {"Nine", "Nines"},
+	10: {"Ten", "Tens"},
+	11: {"Jack", "Jacks"},
+	12: {"Queen", "Queens"},
+	13: {"King", "Kings"},
+	14: {"Ace", "Aces"},
+}
+
+// DescribeHand returns the PokerStars description of a made hand, for example
+// "two pair, Kings and Fives".
+func DescribeHand(result models.HandResult) string {
+	card := func(i int) int {
+		if i < len(result.HighCards) {
+			return result.HighCards[i]
+		}
+		return 0
+	}
+	one := func(i int) string { return rankNames[card(i)][0] }
+	many := func(i int) string { return rankNames[card(i)][1] }
+	straight := func() string {
+		if card(0) == 5 {
+			return "Ace to Five"
+		}
+		return rankNames[card(0)-4][0] + " to " + one(0)
+	}
+
+	switch result.Rank {
+	case models.HandRankOnePair:
+		return "a pair of " + many(0)
+	case models.HandRankTwoPair:
+		return "two pair, " + many(0) + " and " + many(1)
+	case models.HandRankThreeOfAKind:
+		return "three of a kind, " + many(0)
+	case models.HandRankStraight:
+		return "a straight, " + straight()
+	case models.HandRankFlush:
+		return "a flush, " + one(0) + " high"
+	case models.HandRankFullHouse:
+		return "a full house, " + many(0) + " full of " + many(1)
+	case models.HandRankFourOfAKind:
+		return "four of a kind, " + many(0)
+	case models.HandRankStraightFlush:
+		return "a straight flush, " + straight()
+	case models.HandRankRoyalFlush:
+		return "a Royal Flush"
+	}
+
+	return "high card " + one(0)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ahmetkoprulu/rtrp/common/data"
+	"github.com/ahmetkoprulu/rtrp/models"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRoomNotFound      = errors.New("room not found")
+	ErrInvalidRoom       = errors.New("room needs an id, a name, a min bet and seats")
+	ErrInvalidRoomStatus = errors.New("invalid room status")
+)
+
+const roomColumns = `id, name, game_type, betting_structure, min_bet, max_players, max_game_players,
+	action_timeout_seconds, time_bank_seconds, min_buy_in, max_buy_in, status, created_at, updated_at`
+
+// RoomService keeps the definitions of the tables the game servers run, so
+// the tables survive restarts. The game servers validate the rules of a table
+// before saving it.
+type RoomService struct {
+	db *data.PgDbContext
+}
+
+func NewRoomService(db *data.PgDbContext) *RoomService {
+	return &RoomService{db: db}
+}
+
+// GetRooms returns the rooms that are not closed.
+func (s *RoomService) GetRooms(ctx context.Context) ([]models.Room, error) {
+	rows, err := s.db.Query(ctx, "SELECT "+roomColumns+" FROM rooms WHERE status <> $1 ORDER BY created_at", models.RoomStatusClosed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rooms := make([]models.Room, 0)
+	for rows.Next() {
+		room, err := scanRoom(rows)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, *room)
+	}
+
+	return rooms, rows.Err()
+}
+
+func (s *RoomService) GetRoom(ctx context.Context, id string) (*models.Room, error) {
+	room, err := scanRoom(s.db.QueryRow(ctx, "SELECT "+roomColumns+" FROM rooms WHERE id = $1", id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+
+	return room, nil
+}
+
+// SaveRoom creates the room or replaces the definition of the room with the
+// same ID.
+func (s *RoomService) SaveRoom(ctx context.Context, room *models.Room) (*models.Room, error) {
+	if room.ID == "" || room.Name == "" || room.MinBet <= 0 || room.MaxGamePlayers <= 0 {
+		return nil, ErrInvalidRoom
+	}
+
+	if room.Status == "" {
+		room.Status = models.RoomStatusActive
+	}
+
+	if !validRoomStatus(room.Status) {
+		return nil, ErrInvalidRoomStatus
+	}
+
+	return scanRoom(s.db.QueryRow(ctx, `
+		INSERT INTO rooms (id, name, game_type, betting_structure, min_bet, max_players, max_game_players,
+			action_timeout_seconds, time_bank_seconds, min_buy_in, max_buy_in, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			game_type = EXCLUDED.game_type,
+			betting_structure = EXCLUDED.betting_structure,
+			min_bet = EXCLUDED.min_bet,
+			max_players = EXCLUDED.max_players,
+			max_game_players = EXCLUDED.max_game_players,
+			action_timeout_seconds = EXCLUDED.action_timeout_seconds,
+			time_bank_seconds = EXCLUDED.time_bank_seconds,
+			min_buy_in = EXCLUDED.min_buy_in,
+			max_buy_in = EXCLUDED.max_buy_in,
+			status = EXCLUDED.status,
+			updated_at = NOW()
+		RETURNING `+roomColumns,
+		room.ID, room.Name, room.GameType, room.BettingStructure, room.MinBet, room.MaxPlayers, room.MaxGamePlayers,
+		room.ActionTimeoutSeconds, room.TimeBankSeconds, room.MinBuyIn, room.MaxBuyIn, room.Status))
+}
+
+// SetRoomStatus pauses, resumes or closes the room.
+func (s *RoomService) SetRoomStatus(ctx context.Context, id string, status models.RoomStatus) (*models.Room, error) {
+	if !validRoomStatus(status) {
+		return nil, ErrInvalidRoomStatus
+	}
+
+	room, err := scanRoom(s.db.QueryRow(ctx, `
+		UPDATE rooms SET status = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+roomColumns, id, status))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func validRoomStatus(status models.RoomStatus) bool {
+	switch status {
+	case models.RoomStatusActive, models.RoomStatusPaused, models.RoomStatusClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+func scanRoom(row pgx.Row) (*models.Room, error) {
+	var room models.Room
+	err := row.Scan(
+		&room.ID, &room.Name, &room.GameType, &room.BettingStructure, &room.MinBet, &room.MaxPlayers, &room.MaxGamePlayers,
+		&room.ActionTimeoutSeconds, &room.TimeBankSeconds, &room.MinBuyIn, &room.MaxBuyIn, &room.Status,
+		&room.CreatedAt, &room.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &room, nil
+}
@@ -0,0 +1,7 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_hand_history_players_player_id;
+DROP INDEX IF EXISTS idx_hand_histories_room_id;
+
+-- Drop tables in reverse order of creation (to handle foreign key constraints)
+DROP TABLE IF EXISTS hand_history_players;
+DROP TABLE IF EXISTS hand_histories;
@@ -0,0 +1,21 @@
+-- Create hand_histories table
+CREATE TABLE IF NOT EXISTS hand_histories (
+    id VARCHAR(36) PRIMARY KEY,
+    room_id VARCHAR(255) NOT NULL,
+    game_type INTEGER NOT NULL,
+    hand JSONB NOT NULL DEFAULT '{}'::jsonb,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create hand_history_players table to look up the hands a player was dealt into
+CREATE TABLE IF NOT EXISTS hand_history_players (
+    hand_id VARCHAR(36) NOT NULL REFERENCES hand_histories(id) ON DELETE CASCADE,
+    player_id VARCHAR(10) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (hand_id, player_id)
+);
+
+CREATE INDEX idx_hand_histories_room_id ON hand_histories(room_id, started_at);
+CREATE INDEX idx_hand_history_players_player_id ON hand_history_players(player_id, started_at);
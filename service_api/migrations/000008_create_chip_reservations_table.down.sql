@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_chip_reservations_player_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS chip_reservations;
@@ -0,0 +1,19 @@
+-- Create chip_reservations table to hold the chips a player brought to a table
+CREATE TABLE IF NOT EXISTS chip_reservations (
+    id VARCHAR(36) PRIMARY KEY,
+    player_id VARCHAR(10) NOT NULL REFERENCES players(id),
+    room_id VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'reserved',
+    settled_amount BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_chip_reservations_amount CHECK (amount > 0),
+    CONSTRAINT chk_chip_reservations_status CHECK (status IN ('reserved', 'released', 'settled'))
+);
+
+CREATE INDEX idx_chip_reservations_player_id ON chip_reservations(player_id) WHERE status = 'reserved';
+
+-- Add column comments
+COMMENT ON COLUMN chip_reservations.amount IS 'Chips taken from the wallet for a buy-in or a top-up';
+COMMENT ON COLUMN chip_reservations.settled_amount IS 'Chips given back to the wallet when the reservation was released or settled';
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_rooms_status;
+
+-- Drop tables
+DROP TABLE IF EXISTS rooms;
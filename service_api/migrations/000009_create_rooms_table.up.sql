@@ -0,0 +1,30 @@
+-- Create rooms table to hold the tables the game servers run
+CREATE TABLE IF NOT EXISTS rooms (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    game_type INT NOT NULL,
+    betting_structure VARCHAR(16) NOT NULL DEFAULT 'no_limit',
+    min_bet BIGINT NOT NULL,
+    max_players INT NOT NULL,
+    max_game_players INT NOT NULL,
+    action_timeout_seconds INT NOT NULL DEFAULT 0,
+    time_bank_seconds INT NOT NULL DEFAULT 0,
+    min_buy_in BIGINT NOT NULL DEFAULT 0,
+    max_buy_in BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_rooms_min_bet CHECK (min_bet > 0),
+    CONSTRAINT chk_rooms_status CHECK (status IN ('active', 'paused', 'closed'))
+);
+
+CREATE INDEX idx_rooms_status ON rooms(status);
+
+-- Add column comments
+COMMENT ON COLUMN rooms.min_bet IS 'Big blind of the table';
+COMMENT ON COLUMN rooms.max_players IS 'Players the room holds, seated or not';
+COMMENT ON COLUMN rooms.max_game_players IS 'Seats at the table';
+COMMENT ON COLUMN rooms.action_timeout_seconds IS 'Time to act, 0 for the game server default';
+COMMENT ON COLUMN rooms.time_bank_seconds IS 'Time bank of each player, 0 for the game server default';
+COMMENT ON COLUMN rooms.min_buy_in IS 'Smallest buy-in, 0 for the game server default';
+COMMENT ON COLUMN rooms.max_buy_in IS 'Largest buy-in, 0 for the game server default';
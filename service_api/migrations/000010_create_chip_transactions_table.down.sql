@@ -0,0 +1,10 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_chip_transactions_reservation_id;
+DROP INDEX IF EXISTS idx_chip_transactions_player_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS chip_transactions;
+
+-- Drop columns
+ALTER TABLE chip_reservations DROP CONSTRAINT IF EXISTS chk_chip_reservations_type;
+ALTER TABLE chip_reservations DROP COLUMN IF EXISTS type;
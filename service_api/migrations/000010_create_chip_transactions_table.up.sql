@@ -0,0 +1,22 @@
+-- Record why the chips of each reservation were taken
+ALTER TABLE chip_reservations ADD COLUMN type VARCHAR(16) NOT NULL DEFAULT 'buy_in';
+ALTER TABLE chip_reservations ADD CONSTRAINT chk_chip_reservations_type CHECK (type IN ('buy_in', 'top_up'));
+
+-- Create chip_transactions table to keep every chip movement of a reservation
+CREATE TABLE IF NOT EXISTS chip_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id VARCHAR(36) NOT NULL REFERENCES chip_reservations(id),
+    player_id VARCHAR(10) NOT NULL REFERENCES players(id),
+    room_id VARCHAR(255) NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    change BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_chip_transactions_type CHECK (type IN ('buy_in', 'top_up', 'cash_out', 'prize', 'refund'))
+);
+
+CREATE INDEX idx_chip_transactions_player_id ON chip_transactions(player_id);
+CREATE INDEX idx_chip_transactions_reservation_id ON chip_transactions(reservation_id);
+
+-- Add column comments
+COMMENT ON COLUMN chip_reservations.type IS 'buy_in or top_up';
+COMMENT ON COLUMN chip_transactions.change IS 'Chips added to the wallet, negative when taken from it';
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_chip_reservations_owner;
+
+-- Drop columns
+ALTER TABLE chip_reservations DROP COLUMN IF EXISTS session;
+ALTER TABLE chip_reservations DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,10 @@
+-- Record which game server holds each reservation, so a server that restarts
+-- can give back the chips its last run left reserved
+ALTER TABLE chip_reservations ADD COLUMN owner VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE chip_reservations ADD COLUMN session VARCHAR(36) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_chip_reservations_owner ON chip_reservations(owner) WHERE status = 'reserved';
+
+-- Add column comments
+COMMENT ON COLUMN chip_reservations.owner IS 'Game server instance that made the reservation';
+COMMENT ON COLUMN chip_reservations.session IS 'Run of the game server that made the reservation';
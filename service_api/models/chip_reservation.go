@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+type ChipReservationStatus string
+
+const (
+	ChipReservationStatusReserved ChipReservationStatus = "reserved"
+	ChipReservationStatusReleased ChipReservationStatus = "released"
+	ChipReservationStatusSettled  ChipReservationStatus = "settled"
+)
+
+// ChipTransactionType is why chips move between a player's wallet and a
+// table.
+type ChipTransactionType string
+
+const (
+	ChipTransactionBuyIn   ChipTransactionType = "buy_in"   // Wallet to table when the player sits down or registers
+	ChipTransactionTopUp   ChipTransactionType = "top_up"   // Wallet to table between hands
+	ChipTransactionCashOut ChipTransactionType = "cash_out" // Table to wallet when the player leaves
+	ChipTransactionPrize   ChipTransactionType = "prize"    // Tournament to wallet for the place the player finished in
+	ChipTransactionRefund  ChipTransactionType = "refund"   // Reserved chips given back for a seat the player never took
+)
+
+// ChipReservation holds the chips a player took from their wallet to a table
+// until they leave it. The game server picks the ID so retried calls find the
+// same reservation, and names itself and its run as the owner and session so
+// it can give back what a crashed run left reserved.
+type ChipReservation struct {
+	ID            string                `json:"id"`
+	PlayerID      string                `json:"player_id"`
+	RoomID        string                `json:"room_id"`
+	Amount        int64                 `json:"amount"`
+	Type          ChipTransactionType   `json:"type"`
+	Status        ChipReservationStatus `json:"status"`
+	SettledAmount *int64                `json:"settled_amount,omitempty"`
+	Owner         string                `json:"owner"`
+	Session       string                `json:"session"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+// ChipTransaction is one movement of chips between a player's wallet and a
+// table. Change is negative when chips leave the wallet.
+type ChipTransaction struct {
+	ID            int64               `json:"id"`
+	ReservationID string              `json:"reservation_id"`
+	PlayerID      string              `json:"player_id"`
+	RoomID        string              `json:"room_id"`
+	Type          ChipTransactionType `json:"type"`
+	Change        int64               `json:"change"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
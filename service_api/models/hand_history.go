@@ -0,0 +1,129 @@
+package models
+
+import "time"
+
+// Hand records are produced by the game server and stored as they were
+// published. The enums below mirror the game server's Holdem values.
+
+type HandGameType int
+
+const (
+	HandGameTypeHoldem HandGameType = 1
+	HandGameTypeOmaha  HandGameType = 2
+)
+
+type HandBettingStructure string
+
+const (
+	HandBettingNoLimit    HandBettingStructure = "no_limit"
+	HandBettingPotLimit   HandBettingStructure = "pot_limit"
+	HandBettingFixedLimit HandBettingStructure = "fixed_limit"
+)
+
+type HandRound int
+
+const (
+	HandRoundPreFlop HandRound = iota
+	HandRoundFlop
+	HandRoundTurn
+	HandRoundRiver
+	HandRoundShowdown
+)
+
+type HandActionType int
+
+const (
+	HandActionFold HandActionType = iota
+	HandActionCall
+	HandActionRaise
+	HandActionBet
+	HandActionCheck
+	HandActionAllIn
+)
+
+type HandRank int
+
+const (
+	HandRankHighCard HandRank = iota
+	HandRankOnePair
+	HandRankTwoPair
+	HandRankThreeOfAKind
+	HandRankStraight
+	HandRankFlush
+	HandRankFullHouse
+	HandRankFourOfAKind
+	HandRankStraightFlush
+	HandRankRoyalFlush
+)
+
+type Card struct {
+	Suit  string `json:"suit"`
+	Value int    `json:"value"`
+}
+
+type HandRecord struct {
+	HandID             string               `json:"hand_id"`
+	RoomID             string               `json:"room_id"`
+	RoomName           string               `json:"room_name"`
+	GameType           HandGameType         `json:"game_type"`
+	BettingStructure   HandBettingStructure `json:"betting_structure"`
+	MaxPlayers         int                  `json:"max_players"`
+	StartedAt          time.Time            `json:"started_at"`
+	EndedAt            time.Time            `json:"ended_at"`
+	DealerPosition     int                  `json:"dealer_position"`
+	SmallBlindPosition int                  `json:"small_blind_position"`
+	BigBlindPosition   int                  `json:"big_blind_position"`
+	SmallBlindAmount   int                  `json:"small_blind_amount"`
+	BigBlindAmount     int                  `json:"big_blind_amount"`
+	Seats              []HandRecordSeat     `json:"seats"`
+	Blinds             []HandRecordBlind    `json:"blinds"`
+	Actions            []HandRecordAction   `json:"actions"`
+	Board              []Card               `json:"board"`
+	SecondBoard        []Card               `json:"second_board,omitempty"`
+	LastRound          HandRound            `json:"last_round"`
+	TotalPot           int                  `json:"total_pot"`
+	Pots               []HandRecordPot      `json:"pots"`
+	Winners            []HandResult         `json:"winners"`
+}
+
+type HandRecordSeat struct {
+	Position       int    `json:"position"`
+	PlayerID       string `json:"player_id"`
+	Username       string `json:"username"`
+	StartingStack  int    `json:"starting_stack"`
+	FinalStack     int    `json:"final_stack"`
+	HoleCards      []Card `json:"hole_cards"`
+	WentToShowdown bool   `json:"went_to_showdown"`
+}
+
+type HandRecordBlind struct {
+	PlayerID string `json:"player_id"`
+	Type     string `json:"type"`
+	Amount   int    `json:"amount"`
+}
+
+type HandRecordAction struct {
+	PlayerID  string         `json:"player_id"`
+	Action    HandActionType `json:"action"`
+	Amount    int            `json:"amount"`
+	Round     HandRound      `json:"round"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+type HandRecordPot struct {
+	Amount            int            `json:"amount"`
+	EligiblePlayerIDs []string       `json:"eligible_player_ids"`
+	Winners           []HandPotShare `json:"winners"`
+}
+
+type HandPotShare struct {
+	PlayerID string `json:"player_id"`
+	Amount   int    `json:"amount"`
+}
+
+type HandResult struct {
+	Rank      HandRank `json:"rank"`
+	HighCards []int    `json:"high_cards"`
+	PlayerID  string   `json:"player_id"`
+	Amount    int      `json:"amount"`
+}
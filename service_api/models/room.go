@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+type RoomStatus string
+
+const (
+	RoomStatusActive RoomStatus = "active"
+	RoomStatusPaused RoomStatus = "paused" // Deals no new hands, players keep their seats
+	RoomStatusClosed RoomStatus = "closed" // Taken down, not loaded by the game servers
+)
+
+// Room is the definition of a table the game servers run. Zero timers and
+// buy-ins leave the game server defaults.
+type Room struct {
+	ID                   string     `json:"id"`
+	Name                 string     `json:"name"`
+	GameType             int        `json:"game_type"`
+	BettingStructure     string     `json:"betting_structure"`
+	MinBet               int64      `json:"min_bet"`
+	MaxPlayers           int        `json:"max_players"`
+	MaxGamePlayers       int        `json:"max_game_players"`
+	ActionTimeoutSeconds int        `json:"action_timeout_seconds"`
+	TimeBankSeconds      int        `json:"time_bank_seconds"`
+	MinBuyIn             int64      `json:"min_buy_in"`
+	MaxBuyIn             int64      `json:"max_buy_in"`
+	Status               RoomStatus `json:"status"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
@@ -42,7 +42,8 @@ func main() {
 	}
 
 	consumers := map[string]consumers.IConsumer{
-		"chip-update": consumers.NewChipUpdateConsumer("chip-update", db),
+		"chip-update":   consumers.NewChipUpdateConsumer("chip-update", db),
+		"hand-complete": consumers.NewHandCompleteConsumer("hand-complete", db),
 	}
 
 	consumerManager, err := internal.NewConsumerManager(db, consumers)
@@ -0,0 +1,90 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/ahmetkoprulu/rtrp/consumers/common/data"
+	"github.com/ahmetkoprulu/rtrp/consumers/internal/mq"
+	"github.com/ahmetkoprulu/rtrp/consumers/models"
+)
+
+type HandCompleteConsumer struct {
+	key      string
+	db       *data.PgDbContext
+	mqClient *mq.MqClient
+}
+
+func NewHandCompleteConsumer(key string, db *data.PgDbContext) *HandCompleteConsumer {
+	mqClient, err := mq.NewMqClient()
+	if err != nil {
+		log.Fatal("Failed to initialize MQ: ", err)
+	}
+
+	return &HandCompleteConsumer{key: key, db: db, mqClient: mqClient}
+}
+
+func (h *HandCompleteConsumer) Start(key string, wg *sync.WaitGroup) error {
+	// Declare queue (idempotent)
+	err := h.mqClient.Provider.DeclareQueue(mq.GameAuditsQueue, true, mq.GameAuditsRoutingKey, mq.GameExchange)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Starting %s consumer", key)
+
+	wg.Add(1)
+	defer wg.Done()
+
+	if err = h.mqClient.Provider.Subscribe(mq.GameAuditsQueue, key, h.Consume); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (h *HandCompleteConsumer) Consume(rawMsg []byte) error {
+	var msg models.HandCompleteMessage
+	err := json.Unmarshal(rawMsg, &msg)
+	if err != nil {
+		return err
+	}
+
+	var summary models.HandSummary
+	if err := json.Unmarshal(msg.Hand, &summary); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Consuming hand %s from room %s", h.key, msg.HandID, msg.RoomID)
+	return h.db.WithTransaction(context.Background(), func(tx data.QueryRunner) error {
+		// Redelivered messages are ignored, the hand is already stored
+		tag, err := tx.Exec(context.Background(), `
+			INSERT INTO hand_histories (id, room_id, game_type, hand, started_at, ended_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (id) DO NOTHING
+		`, msg.HandID, msg.RoomID, msg.GameType, []byte(msg.Hand), summary.StartedAt, summary.EndedAt)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		for _, seat := range summary.Seats {
+			_, err := tx.Exec(context.Background(), `
+				INSERT INTO hand_history_players (hand_id, player_id, started_at)
+				VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING
+			`, msg.HandID, seat.PlayerID, summary.StartedAt)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
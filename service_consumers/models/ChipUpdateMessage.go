@@ -7,4 +7,5 @@ type ChipUpdateMessage struct {
 type PlayerChipChange struct {
 	PlayerID string `json:"player_id"`
 	Change   int    `json:"change"`
+	Type     string `json:"type"` // buy_in, top_up, cash_out or prize
 }
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type HandCompleteMessage struct {
+	MessageID string          `json:"message_id"`
+	Timestamp time.Time       `json:"timestamp"`
+	GameType  int             `json:"game_type"`
+	RoomID    string          `json:"room_id"`
+	HandID    string          `json:"hand_id"`
+	Hand      json.RawMessage `json:"hand"`
+}
+
+// HandSummary holds the fields of the hand record needed to index it. The
+// record itself is stored as it was published.
+type HandSummary struct {
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Seats     []struct {
+		PlayerID string `json:"player_id"`
+	} `json:"seats"`
+}
@@ -27,15 +27,32 @@ func main() {
 	}
 
 	wsServer := internal.NewServer()
+	if err := wsServer.LoadRooms(); err != nil {
+		log.Fatal("Failed to load rooms: ", err)
+	}
 
+	go wsServer.ReleaseStaleReservations()
 	go wsServer.Run()
+	go wsServer.RunPools()
+	go wsServer.RunQuickSeats()
+	go wsServer.RunPrivateRooms()
 
 	http.Handle("/", http.FileServer(http.Dir("./public")))
 	http.HandleFunc("/ws", wsServer.HandleWebSocket)
 	http.HandleFunc("/rooms", wsServer.HandleRoomList)
+	http.HandleFunc("/tournaments", wsServer.HandleTournamentList)
 	http.HandleFunc("/admin/reset", wsServer.HandleReset)
+	http.HandleFunc("POST /admin/rooms", wsServer.HandleCreateRoom)
+	http.HandleFunc("PUT /admin/rooms/{id}", wsServer.HandleUpdateRoom)
+	http.HandleFunc("POST /admin/rooms/{id}/pause", wsServer.HandlePauseRoom)
+	http.HandleFunc("POST /admin/rooms/{id}/resume", wsServer.HandleResumeRoom)
+	http.HandleFunc("POST /admin/rooms/{id}/close", wsServer.HandleCloseRoom)
+	http.HandleFunc("PUT /admin/pools/{id}", wsServer.HandleSetPool)
+	http.HandleFunc("POST /admin/sit-and-gos", wsServer.HandleOpenSitAndGo)
+	http.HandleFunc("POST /admin/tournaments", wsServer.HandleOpenTournament)
+	http.HandleFunc("POST /admin/tournaments/{id}/cancel", wsServer.HandleCancelTournament)
 	log.Println("Starting game server on :" + config.ServerPort + "...")
-	log.Println("Default room created and ready for connections")
+	log.Println("Rooms loaded and ready for connections")
 	if err := http.ListenAndServe(":"+config.ServerPort, nil); err != nil {
 		log.Fatal("ListenAndServe: ", err)
 	}
@@ -50,7 +67,6 @@ func initMq(config *models.Config) (*mq.MqClient, error) {
 	}
 
 	mqProvider.Provider.DeclareExchange(mq.GameExchange, "topic", true)
-	// mqProvider.Provider.DeclareQueue(mq.ChipUpdatesQueue, true, mq.ChipUpdatesRoutingKey, mq.GameExchange)
 	// mqProvider.Provider.DeclareQueue(mq.GameAnalyticsQueue, true, mq.GameAnalyticsRoutingKey, mq.GameExchange)
 
 	return mqProvider, nil
@@ -11,6 +11,9 @@ type ApiService struct {
 	clientFactory *Factory
 	AuthService   *AuthService
 	PlayerService *PlayerService
+
+	ReservationService *ReservationService
+	RoomService        *RoomService
 }
 
 func NewApiService() *ApiService {
@@ -32,6 +35,8 @@ func NewApiService() *ApiService {
 
 	service.AuthService = NewAuthService(service, "/auth")
 	service.PlayerService = NewPlayerService(service, "/players")
+	service.ReservationService = NewReservationService(service, "/chip-reservations", config.InstanceID)
+	service.RoomService = NewRoomService(service, "/rooms")
 
 	return service
 }
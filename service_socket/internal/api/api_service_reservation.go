@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/internal/mq"
+	"github.com/google/uuid"
+)
+
+// ErrInsufficientChips is returned when the player's wallet does not hold the
+// chips to reserve.
+var ErrInsufficientChips = errors.New("insufficient chips")
+
+// ReservationService holds the chips players take to a table in their wallets
+// until they leave it. Every call can be retried with the same reservation ID.
+// Reservations are made in the name of the game server instance and this run
+// of it, so the next run can release what this one leaves open.
+type ReservationService struct {
+	parent   *ApiService
+	endpoint string
+	client   *ApiClient
+	owner    string
+	session  string
+}
+
+func NewReservationService(parent *ApiService, endpoint string, owner string) *ReservationService {
+	service := &ReservationService{
+		parent:   parent,
+		endpoint: parent.config.BaseURL + endpoint,
+		owner:    owner,
+		session:  uuid.New().String(),
+	}
+	service.client = parent.getClient("reservation-service", endpoint)
+
+	return service
+}
+
+// Reserve holds amount chips of the player's wallet for their seat at the
+// room. Every buy-in and top-up is a reservation of its own, a top-up
+// reserves only the chips it adds under a new ID.
+func (s *ReservationService) Reserve(reservationID, playerID, roomID string, transaction mq.ChipTransactionType, amount int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response ApiResponse[ChipReservation]
+
+	err := s.client.Put(ctx, s.endpoint+"/"+reservationID, ReserveChipsRequest{
+		PlayerID: playerID,
+		RoomID:   roomID,
+		Owner:    s.owner,
+		Session:  s.session,
+		Type:     transaction,
+		Amount:   amount,
+	}, &response)
+	if err != nil {
+		if strings.Contains(err.Error(), ErrInsufficientChips.Error()) {
+			return ErrInsufficientChips
+		}
+		return fmt.Errorf("failed to reserve chips: %w", err)
+	}
+
+	return nil
+}
+
+// Release gives all the reserved chips back to the wallet.
+func (s *ReservationService) Release(reservationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response ApiResponse[ChipReservation]
+
+	err := s.client.Post(ctx, s.endpoint+"/"+reservationID+"/release", nil, &response)
+	if err != nil {
+		// Never made, nothing to give back
+		if strings.Contains(err.Error(), "status=404") {
+			return nil
+		}
+		return fmt.Errorf("failed to release chips: %w", err)
+	}
+
+	return nil
+}
+
+// Settle closes the reservation and credits the stack the player left the
+// table with, or their prize, to their wallet.
+func (s *ReservationService) Settle(reservationID string, transaction mq.ChipTransactionType, amount int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response ApiResponse[ChipReservation]
+
+	err := s.client.Post(ctx, s.endpoint+"/"+reservationID+"/settle", SettleChipsRequest{
+		Type:   transaction,
+		Amount: amount,
+	}, &response)
+	if err != nil {
+		return fmt.Errorf("failed to settle chips: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseStale releases the reservations earlier runs of this instance left
+// open and returns how many there were.
+func (s *ReservationService) ReleaseStale() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var response ApiResponse[ReleaseStaleChipsResponse]
+
+	err := s.client.Post(ctx, s.endpoint+"/release-stale", ReleaseStaleChipsRequest{
+		Owner:   s.owner,
+		Session: s.session,
+	}, &response)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release stale reservations: %w", err)
+	}
+
+	return response.Data.Released, nil
+}
+
+type ReserveChipsRequest struct {
+	PlayerID string                 `json:"player_id"`
+	RoomID   string                 `json:"room_id"`
+	Owner    string                 `json:"owner"`
+	Session  string                 `json:"session"`
+	Type     mq.ChipTransactionType `json:"type"`
+	Amount   int                    `json:"amount"`
+}
+
+type SettleChipsRequest struct {
+	Type   mq.ChipTransactionType `json:"type"`
+	Amount int                    `json:"amount"`
+}
+
+type ReleaseStaleChipsRequest struct {
+	Owner   string `json:"owner"`
+	Session string `json:"session"`
+}
+
+type ReleaseStaleChipsResponse struct {
+	Released int `json:"released"`
+}
+
+type ChipReservation struct {
+	ID            string `json:"id"`
+	PlayerID      string `json:"player_id"`
+	RoomID        string `json:"room_id"`
+	Amount        int    `json:"amount"`
+	Type          string `json:"type"`
+	Status        string `json:"status"`
+	SettledAmount *int   `json:"settled_amount"`
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+// RoomService keeps the definitions of the rooms the server runs.
+type RoomService struct {
+	parent   *ApiService
+	endpoint string
+	client   *ApiClient
+}
+
+func NewRoomService(parent *ApiService, endpoint string) *RoomService {
+	service := &RoomService{
+		parent:   parent,
+		endpoint: parent.config.BaseURL + endpoint,
+	}
+	service.client = parent.getClient("room-service", endpoint)
+
+	return service
+}
+
+// GetRooms returns the definitions of the rooms that are not closed.
+func (s *RoomService) GetRooms() ([]models.RoomDefinition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response ApiResponse[[]models.RoomDefinition]
+
+	err := s.client.Get(ctx, s.endpoint, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rooms: %w", err)
+	}
+
+	return response.Data, nil
+}
+
+// SaveRoom creates the room or replaces its definition.
+func (s *RoomService) SaveRoom(room models.RoomDefinition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response ApiResponse[models.RoomDefinition]
+
+	err := s.client.Put(ctx, s.endpoint+"/"+room.ID, room, &response)
+	if err != nil {
+		return fmt.Errorf("failed to save room: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RoomService) SetRoomStatus(roomID string, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response ApiResponse[models.RoomDefinition]
+
+	err := s.client.Put(ctx, s.endpoint+"/"+roomID+"/status", SetRoomStatusRequest{
+		Status: status,
+	}, &response)
+	if err != nil {
+		return fmt.Errorf("failed to set room status: %w", err)
+	}
+
+	return nil
+}
+
+type SetRoomStatusRequest struct {
+	Status string `json:"status"`
+}
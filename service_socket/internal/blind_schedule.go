@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+var (
+	ErrorBlindScheduleInvalid = errors.New("blind schedule needs a known preset or levels with blinds, an ante below the big blind and a duration")
+	ErrorBlindScheduleRunning = errors.New("blinds follow the room's blind schedule")
+)
+
+// BlindSchedulePresets are the named blind schedules rooms and tournaments can
+// pick instead of listing their levels. They start at a big blind of 20, cash
+// tables scale them to their own big blind.
+var BlindSchedulePresets = map[string][]models.BlindLevel{
+	"turbo": {
+		{SmallBlind: 10, BigBlind: 20, DurationSeconds: 180},
+		{SmallBlind: 20, BigBlind: 40, DurationSeconds: 180},
+		{SmallBlind: 30, BigBlind: 60, DurationSeconds: 180},
+		{SmallBlind: 50, BigBlind: 100, Ante: 10, DurationSeconds: 180},
+		{SmallBlind: 75, BigBlind: 150, Ante: 15, DurationSeconds: 180},
+		{SmallBlind: 100, BigBlind: 200, Ante: 25, DurationSeconds: 180},
+		{SmallBlind: 150, BigBlind: 300, Ante: 25, DurationSeconds: 180},
+		{SmallBlind: 200, BigBlind: 400, Ante: 50, DurationSeconds: 180},
+		{SmallBlind: 300, BigBlind: 600, Ante: 75, DurationSeconds: 180},
+		{SmallBlind: 500, BigBlind: 1000, Ante: 100, DurationSeconds: 180},
+	},
+	"regular": {
+		{SmallBlind: 10, BigBlind: 20, DurationSeconds: 600},
+		{SmallBlind: 15, BigBlind: 30, DurationSeconds: 600},
+		{SmallBlind: 25, BigBlind: 50, DurationSeconds: 600},
+		{SmallBlind: 50, BigBlind: 100, Ante: 10, DurationSeconds: 600},
+		{SmallBlind: 75, BigBlind: 150, Ante: 15, DurationSeconds: 600},
+		{SmallBlind: 100, BigBlind: 200, Ante: 25, DurationSeconds: 600},
+		{SmallBlind: 150, BigBlind: 300, Ante: 25, DurationSeconds: 600},
+		{SmallBlind: 200, BigBlind: 400, Ante: 50, DurationSeconds: 600},
+		{SmallBlind: 300, BigBlind: 600, Ante: 75, DurationSeconds: 600},
+		{SmallBlind: 400, BigBlind: 800, Ante: 100, DurationSeconds: 600},
+		{SmallBlind: 600, BigBlind: 1200, Ante: 150, DurationSeconds: 600},
+		{SmallBlind: 1000, BigBlind: 2000, Ante: 200, DurationSeconds: 600},
+	},
+	"deep": {
+		{SmallBlind: 10, BigBlind: 20, DurationSeconds: 900},
+		{SmallBlind: 15, BigBlind: 30, DurationSeconds: 900},
+		{SmallBlind: 20, BigBlind: 40, DurationSeconds: 900},
+		{SmallBlind: 25, BigBlind: 50, DurationSeconds: 900},
+		{SmallBlind: 30, BigBlind: 60, DurationSeconds: 900},
+		{SmallBlind: 40, BigBlind: 80, DurationSeconds: 900},
+		{SmallBlind: 50, BigBlind: 100, Ante: 10, DurationSeconds: 900},
+		{SmallBlind: 60, BigBlind: 120, Ante: 10, DurationSeconds: 900},
+		{SmallBlind: 75, BigBlind: 150, Ante: 15, DurationSeconds: 900},
+		{SmallBlind: 100, BigBlind: 200, Ante: 20, DurationSeconds: 900},
+		{SmallBlind: 125, BigBlind: 250, Ante: 25, DurationSeconds: 900},
+		{SmallBlind: 150, BigBlind: 300, Ante: 30, DurationSeconds: 900},
+		{SmallBlind: 200, BigBlind: 400, Ante: 40, DurationSeconds: 900},
+		{SmallBlind: 250, BigBlind: 500, Ante: 50, DurationSeconds: 900},
+		{SmallBlind: 300, BigBlind: 600, Ante: 60, DurationSeconds: 900},
+		{SmallBlind: 400, BigBlind: 800, Ante: 80, DurationSeconds: 900},
+		{SmallBlind: 500, BigBlind: 1000, Ante: 100, DurationSeconds: 900},
+	},
+}
+
+// blindSchedule returns the levels given, or else those of the named preset.
+// Neither leaves no schedule.
+func blindSchedule(levels []models.BlindLevel, preset string) ([]models.BlindLevel, error) {
+	if len(levels) > 0 || preset == "" {
+		return levels, nil
+	}
+
+	levels, ok := BlindSchedulePresets[preset]
+	if !ok {
+		return nil, ErrorBlindScheduleInvalid
+	}
+
+	return levels, nil
+}
+
+// roomBlindSchedule returns the blind schedule of a cash table, nil when its
+// blinds stay at the min bet. Presets are scaled to start at the table's big
+// blind.
+func roomBlindSchedule(definition models.RoomDefinition) ([]models.BlindLevel, error) {
+	levels, err := blindSchedule(definition.BlindLevels, definition.BlindSchedule)
+	if err != nil || len(levels) == 0 {
+		return nil, err
+	}
+
+	if len(definition.BlindLevels) == 0 {
+		levels = scaleBlindLevels(levels, definition.MinBet)
+	}
+
+	if !validBlindLevels(levels) {
+		return nil, ErrorBlindScheduleInvalid
+	}
+
+	return levels, nil
+}
+
+// scaleBlindLevels sizes the levels for a schedule starting at the big blind.
+func scaleBlindLevels(levels []models.BlindLevel, bigBlind int) []models.BlindLevel {
+	base := levels[0].BigBlind
+	scaled := make([]models.BlindLevel, len(levels))
+	for i, level := range levels {
+		scaled[i] = models.BlindLevel{
+			SmallBlind:      max(level.SmallBlind*bigBlind/base, 1),
+			BigBlind:        max(level.BigBlind*bigBlind/base, 1),
+			Ante:            level.Ante * bigBlind / base,
+			DurationSeconds: level.DurationSeconds,
+		}
+	}
+
+	return scaled
+}
+
+func validBlindLevels(levels []models.BlindLevel) bool {
+	if len(levels) == 0 {
+		return false
+	}
+
+	for _, level := range levels {
+		if level.BigBlind <= 0 || level.SmallBlind <= 0 || level.SmallBlind > level.BigBlind ||
+			level.Ante < 0 || level.Ante >= level.BigBlind || level.DurationSeconds <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// blindClock moves a table or a tournament up its blind levels as their time
+// runs out. The last level lasts until the clock stops. Tables follow the
+// clock between hands.
+type blindClock struct {
+	levels  []models.BlindLevel
+	level   int
+	endsAt  time.Time // When the level being played is over, zero on the last level
+	timer   *time.Timer
+	started bool
+	stopped bool
+	onLevel func(level int, blinds models.BlindLevel) // Called as each level after the first starts, may be nil
+	mu      sync.Mutex
+}
+
+func newBlindClock(levels []models.BlindLevel, onLevel func(level int, blinds models.BlindLevel)) *blindClock {
+	return &blindClock{levels: levels, onLevel: onLevel}
+}
+
+// start runs the clock from the first level. A clock runs once, starting it
+// again does nothing.
+func (c *blindClock) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.started {
+		return
+	}
+
+	c.started = true
+	c.schedule()
+}
+
+func (c *blindClock) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stopped = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// current returns the index of the level being played, its blinds and when it
+// is over. The time is zero on the last level and before the clock starts.
+func (c *blindClock) current() (int, models.BlindLevel, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.level, c.levels[c.level], c.endsAt
+}
+
+// schedule moves to the next level when the one being played is over. The
+// caller holds mu.
+func (c *blindClock) schedule() {
+	c.endsAt = time.Time{}
+	if c.stopped || c.level >= len(c.levels)-1 {
+		return
+	}
+
+	duration := time.Duration(c.levels[c.level].DurationSeconds) * time.Second
+	c.endsAt = time.Now().Add(duration)
+	c.timer = time.AfterFunc(duration, c.next)
+}
+
+func (c *blindClock) next() {
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		return
+	}
+
+	c.level++
+	level, blinds := c.level, c.levels[c.level]
+	c.schedule()
+	c.mu.Unlock()
+
+	if c.onLevel != nil {
+		c.onLevel(level, blinds)
+	}
+}
@@ -11,35 +11,58 @@ import (
 )
 
 type Client struct {
-	User           *models.User    `json:"user"`
-	authToken      string          `json:"-"`
-	IpAddress      string          `json:"-"`
-	ConnectionTime time.Time       `json:"-"`
-	ConnectCount   int             `json:"-"`
-	DisconnectTime time.Time       `json:"-"`
-	IdleTime       time.Time       `json:"-"`
-	Conn           *websocket.Conn `json:"-"`
-	Server         *Server         `json:"-"`
-	mu             sync.Mutex      `json:"-"`
-	CurrentRoom    *Room           `json:"-"`
-	CurrentGame    *Game           `json:"-"`
-	IsDisconnected bool            `json:"-"`
-	send           chan []byte     `json:"-"`
+	User           *models.User     `json:"user"`
+	authToken      string           `json:"-"`
+	IpAddress      string           `json:"-"`
+	ConnectionTime time.Time        `json:"-"`
+	ConnectCount   int              `json:"-"`
+	DisconnectTime time.Time        `json:"-"`
+	IdleTime       time.Time        `json:"-"`
+	Conn           *websocket.Conn  `json:"-"`
+	Server         *Server          `json:"-"`
+	mu             sync.Mutex       `json:"-"`
+	rooms          map[string]*Room `json:"-"` // Rooms the client plays or watches in
+	roomsMu        sync.Mutex       `json:"-"`
+	IsDisconnected bool             `json:"-"`
+	send           chan []byte      `json:"-"`
+	done           chan struct{}    `json:"-"` // Closed to stop writePump
+	sendMu         sync.Mutex       `json:"-"`
+	closeOnce      sync.Once        `json:"-"`
 }
 
 func (c *Client) readPump() {
 	defer func() {
-		// Remove player from game when disconnected
-		room, err := c.Server.roomManager.GetRoomByPlayerID(c.User.Player.ID)
-		if err != nil {
-			return
-		}
+		c.MarkDisconnected()
+
+		left, held := make([]*Room, 0), make([]*Room, 0)
+		for _, room := range c.Rooms() {
+			// Spectators only need to stop receiving the room's messages
+			if room.IsSpectator(c.User.Player.ID) {
+				room.RemoveSpectator(c.User.Player.ID)
+				continue
+			}
 
-		if room != nil && room.Game != nil {
-			if err := c.Server.handler.roomManager.LeaveRoom(room.ID, c.User.Player.ID); err != nil {
-				log.Printf("Error removing player from game: %v", err)
+			if room.Game == nil || !room.HasClient(c) {
+				continue
 			}
-			// Broadcast the updated room state to other players
+
+			if room.Game.IsSeated(c.User.Player.ID) && c.Server.ReconnectGracePeriod > 0 {
+				// Keep the seat so the player can reconnect to the hand
+				held = append(held, room)
+			} else {
+				if err := c.Server.handler.roomManager.LeaveRoom(room.ID, c.User.Player.ID); err != nil {
+					log.Printf("Error removing player from game: %v", err)
+				}
+			}
+			left = append(left, room)
+		}
+
+		if len(held) > 0 {
+			c.Server.HoldSeats(c, held)
+		}
+
+		// Broadcast the updated room states to other players
+		for _, room := range left {
 			c.Server.handler.broadcastRoomState(room)
 		}
 
@@ -71,37 +94,129 @@ func (c *Client) writePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
-			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
+		case <-c.done:
+			c.mu.Lock()
+			c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+			c.mu.Unlock()
+			return
 
+		case message := <-c.send:
 			c.mu.Lock()
 			err := c.Conn.WriteMessage(websocket.TextMessage, message)
 			c.mu.Unlock()
 
 			if err != nil {
+				// Nothing reads the send channel any more, so stop queueing
 				log.Printf("error writing message: %v", err)
+				c.closeSend()
 				return
 			}
 		}
 	}
 }
 
+// joinRoom records that the client plays or watches in the room.
+func (c *Client) joinRoom(room *Room) {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+
+	if c.rooms == nil {
+		c.rooms = make(map[string]*Room)
+	}
+	c.rooms[room.ID] = room
+}
+
+func (c *Client) leaveRoom(room *Room) {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+
+	if c.rooms[room.ID] == room {
+		delete(c.rooms, room.ID)
+	}
+}
+
+// Rooms returns the rooms the client plays or watches in.
+func (c *Client) Rooms() []*Room {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+
+	rooms := make([]*Room, 0, len(c.rooms))
+	for _, room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+
+	return rooms
+}
+
 func (c *Client) Touch() {
 	c.IdleTime = time.Now().Add(c.Server.IdlePlayerTime)
 }
 
 func (c *Client) Broadcast(message interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	msg, err := json.Marshal(message)
 	if err != nil {
 		log.Printf("error marshalling message: %v", err)
 		return
 	}
 
-	c.send <- msg
+	c.Send(msg)
+}
+
+// Send queues the message for the client. Messages to a client whose
+// connection has dropped are discarded. A client too slow to empty its buffer
+// is disconnected, so the sender never waits on it.
+func (c *Client) Send(msg []byte) {
+	if !c.Connected() {
+		return
+	}
+
+	select {
+	case c.send <- msg:
+	default:
+		log.Printf("[ERROR] Send buffer full, disconnecting client - PlayerID: %s", c.User.Player.ID)
+		c.closeSend()
+	}
+}
+
+// SendOrDrop queues the message for the client, or drops it when the client's
+// buffer is full. Spectators miss the updates they can not keep up with
+// instead of being disconnected.
+func (c *Client) SendOrDrop(msg []byte) {
+	if !c.Connected() {
+		return
+	}
+
+	select {
+	case c.send <- msg:
+	default:
+	}
+}
+
+// MarkDisconnected stops all further messages to the client.
+func (c *Client) MarkDisconnected() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.IsDisconnected = true
+	c.DisconnectTime = time.Now()
+}
+
+func (c *Client) Connected() bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	return !c.IsDisconnected
+}
+
+// closeSend marks the client disconnected and stops writePump. The send
+// channel is never closed, so a late send can not panic. It is safe to call
+// more than once.
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	c.IsDisconnected = true
+	c.sendMu.Unlock()
+
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
 }
@@ -20,6 +20,12 @@ func LoadEnvironment() *models.Config {
 		ServerPort:  os.Getenv("PORT"),
 		BaseUrl:     os.Getenv("BASE_URL"),
 		ApiUrl:      os.Getenv("API_URL"),
+		AdminToken:  os.Getenv("ADMIN_TOKEN"),
+		InstanceID:  os.Getenv("INSTANCE_ID"),
+	}
+
+	if config.InstanceID == "" {
+		config.InstanceID, _ = os.Hostname()
 	}
 
 	return config
@@ -0,0 +1,202 @@
+package evaluator
+
+import (
+	"math/rand"
+)
+
+// MaxExactEvaluations is the most hand evaluations CalculateEquity spends on
+// enumerating every runout before it falls back to sampling.
+const MaxExactEvaluations = 2_000_000
+
+// MonteCarloTrials is the number of random runouts sampled when enumerating
+// them all is too costly.
+const MonteCarloTrials = 20_000
+
+// HandEvaluator returns the strength of a player's hole cards with a full
+// board.
+type HandEvaluator func(hand, board []Card) Strength
+
+// EvaluateHoldem evaluates hole cards with a board, any five of the cards
+// can be used.
+func EvaluateHoldem(hand, board []Card) Strength {
+	var cards [7]Card
+	n := copy(cards[:], hand)
+	n += copy(cards[n:], board)
+	return Evaluate(cards[:n]...)
+}
+
+// Equity is a player's share of the runouts of a hand. Win and Tie are the
+// fractions of runouts won outright and split, Share is the part of the pot
+// the player can expect, splits included.
+type Equity struct {
+	Win   float64 `json:"win"`
+	Tie   float64 `json:"tie"`
+	Share float64 `json:"share"`
+}
+
+// CalculateEquity returns the equity of every hand over all the ways the
+// board can be completed. Cards in hands, board and dead can not come. It
+// enumerates every runout when that takes at most MaxExactEvaluations
+// evaluations, otherwise it samples MonteCarloTrials of them, and reports
+// which one it did.
+func CalculateEquity(hands [][]Card, board []Card, dead []Card, evaluate HandEvaluator) ([]Equity, bool) {
+	equities := make([]Equity, len(hands))
+	if len(hands) == 0 || len(board) > 5 {
+		return equities, true
+	}
+
+	var used [52]bool
+	markUsed := func(cards []Card) {
+		for _, card := range cards {
+			if card.Valid() {
+				used[card] = true
+			}
+		}
+	}
+	for _, hand := range hands {
+		markUsed(hand)
+	}
+	markUsed(board)
+	markUsed(dead)
+
+	deck := make([]Card, 0, 52)
+	for card := Card(0); card < 52; card++ {
+		if !used[card] {
+			deck = append(deck, card)
+		}
+	}
+
+	missing := 5 - len(board)
+	if missing > len(deck) {
+		return equities, true
+	}
+
+	full := make([]Card, 5)
+	copy(full, board)
+	runout := full[len(board):]
+	tally := newEquityTally(len(hands))
+
+	cost := binomial(len(deck), missing) * len(hands) * evaluationCost(hands[0])
+	exact := cost <= MaxExactEvaluations
+	if exact {
+		forEachCombination(deck, missing, runout, func() {
+			tally.add(hands, full, evaluate)
+		})
+	} else {
+		rng := rand.New(rand.NewSource(rand.Int63()))
+		for trial := 0; trial < MonteCarloTrials; trial++ {
+			// Partial Fisher-Yates: the first cards of the deck are a
+			// uniform sample without replacement.
+			for i := 0; i < missing; i++ {
+				j := i + rng.Intn(len(deck)-i)
+				deck[i], deck[j] = deck[j], deck[i]
+				runout[i] = deck[i]
+			}
+			tally.add(hands, full, evaluate)
+		}
+	}
+
+	return tally.equities(), exact
+}
+
+type equityTally struct {
+	runouts   int
+	wins      []int
+	ties      []int
+	shares    []float64
+	strengths []Strength
+}
+
+func newEquityTally(players int) *equityTally {
+	return &equityTally{
+		wins:      make([]int, players),
+		ties:      make([]int, players),
+		shares:    make([]float64, players),
+		strengths: make([]Strength, players),
+	}
+}
+
+func (t *equityTally) add(hands [][]Card, board []Card, evaluate HandEvaluator) {
+	best := Strength(0)
+	winners := 0
+	for i, hand := range hands {
+		t.strengths[i] = evaluate(hand, board)
+		switch {
+		case t.strengths[i] > best:
+			best = t.strengths[i]
+			winners = 1
+		case t.strengths[i] == best:
+			winners++
+		}
+	}
+
+	t.runouts++
+	for i := range hands {
+		if t.strengths[i] != best {
+			continue
+		}
+
+		if winners == 1 {
+			t.wins[i]++
+		} else {
+			t.ties[i]++
+		}
+		t.shares[i] += 1 / float64(winners)
+	}
+}
+
+func (t *equityTally) equities() []Equity {
+	equities := make([]Equity, len(t.wins))
+	if t.runouts == 0 {
+		return equities
+	}
+
+	runouts := float64(t.runouts)
+	for i := range equities {
+		equities[i] = Equity{
+			Win:   float64(t.wins[i]) / runouts,
+			Tie:   float64(t.ties[i]) / runouts,
+			Share: t.shares[i] / runouts,
+		}
+	}
+
+	return equities
+}
+
+// evaluationCost is the number of five card lookups per evaluation, which is
+// one for Holdem style hands and one per two card and three card combination
+// for Omaha.
+func evaluationCost(hand []Card) int {
+	if len(hand) <= 2 {
+		return 1
+	}
+
+	return binomial(len(hand), 2) * 10
+}
+
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+
+	result := 1
+	for i := 1; i <= k; i++ {
+		result = result * (n - k + i) / i
+	}
+
+	return result
+}
+
+// forEachCombination fills out with every k card combination of deck in turn
+// and calls fn after each.
+func forEachCombination(deck []Card, k int, out []Card, fn func()) {
+	if k == 0 {
+		fn()
+		return
+	}
+
+	for i := 0; i <= len(deck)-k; i++ {
+		out[len(out)-k] = deck[i]
+		forEachCombination(deck[i+1:], k-1, out, fn)
+	}
+}
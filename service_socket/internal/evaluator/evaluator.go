@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"math/bits"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+// Card is a card packed into a byte: the rank (0 for a two up to 12 for an
+// ace) in the high bits and the suit in the low two bits.
+type Card uint8
+
+// Category is the class of a poker hand. The order matches the Holdem
+// HandRank values so the two can be converted directly.
+type Category int
+
+const (
+	HighCard Category = iota
+	OnePair
+	TwoPair
+	ThreeOfAKind
+	Straight
+	Flush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+	RoyalFlush
+)
+
+// Strength is the value of the best five card hand that can be made from a
+// set of cards. A higher strength beats a lower one and equal strengths tie.
+// Valid strengths are 1 (seven high) to MaxStrength (royal flush), 0 means the
+// cards could not be evaluated.
+type Strength uint16
+
+// MaxStrength is the number of distinct five card hand values.
+const MaxStrength Strength = 7462
+
+// InvalidCard stands for a card that is hidden or not part of a standard
+// deck. Evaluate returns 0 for any set of cards that holds it.
+const InvalidCard Card = 0xFF
+
+var suits = map[string]uint8{
+	string(models.Hearts):   0,
+	string(models.Diamonds): 1,
+	string(models.Clubs):    2,
+	string(models.Spades):   3,
+}
+
+// NewCard builds a card from a value between 2 and 14 (ace) and a suit index
+// between 0 and 3. It returns InvalidCard for anything else.
+func NewCard(value int, suit int) Card {
+	if value < 2 || value > 14 || suit < 0 || suit > 3 {
+		return InvalidCard
+	}
+
+	return Card((value-2)<<2 | suit)
+}
+
+// FromModel converts a dealt card to its packed form. Hidden cards and cards
+// with an unknown value or suit become InvalidCard.
+func FromModel(card models.Card) Card {
+	suit, ok := suits[card.Suit]
+	if card.Hidden || !ok {
+		return InvalidCard
+	}
+
+	return NewCard(card.Value, int(suit))
+}
+
+// FromModels converts dealt cards to their packed form.
+func FromModels(cards []models.Card) []Card {
+	packed := make([]Card, len(cards))
+	for i, card := range cards {
+		packed[i] = FromModel(card)
+	}
+
+	return packed
+}
+
+// Valid reports whether the card is one of the 52 cards of a deck.
+func (c Card) Valid() bool {
+	return c < 52
+}
+
+func (c Card) Value() int {
+	return int(c>>2) + 2
+}
+
+func (c Card) Suit() int {
+	return int(c & 3)
+}
+
+// Evaluate returns the strength of the best five card hand in cards. It
+// accepts five to seven cards and returns 0 for any other count, or when a
+// card is invalid or appears twice. It does not allocate.
+func Evaluate(cards ...Card) Strength {
+	n := len(cards)
+	if n < 5 || n > 7 {
+		return 0
+	}
+
+	var seen uint64
+	var counts [13]uint8
+	var suitMasks [4]uint16
+	for _, card := range cards {
+		if !card.Valid() || seen&(1<<card) != 0 {
+			return 0
+		}
+		seen |= 1 << card
+
+		rank := card >> 2
+		counts[rank]++
+		suitMasks[card&3] |= 1 << rank
+	}
+
+	// With seven cards or fewer a flush can not share the hand with four of a
+	// kind or a full house, so a five card suit always decides the hand.
+	for _, mask := range suitMasks {
+		if bits.OnesCount16(mask) >= 5 {
+			return flushTable[mask]
+		}
+	}
+
+	return rankTables[n][rankIndex(&counts, n)]
+}
+
+// EvaluateCards is Evaluate for dealt cards.
+func EvaluateCards(cards []models.Card) Strength {
+	var packed [7]Card
+	if len(cards) > len(packed) {
+		return 0
+	}
+
+	for i, card := range cards {
+		packed[i] = FromModel(card)
+	}
+
+	return Evaluate(packed[:len(cards)]...)
+}
+
+// Category returns the class of the hand.
+func (s Strength) Category() Category {
+	return strengthInfo[s].category
+}
+
+// HighCards returns the card values that break ties within the category, most
+// significant first: the quads then the kicker, the trips then the pair, the
+// top card of a straight (5 for a wheel), the five cards of a flush and so
+// on.
+func (s Strength) HighCards() []int {
+	info := strengthInfo[s]
+	highCards := make([]int, info.length)
+	for i := range highCards {
+		highCards[i] = int(info.values[i])
+	}
+
+	return highCards
+}
+
+// Compare returns 1 if a beats b, -1 if b beats a and 0 on a tie.
+func Compare(a, b Strength) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+
+	return 0
+}
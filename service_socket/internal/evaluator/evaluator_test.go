@@ -0,0 +1,149 @@
+package evaluator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+func deck() []Card {
+	cards := make([]Card, 52)
+	for i := range cards {
+		cards[i] = Card(i)
+	}
+
+	return cards
+}
+
+func TestEvaluateAllFiveCardHands(t *testing.T) {
+	want := map[Category]int{
+		RoyalFlush:    4,
+		StraightFlush: 36,
+		FourOfAKind:   624,
+		FullHouse:     3744,
+		Flush:         5108,
+		Straight:      10200,
+		ThreeOfAKind:  54912,
+		TwoPair:       123552,
+		OnePair:       1098240,
+		HighCard:      1302540,
+	}
+
+	counts := map[Category]int{}
+	seen := map[Strength]bool{}
+	total := 0
+	cards := deck()
+	hand := make([]Card, 5)
+	forEachCombination(cards, 5, hand, func() {
+		strength := Evaluate(hand...)
+		if strength == 0 || strength > MaxStrength {
+			t.Fatalf("Evaluate(%v) = %d, out of range", hand, strength)
+		}
+
+		counts[strength.Category()]++
+		seen[strength] = true
+		total++
+	})
+
+	if total != 2598960 {
+		t.Errorf("walked %d hands, want 2598960", total)
+	}
+	if len(seen) != int(MaxStrength) {
+		t.Errorf("got %d distinct strengths, want %d", len(seen), MaxStrength)
+	}
+	for category, count := range want {
+		if counts[category] != count {
+			t.Errorf("category %d: got %d hands, want %d", category, counts[category], count)
+		}
+	}
+}
+
+func TestEvaluateMatchesBestFiveOfSeven(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cards := deck()
+	subset := make([]Card, 5)
+	for trial := 0; trial < 200_000; trial++ {
+		rng.Shuffle(len(cards), func(i, j int) {
+			cards[i], cards[j] = cards[j], cards[i]
+		})
+		seven := cards[:7]
+
+		best := Strength(0)
+		forEachCombination(seven, 5, subset, func() {
+			best = max(best, Evaluate(subset...))
+		})
+
+		if got := Evaluate(seven...); got != best {
+			t.Fatalf("Evaluate(%v) = %d, best five of seven is %d", seven, got, best)
+		}
+		if got := Evaluate(seven[:6]...); got < Evaluate(seven[:5]...) {
+			t.Fatalf("Evaluate(%v) = %d, weaker than its first five cards", seven[:6], got)
+		}
+	}
+}
+
+func TestEvaluateRejectsInvalidCards(t *testing.T) {
+	valid := []Card{NewCard(14, 0), NewCard(13, 0), NewCard(12, 0), NewCard(11, 0), NewCard(10, 0)}
+	if Evaluate(valid...) != MaxStrength {
+		t.Fatalf("royal flush should be MaxStrength")
+	}
+
+	tests := map[string][]Card{
+		"invalid card":   {valid[0], valid[1], valid[2], valid[3], InvalidCard},
+		"duplicate card": {valid[0], valid[1], valid[2], valid[3], valid[3]},
+		"five of a rank": {NewCard(9, 0), NewCard(9, 1), NewCard(9, 2), NewCard(9, 3), NewCard(9, 0)},
+		"too few cards":  valid[:4],
+	}
+	for name, cards := range tests {
+		if got := Evaluate(cards...); got != 0 {
+			t.Errorf("%s: Evaluate(%v) = %d, want 0", name, cards, got)
+		}
+	}
+
+	dealt := map[string][]models.Card{
+		"hidden card": {
+			{Suit: "spades", Value: 14}, {Suit: "spades", Value: 13}, {Suit: "spades", Value: 12},
+			{Suit: "spades", Value: 11}, {Hidden: true},
+		},
+		"zero value card": {
+			{Suit: "spades", Value: 14}, {Suit: "spades", Value: 13}, {Suit: "spades", Value: 12},
+			{Suit: "spades", Value: 11}, {},
+		},
+	}
+	for name, cards := range dealt {
+		if got := EvaluateCards(cards); got != 0 {
+			t.Errorf("%s: EvaluateCards(%v) = %d, want 0", name, cards, got)
+		}
+	}
+}
+
+func benchmarkHands(n int) [][]Card {
+	rng := rand.New(rand.NewSource(1))
+	cards := deck()
+	hands := make([][]Card, 1024)
+	for i := range hands {
+		rng.Shuffle(len(cards), func(a, b int) {
+			cards[a], cards[b] = cards[b], cards[a]
+		})
+		hands[i] = append([]Card(nil), cards[:n]...)
+	}
+
+	return hands
+}
+
+func Benchmark5(b *testing.B) {
+	hands := benchmarkHands(5)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Evaluate(hands[i&1023]...)
+	}
+}
+
+func Benchmark7(b *testing.B) {
+	hands := benchmarkHands(7)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Evaluate(hands[i&1023]...)
+	}
+}
@@ -0,0 +1,35 @@
+package evaluator
+
+import "github.com/ahmetkoprulu/rtrp/game/models"
+
+// EvaluateOmaha returns the strength of the best hand made of exactly two
+// cards from hand and exactly three from board. It returns 0 when either side
+// has too few cards.
+func EvaluateOmaha(hand, board []Card) Strength {
+	if len(hand) < 2 || len(board) < 3 {
+		return 0
+	}
+
+	best := Strength(0)
+	var cards [5]Card
+	for a := 0; a < len(hand); a++ {
+		for b := a + 1; b < len(hand); b++ {
+			cards[0], cards[1] = hand[a], hand[b]
+			for c := 0; c < len(board); c++ {
+				for d := c + 1; d < len(board); d++ {
+					for e := d + 1; e < len(board); e++ {
+						cards[2], cards[3], cards[4] = board[c], board[d], board[e]
+						best = max(best, Evaluate(cards[:]...))
+					}
+				}
+			}
+		}
+	}
+
+	return best
+}
+
+// EvaluateOmahaCards is EvaluateOmaha for dealt cards.
+func EvaluateOmahaCards(hand, board []models.Card) Strength {
+	return EvaluateOmaha(FromModels(hand), FromModels(board))
+}
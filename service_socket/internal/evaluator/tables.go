@@ -0,0 +1,286 @@
+package evaluator
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// The tables below are built once when the package is loaded.
+//
+// Hands that contain a flush are looked up by the 13 bit rank mask of the
+// flush suit. Every other hand only depends on how many cards of each rank it
+// holds, so those are looked up by a perfect hash of the 13 rank counts: the
+// position of the counts among all count vectors with the same number of
+// cards, in lexicographic order. There are 6175, 18395 and 49205 such vectors
+// for five, six and seven cards.
+
+type handInfo struct {
+	category Category
+	length   uint8
+	values   [5]uint8
+}
+
+var (
+	flushTable   [1 << 13]Strength
+	rankTables   [8][]Strength
+	strengthInfo [MaxStrength + 1]handInfo
+
+	// vectorCount[k][m] is the number of ways to place m cards over k ranks
+	// with at most four cards per rank.
+	vectorCount [14][8]int
+	// rankOffset[i][m][c] is how many count vectors sort before the ones
+	// that have c cards of rank i when m cards are left for ranks i and up.
+	rankOffset [13][8][5]int
+)
+
+func init() {
+	buildRankOffsets()
+	buildStrengths()
+	buildFlushTable()
+	buildRankTables()
+}
+
+func rankIndex(counts *[13]uint8, n int) int {
+	index := 0
+	for i, count := range counts {
+		index += rankOffset[i][n][count]
+		n -= int(count)
+	}
+
+	return index
+}
+
+func buildRankOffsets() {
+	vectorCount[0][0] = 1
+	for k := 1; k <= 13; k++ {
+		for m := 0; m <= 7; m++ {
+			for c := 0; c <= 4 && c <= m; c++ {
+				vectorCount[k][m] += vectorCount[k-1][m-c]
+			}
+		}
+	}
+
+	for i := 0; i < 13; i++ {
+		for m := 0; m <= 7; m++ {
+			offset := 0
+			for c := 0; c <= 4; c++ {
+				rankOffset[i][m][c] = offset
+				if c <= m {
+					offset += vectorCount[12-i][m-c]
+				}
+			}
+		}
+	}
+}
+
+// handKey orders five card hands: the category in the top bits followed by
+// up to five tie-breaking values of four bits each.
+func handKey(info handInfo) int {
+	key := int(info.category) << 20
+	for i := 0; i < int(info.length); i++ {
+		key |= int(info.values[i]) << (16 - 4*i)
+	}
+
+	return key
+}
+
+// classify describes the five card hand with the given rank counts.
+func classify(counts *[13]uint8, flush bool) handInfo {
+	info := handInfo{}
+	add := func(rank int) {
+		info.values[info.length] = uint8(rank + 2)
+		info.length++
+	}
+
+	var mask uint16
+	for rank, count := range counts {
+		if count > 0 {
+			mask |= 1 << rank
+		}
+	}
+
+	if bits.OnesCount16(mask) == 5 {
+		high, straight := straightHigh(mask)
+		switch {
+		case straight && flush && high == 12:
+			info.category = RoyalFlush
+		case straight && flush:
+			info.category = StraightFlush
+		case flush:
+			info.category = Flush
+		case straight:
+			info.category = Straight
+		default:
+			info.category = HighCard
+		}
+
+		if straight {
+			add(high)
+			return info
+		}
+
+		for rank := 12; rank >= 0; rank-- {
+			if counts[rank] > 0 {
+				add(rank)
+			}
+		}
+		return info
+	}
+
+	// Ranks grouped by count, largest group first and higher ranks first
+	// within a group.
+	var groups [5]int
+	for count := 4; count >= 1; count-- {
+		for rank := 12; rank >= 0; rank-- {
+			if int(counts[rank]) == count {
+				add(rank)
+				groups[count]++
+			}
+		}
+	}
+
+	switch {
+	case groups[4] == 1:
+		info.category = FourOfAKind
+	case groups[3] == 1 && groups[2] == 1:
+		info.category = FullHouse
+	case groups[3] == 1:
+		info.category = ThreeOfAKind
+	case groups[2] == 2:
+		info.category = TwoPair
+	default:
+		info.category = OnePair
+	}
+
+	return info
+}
+
+// straightHigh returns the top rank of the straight in a five rank mask.
+func straightHigh(mask uint16) (int, bool) {
+	const wheel = 1<<12 | 0b1111
+	if mask == wheel {
+		return 3, true
+	}
+
+	low := bits.TrailingZeros16(mask)
+	if mask>>low == 0b11111 {
+		return low + 4, true
+	}
+
+	return 0, false
+}
+
+// buildStrengths numbers every distinct five card hand from the weakest to
+// the strongest.
+func buildStrengths() {
+	hands := map[int]handInfo{}
+	forEachCounts(5, func(counts *[13]uint8) {
+		info := classify(counts, false)
+		hands[handKey(info)] = info
+
+		if info.category == HighCard || info.category == Straight {
+			info = classify(counts, true)
+			hands[handKey(info)] = info
+		}
+	})
+
+	keys := make([]int, 0, len(hands))
+	for key := range hands {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	if len(keys) != int(MaxStrength) {
+		panic("evaluator: unexpected number of distinct hands")
+	}
+
+	for i, key := range keys {
+		strengthInfo[i+1] = hands[key]
+	}
+}
+
+func strengthOf(info handInfo) Strength {
+	key := handKey(info)
+	strength := sort.Search(int(MaxStrength), func(i int) bool {
+		return handKey(strengthInfo[i+1]) >= key
+	})
+
+	return Strength(strength + 1)
+}
+
+func buildFlushTable() {
+	for mask := 0; mask < len(flushTable); mask++ {
+		if bits.OnesCount16(uint16(mask)) != 5 {
+			continue
+		}
+
+		var counts [13]uint8
+		for rank := range counts {
+			counts[rank] = uint8(mask >> rank & 1)
+		}
+		flushTable[mask] = strengthOf(classify(&counts, true))
+	}
+
+	// Six and seven card flushes take their best five card subset, which
+	// is the best of the masks with one rank less.
+	for size := 6; size <= 7; size++ {
+		for mask := 0; mask < len(flushTable); mask++ {
+			if bits.OnesCount16(uint16(mask)) != size {
+				continue
+			}
+
+			for rest := mask; rest != 0; rest &= rest - 1 {
+				lowest := rest & -rest
+				flushTable[mask] = max(flushTable[mask], flushTable[mask&^lowest])
+			}
+		}
+	}
+}
+
+func buildRankTables() {
+	rankTables[5] = make([]Strength, vectorCount[13][5])
+	forEachCounts(5, func(counts *[13]uint8) {
+		rankTables[5][rankIndex(counts, 5)] = strengthOf(classify(counts, false))
+	})
+
+	for n := 6; n <= 7; n++ {
+		table := make([]Strength, vectorCount[13][n])
+		forEachCounts(n, func(counts *[13]uint8) {
+			best := Strength(0)
+			for rank := range counts {
+				if counts[rank] == 0 {
+					continue
+				}
+
+				counts[rank]--
+				best = max(best, rankTables[n-1][rankIndex(counts, n-1)])
+				counts[rank]++
+			}
+			table[rankIndex(counts, n)] = best
+		})
+		rankTables[n] = table
+	}
+}
+
+// forEachCounts calls fn with every way to hold n cards with at most four
+// cards of each rank.
+func forEachCounts(n int, fn func(counts *[13]uint8)) {
+	var counts [13]uint8
+	var walk func(rank, left int)
+	walk = func(rank, left int) {
+		if rank == 13 {
+			if left == 0 {
+				fn(&counts)
+			}
+			return
+		}
+
+		for c := 0; c <= 4 && c <= left; c++ {
+			counts[rank] = uint8(c)
+			walk(rank+1, left-c)
+		}
+		counts[rank] = 0
+	}
+
+	walk(0, n)
+}
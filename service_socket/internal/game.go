@@ -29,6 +29,7 @@ const (
 	GamePlayerStatusActive
 	GamePlayerStatusInactive
 	GamePlayerStatusFolded
+	GamePlayerStatusSittingOut
 )
 
 type GameActionType string
@@ -42,19 +43,52 @@ const (
 type GameError error
 
 var (
-	ErrorGameFull            GameError = errors.New("game_full")
-	ErrorGamePlayerAlreadyIn GameError = errors.New("game_player_already_in")
-	ErrorGamePositionTaken   GameError = errors.New("game_position_taken")
-	ErrorGamePlayerNotFound  GameError = errors.New("game_player_not_found")
-	ErrorGameNotReady        GameError = errors.New("game_not_ready")
+	ErrorGameFull              GameError = errors.New("game_full")
+	ErrorGamePlayerAlreadyIn   GameError = errors.New("game_player_already_in")
+	ErrorGamePositionTaken     GameError = errors.New("game_position_taken")
+	ErrorGamePlayerNotFound    GameError = errors.New("game_player_not_found")
+	ErrorGameNotReady          GameError = errors.New("game_not_ready")
+	ErrorGameInvalidClientSeed GameError = errors.New("game_invalid_client_seed")
+	ErrorGameInvalidBlinds     GameError = errors.New("game_invalid_blinds")
+	ErrorGameTournament        GameError = errors.New("game_tournament")
 )
 
+const MaxClientSeedLength = 64
+
 type GameType int
 
 const (
 	GameTypeHoldem GameType = 1
+	GameTypeOmaha  GameType = 2
 )
 
+// ActionTimerSettings control how long players have to act at a table.
+type ActionTimerSettings struct {
+	ActionTimeout time.Duration `json:"action_timeout"`
+	// TimeBank is the extra time a player starts with and can hold at most.
+	// It is used once the action timeout runs out.
+	TimeBank time.Duration `json:"time_bank"`
+	// TimeBankRefill is given back to every player each TimeBankRefillHands
+	// hands.
+	TimeBankRefill      time.Duration `json:"time_bank_refill"`
+	TimeBankRefillHands int           `json:"time_bank_refill_hands"`
+	// SitOutAfterTimeouts is the number of turns in a row a player can let
+	// run out before they sit out. Zero never sits players out.
+	SitOutAfterTimeouts int `json:"sit_out_after_timeouts"`
+	// SitOutRemoveAfter is how long a player can sit out before losing their
+	// seat. Zero keeps the seat.
+	SitOutRemoveAfter time.Duration `json:"sit_out_remove_after"`
+}
+
+var DefaultActionTimerSettings = ActionTimerSettings{
+	ActionTimeout:       10 * time.Second,
+	TimeBank:            30 * time.Second,
+	TimeBankRefill:      5 * time.Second,
+	TimeBankRefillHands: 10,
+	SitOutAfterTimeouts: 2,
+	SitOutRemoveAfter:   10 * time.Minute,
+}
+
 type IPlayable interface {
 	Start() error
 	End() error
@@ -63,7 +97,9 @@ type IPlayable interface {
 	ProcessAction(action json.RawMessage) error
 	DealCards() error
 	CanStart() bool
+	SetBlinds(smallBlind, bigBlind, ante int) error
 	GetGameState() interface{}
+	GetPlayerState(playerID string) interface{}
 }
 
 type GameAction struct {
@@ -97,6 +133,20 @@ type GamePlayer struct {
 	LastAction string           `json:"last_action"`
 	Client     *Client          `json:"client"`
 	Status     GamePlayerStatus `json:"status"`
+	ClientSeed string           `json:"-"`
+	Timeouts   int              `json:"-"` // Turns in a row the player let run out
+	SittingOut bool             `json:"-"` // Sits out from the next hand on
+	SatOutAt   time.Time        `json:"-"`
+	Away       bool             `json:"-"` // Left a tournament with chips, dealt in and folded until blinded out
+
+	// Blinds the player owes for joining or sitting out between the blinds,
+	// paid on their next big blind or, with BlindEntryPostNow, straight away
+	BlindEntry       models.BlindEntry `json:"-"`
+	MissedBigBlind   bool              `json:"-"`
+	MissedSmallBlind bool              `json:"-"`
+
+	PendingTopUp int      `json:"pending_top_up"` // Added to the balance when the next hand starts
+	Reservations []string `json:"-"`              // Wallet reservations of the buy-in and each top-up
 }
 
 type Game struct {
@@ -107,12 +157,18 @@ type Game struct {
 	Playable    IPlayable            `json:"playable"`
 	MinBet      int                  `json:"min_bet"`
 	MaxPlayers  int                  `json:"max_players"`
+	ActionTimer ActionTimerSettings  `json:"action_timer"`
+	BuyIn       BuyInLimits          `json:"buy_in"`
 	ActionChan  chan GameAction      `json:"-"`
 	MessageChan chan models.Response `json:"-"`
 	Room        *Room                `json:"-"`
 	Mu          sync.RWMutex         `json:"-"`
 
 	GameEventPublisher *mq.GameEventPublisher
+	Wallet             IChipWallet // Reserves and settles the chips players take to the table
+	Tournament         ITournament // Seats the players and takes them off the table, nil for cash games
+	HandsDealt         int         `json:"-"` // Hands dealt at the table so far
+	BlindClock         *blindClock `json:"-"` // Raises the blinds between hands, nil keeps them as they are
 }
 
 func NewGame(actionChan chan GameAction, messageChan chan models.Response, room *Room, maxPlayers int, minBet int, gameType GameType) *Game {
@@ -127,6 +183,8 @@ func NewGame(actionChan chan GameAction, messageChan chan models.Response, room
 		Players:            make([]*GamePlayer, 0),
 		MaxPlayers:         maxPlayers,
 		MinBet:             minBet,
+		ActionTimer:        DefaultActionTimerSettings,
+		BuyIn:              DefaultBuyInLimits(minBet),
 		ActionChan:         actionChan,
 		MessageChan:        messageChan,
 		Room:               room,
@@ -136,44 +194,105 @@ func NewGame(actionChan chan GameAction, messageChan chan models.Response, room
 	}
 }
 
-func (g *Game) AddPlayer(position int, player *Client) error {
+// AddPlayer seats the player with a stack of buyIn chips reserved in their
+// wallet.
+func (g *Game) AddPlayer(position int, player *Client, blindEntry models.BlindEntry, buyIn int) error {
+	g.Mu.RLock()
+	amount, err := g.checkSeat(position, player, buyIn)
+	g.Mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// The hand plays on while the chips are reserved
+	reservationID := uuid.New().String()
+	if err := g.reserveChips(reservationID, player, mq.ChipTransactionBuyIn, amount); err != nil {
+		return err
+	}
+
 	g.Mu.Lock()
 	defer g.Mu.Unlock()
 
+	// The seat may have been taken in the meantime
+	if _, err := g.checkSeat(position, player, amount); err != nil {
+		g.releaseChips(reservationID)
+		return err
+	}
+
+	player.User.Player.Chips -= int64(amount)
 	gamePlayer := &GamePlayer{
-		Position: position,
-		Client:   player,
-		Balance:  int(player.User.Player.Chips),
+		Position:     position,
+		Client:       player,
+		BlindEntry:   blindEntry,
+		Balance:      amount,
+		Status:       GamePlayerStatusWaiting,
+		Reservations: []string{reservationID},
+	}
+	g.Players = append(g.Players, gamePlayer)
+	g.Playable.OnPlayerJoin(gamePlayer)
+
+	return nil
+}
+
+// checkSeat checks the player can take the seat with the buy-in and returns
+// the chips they sit down with. The caller holds Mu.
+func (g *Game) checkSeat(position int, player *Client, buyIn int) (int, error) {
+	if g.Tournament != nil {
+		return 0, ErrorGameTournament
 	}
 
 	if len(g.Players) >= g.MaxPlayers {
-		return ErrorGameFull
+		return 0, ErrorGameFull
 	}
 
 	for _, p := range g.Players {
 		if p.Client.User.Player.ID == player.User.Player.ID {
-			return ErrorGamePlayerAlreadyIn
+			return 0, ErrorGamePlayerAlreadyIn
 		}
 
 		if p.Position == position {
-			return ErrorGamePositionTaken
+			return 0, ErrorGamePositionTaken
 		}
 	}
 
-	gamePlayer.Status = GamePlayerStatusWaiting
-	g.Players = append(g.Players, gamePlayer)
-	player.CurrentGame = g
-	g.Playable.OnPlayerJoin(gamePlayer)
+	return g.buyInAmount(player, buyIn)
+}
 
-	return nil
+// FreePosition returns the lowest seat no one sits at, false when the table
+// is full.
+func (g *Game) FreePosition() (int, bool) {
+	g.Mu.RLock()
+	defer g.Mu.RUnlock()
+
+	taken := make(map[int]bool, len(g.Players))
+	for _, p := range g.Players {
+		taken[p.Position] = true
+	}
+
+	for position := 0; position < g.MaxPlayers; position++ {
+		if !taken[position] {
+			return position, true
+		}
+	}
+
+	return 0, false
 }
 
+// RemovePlayer takes the player out of the hands to come. A tournament
+// player keeps their stack in play instead: they are dealt in and folded
+// until they are blinded out or come back.
 func (g *Game) RemovePlayer(playerID string) error {
 	// g.Mu.Lock()
 	// defer g.Mu.Unlock()
 
 	for _, p := range g.Players {
 		if p.Client.User.Player.ID == playerID {
+			if g.Tournament != nil {
+				p.Away = true
+				log.Printf("[INFO] Tournament player away - GameID: %s, PlayerID: %s, Balance: %d", g.ID, playerID, p.Balance)
+				return nil
+			}
+
 			// g.Players = slices.Delete(g.Players, i, i+1)
 			p.Status = GamePlayerStatusInactive
 			g.Playable.OnPlayerLeave(p)
@@ -183,6 +302,146 @@ func (g *Game) RemovePlayer(playerID string) error {
 	return nil
 }
 
+// IsSeated reports whether the player has a seat in the game that they have
+// not left.
+func (g *Game) IsSeated(playerID string) bool {
+	g.Mu.RLock()
+	defer g.Mu.RUnlock()
+
+	return g.findPlayer(playerID) != nil
+}
+
+// ReattachClient hands the player's seat to a new connection of the same
+// player.
+func (g *Game) ReattachClient(client *Client) {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	for _, p := range g.Players {
+		if p.Client.User.Player.ID == client.User.Player.ID {
+			p.Client = client
+			p.Away = false
+		}
+	}
+}
+
+// SitOut keeps the player's seat but leaves them out of the hands to come.
+// A hand they are playing is finished first. The player loses the seat when
+// they are still sitting out after SitOutRemoveAfter. Tournament players are
+// dealt every hand.
+func (g *Game) SitOut(playerID string) error {
+	g.Mu.Lock()
+	if g.Tournament != nil {
+		g.Mu.Unlock()
+		return ErrorGameTournament
+	}
+
+	player := g.findPlayer(playerID)
+	if player == nil {
+		g.Mu.Unlock()
+		return ErrorGamePlayerNotFound
+	}
+
+	if player.SittingOut {
+		g.Mu.Unlock()
+		return nil
+	}
+
+	player.SittingOut = true
+	player.SatOutAt = time.Now()
+	satOutAt := player.SatOutAt
+	if g.Status != GameStatusStarted {
+		player.Status = GamePlayerStatusSittingOut
+	}
+	g.Mu.Unlock()
+
+	log.Printf("[INFO] Player sitting out - GameID: %s, PlayerID: %s", g.ID, playerID)
+	if removeAfter := g.ActionTimer.SitOutRemoveAfter; removeAfter > 0 {
+		time.AfterFunc(removeAfter, func() {
+			g.removeSittingOut(playerID, satOutAt)
+		})
+	}
+
+	return nil
+}
+
+// SitIn deals the player back in from the next hand, or from their big blind
+// when they missed blinds and chose to wait for it.
+func (g *Game) SitIn(playerID string, blindEntry models.BlindEntry) error {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	player := g.findPlayer(playerID)
+	if player == nil {
+		return ErrorGamePlayerNotFound
+	}
+
+	player.SittingOut = false
+	player.Timeouts = 0
+	player.BlindEntry = blindEntry
+	if player.Status == GamePlayerStatusSittingOut && g.Status != GameStatusStarted {
+		g.Playable.OnPlayerJoin(player)
+	}
+
+	log.Printf("[INFO] Player sitting in - GameID: %s, PlayerID: %s", g.ID, playerID)
+	return nil
+}
+
+func (g *Game) removeSittingOut(playerID string, satOutAt time.Time) {
+	g.Mu.RLock()
+	player := g.findPlayer(playerID)
+	expired := player != nil && player.SittingOut && player.SatOutAt.Equal(satOutAt)
+	g.Mu.RUnlock()
+
+	if !expired {
+		return
+	}
+
+	log.Printf("[INFO] Removing player who sat out too long - GameID: %s, PlayerID: %s", g.ID, playerID)
+	g.RemovePlayer(playerID)
+	g.Room.BroadcastToRoom(models.Response{
+		Type: models.MessageTypeLeaveGame,
+		Data: models.MessageLeaveGameResponse{
+			RoomID:   g.Room.ID,
+			PlayerID: playerID,
+			State:    g.Room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// findPlayer returns the player's seat that they have not left. The caller
+// holds Mu.
+func (g *Game) findPlayer(playerID string) *GamePlayer {
+	for _, p := range g.Players {
+		if p.Client.User.Player.ID == playerID && p.Status != GamePlayerStatusInactive {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// SetPlayerClientSeed stores the seed the player contributes to the shuffle of
+// the following hands.
+func (g *Game) SetPlayerClientSeed(playerID string, seed string) error {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	if len(seed) > MaxClientSeedLength {
+		return ErrorGameInvalidClientSeed
+	}
+
+	for _, p := range g.Players {
+		if p.Client.User.Player.ID == playerID {
+			p.ClientSeed = seed
+			return nil
+		}
+	}
+
+	return ErrorGamePlayerNotFound
+}
+
 func (g *Game) Start() error {
 	if !g.Playable.CanStart() {
 		return ErrorGameNotReady
@@ -211,6 +470,10 @@ func (g *Game) GetGameState() interface{} {
 	return g.Playable.GetGameState()
 }
 
+func (g *Game) GetPlayerState(playerID string) interface{} {
+	return g.Playable.GetPlayerState(playerID)
+}
+
 // Reset clears all players and resets game state
 func (g *Game) Reset() error {
 	g.Mu.Lock()
@@ -230,7 +493,7 @@ func (g *Game) Reset() error {
 
 	// Clear all players
 	for _, player := range g.Players {
-		player.Client.CurrentGame = nil
+		g.cashOut(player)
 		player.Status = GamePlayerStatusInactive
 	}
 	g.Players = make([]*GamePlayer, 0)
@@ -238,8 +501,16 @@ func (g *Game) Reset() error {
 	// Reset game status
 	g.Status = GameStatusWaiting
 
-	// Reset playable if it's Holdem
-	if holdem, ok := g.Playable.(*Holdem); ok {
+	// Reset playable if it runs on the Holdem engine
+	var holdem *Holdem
+	switch playable := g.Playable.(type) {
+	case *Holdem:
+		holdem = playable
+	case *Omaha:
+		holdem = playable.Holdem
+	}
+
+	if holdem != nil {
 		holdem.RefreshState()
 		// Send done signal to stop any running goroutines
 		select {
@@ -253,18 +524,17 @@ func (g *Game) Reset() error {
 	return nil
 }
 
-func (g *Game) UpdatePlayerChips(playerChanges []mq.PlayerChipChange) error {
-	chipUpdate := &mq.ChipUpdateMessage{
-		MessageID:     uuid.New().String(),
-		RoomID:        g.Room.ID,
-		GameType:      int(g.GameType),
-		PlayerChanges: playerChanges,
-		Timestamp:     time.Now(),
+func (g *Game) PublishHandComplete(record *HandRecord) error {
+	handComplete := &mq.HandCompleteMessage{
+		RoomID:   g.Room.ID,
+		GameType: int(g.GameType),
+		HandID:   record.HandID,
+		Hand:     record,
 	}
 
-	err := g.GameEventPublisher.PublishChipUpdate(chipUpdate)
+	err := g.GameEventPublisher.PublishHandComplete(handComplete)
 	if err != nil {
-		log.Printf("[ERROR] Failed to publish chip update: %v", err)
+		log.Printf("[ERROR] Failed to publish hand complete: %v", err)
 	}
 
 	return err
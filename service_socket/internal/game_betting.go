@@ -0,0 +1,106 @@
+package internal
+
+import "fmt"
+
+type BettingStructureType string
+
+const (
+	BettingNoLimit    BettingStructureType = "no_limit"
+	BettingPotLimit   BettingStructureType = "pot_limit"
+	BettingFixedLimit BettingStructureType = "fixed_limit"
+)
+
+// DefaultRaiseCap is the number of bets allowed in a fixed-limit round, the
+// opening bet (or the big blind before the flop) included.
+const DefaultRaiseCap = 4
+
+// IBettingStructure holds the bet sizing rules of a table. Amounts are the
+// total a player has in for the current round, so a raise to 60 over a bet of
+// 20 is 60.
+type IBettingStructure interface {
+	Type() BettingStructureType
+	// BetLimits returns the smallest and largest total a player with
+	// playerBet already in and balance behind may bet or raise to. A player
+	// who can not reach the minimum can only go all-in.
+	BetLimits(state *HoldemState, playerBet, balance int) (minTo, maxTo int)
+	// CanRaise reports whether another bet or raise is allowed this round.
+	CanRaise(state *HoldemState) bool
+}
+
+// NewBettingStructure returns the structure of the given type for a table
+// with the given big blind. Fixed-limit tables bet the big blind before the
+// turn and twice the big blind on the turn and river.
+func NewBettingStructure(structureType BettingStructureType, bigBlind int) (IBettingStructure, error) {
+	switch structureType {
+	case BettingNoLimit:
+		return NoLimit{}, nil
+	case BettingPotLimit:
+		return PotLimit{}, nil
+	case BettingFixedLimit:
+		return FixedLimit{SmallBet: bigBlind, BigBet: bigBlind * 2, RaiseCap: DefaultRaiseCap}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported betting structure: %s", structureType)
+}
+
+// minRaiseTo is the smallest full bet or raise in big bet games: at least the
+// big blind, and a raise must be at least as big as the last one.
+func minRaiseTo(state *HoldemState) int {
+	return state.CurrentBet + max(state.LastRaiseSize, state.BigBlindAmount)
+}
+
+type NoLimit struct{}
+
+func (NoLimit) Type() BettingStructureType {
+	return BettingNoLimit
+}
+
+func (NoLimit) BetLimits(state *HoldemState, playerBet, balance int) (int, int) {
+	return minRaiseTo(state), playerBet + balance
+}
+
+func (NoLimit) CanRaise(state *HoldemState) bool {
+	return true
+}
+
+// PotLimit caps every bet at the size of the pot after calling.
+type PotLimit struct{}
+
+func (PotLimit) Type() BettingStructureType {
+	return BettingPotLimit
+}
+
+func (PotLimit) BetLimits(state *HoldemState, playerBet, balance int) (int, int) {
+	toCall := max(state.CurrentBet-playerBet, 0)
+	return minRaiseTo(state), min(playerBet+balance, state.CurrentBet+state.Pot+toCall)
+}
+
+func (PotLimit) CanRaise(state *HoldemState) bool {
+	return true
+}
+
+// FixedLimit only allows bets and raises of one fixed size per street, up to
+// RaiseCap bets a round.
+type FixedLimit struct {
+	SmallBet int
+	BigBet   int
+	RaiseCap int
+}
+
+func (FixedLimit) Type() BettingStructureType {
+	return BettingFixedLimit
+}
+
+func (f FixedLimit) BetLimits(state *HoldemState, playerBet, balance int) (int, int) {
+	betSize := f.SmallBet
+	if state.CurrentRound == Turn || state.CurrentRound == River {
+		betSize = f.BigBet
+	}
+
+	betTo := state.CurrentBet + betSize
+	return betTo, min(betTo, playerBet+balance)
+}
+
+func (f FixedLimit) CanRaise(state *HoldemState) bool {
+	return f.RaiseCap <= 0 || state.BetCount < f.RaiseCap
+}
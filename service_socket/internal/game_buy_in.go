@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/internal/api"
+	"github.com/ahmetkoprulu/rtrp/game/internal/mq"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrorGameInvalidBuyIn      GameError = errors.New("game_invalid_buy_in")
+	ErrorGameInsufficientChips GameError = errors.New("game_insufficient_chips")
+	ErrorGameWalletUnavailable GameError = errors.New("game_wallet_unavailable")
+)
+
+// Buy-in limits of a table in big blinds, used when a room does not set its
+// own.
+const (
+	DefaultMinBuyInBigBlinds = 20
+	DefaultMaxBuyInBigBlinds = 100
+)
+
+// Settling and releasing reservations is retried until the wallet takes it,
+// waiting a little longer after each failure up to walletMaxRetryDelay. A
+// warning is logged every walletAttempts failures.
+const (
+	walletAttempts      = 5
+	walletRetryDelay    = 500 * time.Millisecond
+	walletMaxRetryDelay = 30 * time.Second
+)
+
+// BuyInLimits are the smallest and the largest stack a player can bring to
+// the table. Top-ups can not take a stack over Max.
+type BuyInLimits struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func DefaultBuyInLimits(bigBlind int) BuyInLimits {
+	return BuyInLimits{
+		Min: DefaultMinBuyInBigBlinds * bigBlind,
+		Max: DefaultMaxBuyInBigBlinds * bigBlind,
+	}
+}
+
+// IChipWallet keeps the chips players take to a table reserved in their
+// wallets until they leave, so the same chips can not be played at two
+// tables. Reserve and Settle record the chip transaction they make, a buy-in
+// or top-up and a cash-out or prize. Every call can be repeated with the same
+// reservation ID without moving the chips twice.
+type IChipWallet interface {
+	Reserve(reservationID, playerID, roomID string, transaction mq.ChipTransactionType, amount int) error
+	Release(reservationID string) error
+	Settle(reservationID string, transaction mq.ChipTransactionType, amount int) error
+}
+
+// buyInAmount checks the amount the player brings to the table against the
+// limits and their wallet. Zero buys in for as much as the player can up to
+// the maximum. The caller holds Mu.
+func (g *Game) buyInAmount(player *Client, amount int) (int, error) {
+	wallet := int(player.User.Player.Chips)
+	if amount == 0 {
+		amount = min(g.BuyIn.Max, max(wallet, g.BuyIn.Min))
+	}
+
+	if amount < g.BuyIn.Min || amount > g.BuyIn.Max {
+		return 0, ErrorGameInvalidBuyIn
+	}
+
+	if amount > wallet {
+		return 0, ErrorGameInsufficientChips
+	}
+
+	return amount, nil
+}
+
+// TopUp adds chips from the player's wallet to their stack. During a game the
+// chips are added when the next hand starts, so they never play in the hand
+// the player is in.
+func (g *Game) TopUp(playerID string, amount int) error {
+	g.Mu.RLock()
+	player, err := g.checkTopUp(playerID, amount)
+	g.Mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// The hand plays on while the chips are reserved
+	reservationID := uuid.New().String()
+	if err := g.reserveChips(reservationID, player.Client, mq.ChipTransactionTopUp, amount); err != nil {
+		return err
+	}
+
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	if player, err = g.checkTopUp(playerID, amount); err != nil {
+		g.releaseChips(reservationID)
+		return err
+	}
+
+	player.Client.User.Player.Chips -= int64(amount)
+	player.Reservations = append(player.Reservations, reservationID)
+	player.PendingTopUp += amount
+	if g.Status != GameStatusStarted {
+		g.applyTopUp(player)
+	}
+
+	log.Printf("[INFO] Player topped up - GameID: %s, PlayerID: %s, Amount: %d", g.ID, playerID, amount)
+	return nil
+}
+
+// checkTopUp checks the player can add the chips to their stack. The caller
+// holds Mu.
+func (g *Game) checkTopUp(playerID string, amount int) (*GamePlayer, error) {
+	if g.Tournament != nil {
+		return nil, ErrorGameTournament
+	}
+
+	player := g.findPlayer(playerID)
+	if player == nil {
+		return nil, ErrorGamePlayerNotFound
+	}
+
+	if amount <= 0 || player.Balance+player.PendingTopUp+amount > g.BuyIn.Max {
+		return nil, ErrorGameInvalidBuyIn
+	}
+
+	if amount > int(player.Client.User.Player.Chips) {
+		return nil, ErrorGameInsufficientChips
+	}
+
+	return player, nil
+}
+
+// applyTopUp adds the chips the player topped up to their stack. The caller
+// holds Mu.
+func (g *Game) applyTopUp(player *GamePlayer) {
+	player.Balance += player.PendingTopUp
+	player.PendingTopUp = 0
+}
+
+// removeLeftPlayers takes the players who left, and those who have too few
+// chips left to play, off the table and cashes out what is left of their
+// stacks. Tournaments take off the players they eliminate instead. The caller
+// holds Mu.
+func (g *Game) removeLeftPlayers() {
+	if g.Tournament != nil {
+		g.Tournament.RemoveBusted(g)
+		return
+	}
+
+	g.Players = slices.DeleteFunc(g.Players, func(p *GamePlayer) bool {
+		if p.Status != GamePlayerStatusInactive && p.Balance >= g.MinBet {
+			return false
+		}
+
+		g.cashOut(p)
+		return true
+	})
+}
+
+// cashOut moves the player's stack back to their wallet. The whole stack
+// settles the buy-in reservation and the top-up reservations settle empty.
+// The caller holds Mu.
+func (g *Game) cashOut(player *GamePlayer) {
+	amount := player.Balance + player.PendingTopUp
+	player.Balance = 0
+	player.PendingTopUp = 0
+	player.Client.User.Player.Chips += int64(amount)
+
+	for i, reservationID := range player.Reservations {
+		settled := 0
+		if i == 0 {
+			settled = amount
+		}
+		g.retryWallet(reservationID, func(wallet IChipWallet) error {
+			return wallet.Settle(reservationID, mq.ChipTransactionCashOut, settled)
+		})
+	}
+	player.Reservations = nil
+
+	log.Printf("[INFO] Player cashed out - GameID: %s, PlayerID: %s, Amount: %d", g.ID, player.Client.User.Player.ID, amount)
+}
+
+// reserveChips reserves the chips in the player's wallet for the table. A
+// reservation that fails for any reason but the wallet being short is
+// released in the background, in case it went through.
+func (g *Game) reserveChips(reservationID string, player *Client, transaction mq.ChipTransactionType, amount int) error {
+	return reserveChips(g.Wallet, reservationID, player, g.Room.ID, transaction, amount)
+}
+
+// reserveChips reserves the chips in the player's wallet for the room, or the
+// tournament, with the ID.
+func reserveChips(wallet IChipWallet, reservationID string, player *Client, roomID string, transaction mq.ChipTransactionType, amount int) error {
+	err := wallet.Reserve(reservationID, player.User.Player.ID, roomID, transaction, amount)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, api.ErrInsufficientChips):
+		return ErrorGameInsufficientChips
+	default:
+		log.Printf("[ERROR] Failed to reserve chips - RoomID: %s, PlayerID: %s, Amount: %d, Error: %v", roomID, player.User.Player.ID, amount, err)
+		retryWallet(wallet, reservationID, func(wallet IChipWallet) error {
+			return wallet.Release(reservationID)
+		})
+		return ErrorGameWalletUnavailable
+	}
+}
+
+// releaseChips gives the reserved chips of a seat the player did not take
+// back to their wallet.
+func (g *Game) releaseChips(reservationID string) {
+	g.retryWallet(reservationID, func(wallet IChipWallet) error {
+		return wallet.Release(reservationID)
+	})
+}
+
+// retryWallet makes the wallet call in the background and repeats it until it
+// goes through, so the table does not wait on the wallet. Calls still pending
+// when the server stops are not lost for good: the next run releases every
+// reservation this one left open, see Server.ReleaseStaleReservations.
+func (g *Game) retryWallet(reservationID string, call func(wallet IChipWallet) error) {
+	retryWallet(g.Wallet, reservationID, call)
+}
+
+func retryWallet(wallet IChipWallet, reservationID string, call func(wallet IChipWallet) error) {
+	go func() {
+		for attempt := 1; ; attempt++ {
+			err := call(wallet)
+			if err == nil {
+				return
+			}
+
+			if attempt%walletAttempts == 0 {
+				log.Printf("[ERROR] Chip reservation still open, retrying - ReservationID: %s, Attempts: %d, Error: %v", reservationID, attempt, err)
+			}
+			time.Sleep(walletBackoff(attempt))
+		}
+	}()
+}
+
+// walletBackoff is how long to wait after the failed attempt.
+func walletBackoff(attempt int) time.Duration {
+	return min(time.Duration(attempt)*walletRetryDelay, walletMaxRetryDelay)
+}
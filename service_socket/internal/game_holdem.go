@@ -5,15 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math"
-	"math/rand"
-	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/ahmetkoprulu/rtrp/game/internal/mq"
+	"github.com/ahmetkoprulu/rtrp/game/internal/evaluator"
 	"github.com/ahmetkoprulu/rtrp/game/models"
+	"github.com/google/uuid"
 )
 
 type HoldemActionType int
@@ -25,6 +24,8 @@ const (
 	HoldemActionBet
 	HoldemActionCheck
 	HoldemActionAllIn
+	HoldemActionRunItTwiceAccept
+	HoldemActionRunItTwiceDecline
 )
 
 type HoldemMessageType int
@@ -39,6 +40,16 @@ const (
 	HoldemMessagePlayerAction
 	HoldemMessageShowdown
 	HoldemMessageWinner
+	HoldemMessageDeckCommitment
+	HoldemMessageDeckReveal
+	HoldemMessageRunItTwiceOffer
+	HoldemMessageRunItTwiceDecision
+	HoldemMessageRunItTwiceBoard
+	HoldemMessageRunItTwiceShowdown
+	HoldemMessageEquity
+	HoldemMessageTimeBank
+	HoldemMessageBlindLevelUp
+	HoldemMessageBlindLevelTime
 )
 
 type HandRank int
@@ -56,27 +67,72 @@ const (
 	RoyalFlush
 )
 
+// HoldemVariant is what sets apart the community card games played on the
+// Holdem engine.
+type HoldemVariant struct {
+	HoleCards int
+	Betting   BettingStructureType
+	Evaluator evaluator.HandEvaluator
+}
+
+var TexasHoldem = HoldemVariant{
+	HoleCards: 2,
+	Betting:   BettingNoLimit,
+	Evaluator: evaluator.EvaluateHoldem,
+}
+
+func (v HoldemVariant) EvaluateHand(hand, board []models.Card) evaluator.Strength {
+	return v.Evaluator(evaluator.FromModels(hand), evaluator.FromModels(board))
+}
+
 type Holdem struct {
 	State          HoldemState
+	variant        HoldemVariant
+	betting        IBettingStructure
 	deck           *models.Deck
+	serverSeed     string
+	shuffleProof   models.ShuffleProof
+	history        *HandRecord
+	runItTwice     runItTwiceOffer
 	game           *Game
-	actionsChannel chan GameAction
+	turn           actionTurn
+	timeBanks      timeBanks
 	messageChannel chan models.Response
 	doneChannel    chan bool
+	pendingBlinds  *holdemBlinds
+	blindLevel     int // Level of the game's blind clock in play, -1 before the first
 
 	Mu sync.RWMutex
 }
 
+// holdemBlinds are blinds waiting for the hand being played to finish.
+type holdemBlinds struct {
+	smallBlind int
+	bigBlind   int
+	ante       int
+	betting    IBettingStructure
+}
+
 type HoldemState struct {
+	HandID           string
 	Pot              int
 	CurrentRound     HoldemRound
 	CurrentBet       int
 	BigBlindAmount   int
 	SmallBlindAmount int
+	AnteAmount       int
 	RoundComplete    bool
+	LastRaiseSize    int // Size of the last full bet or raise this round
+	BetCount         int // Bets and raises this round, the big blind included
 
-	DealerSeat     *TableSeat
-	SmallBlindSeat *TableSeat
+	// Positions of the button and the blinds, which stay on empty seats for
+	// the dead button and a dead small blind. -1 before the first hand.
+	ButtonPosition     int
+	SmallBlindPosition int
+	BigBlindPosition   int
+
+	DealerSeat     *TableSeat // Last seat dealt in at or before the button
+	SmallBlindSeat *TableSeat // nil when the small blind is dead
 	BigBlindSeat   *TableSeat
 	CurrentSeat    *TableSeat
 	LastRaiserSeat *TableSeat
@@ -85,10 +141,11 @@ type HoldemState struct {
 	PlayerBets              map[string]int
 	PlayerTotalContribution map[string]int
 	PlayerLastAction        map[string]HoldemActionType
+	PlayerActedAt           map[string]int // BetCount when each player last acted this round
 	CommunityCards          []models.Card
 
-	// Side pots for all-in situations
-	// SidePots []SidePot
+	// Main pot first, a new side pot starts at every all-in
+	SidePots []SidePot
 }
 
 type TableSeat struct {
@@ -104,6 +161,8 @@ type HandResult struct {
 	HighCards []int    `json:"high_cards"`
 	PlayerID  string   `json:"player_id"`
 	Amount    int      `json:"amount"`
+
+	Strength evaluator.Strength `json:"-"`
 }
 
 type HoldemRound int
@@ -131,6 +190,7 @@ type HoldemRoundStartResponse struct {
 	DealerSeat       int           `json:"dealer_seat"`
 	SmallBlindAmount int           `json:"small_blind_amount"`
 	BigBlindAmount   int           `json:"big_blind_amount"`
+	AnteAmount       int           `json:"ante_amount"`
 	Hand             []models.Card `json:"hand"`
 }
 
@@ -154,13 +214,30 @@ type HoldemWinnerMessage struct {
 
 type HoldemShowdownMessage struct {
 	Winners   []HandResult `json:"winners"`
+	Pots      []PotResult  `json:"pots"`
 	Pot       int          `json:"pot"`
 	GameState interface{}  `json:"game_state"`
 }
 
+// HoldemBlindLevelMessage is the level of the blind schedule the table plays
+// and how long it has left. Next is nil on the last level.
+type HoldemBlindLevelMessage struct {
+	Level       int                `json:"level"` // Counted from 1
+	SmallBlind  int                `json:"small_blind"`
+	BigBlind    int                `json:"big_blind"`
+	Ante        int                `json:"ante"`
+	SecondsLeft int                `json:"seconds_left"`
+	Next        *models.BlindLevel `json:"next,omitempty"`
+}
+
 type HoldemPlayerTurnMessage struct {
-	PlayerID string `json:"player_id"`
-	Timeout  int    `json:"timeout"`
+	PlayerID   string    `json:"player_id"`
+	Timeout    int       `json:"timeout"`
+	Deadline   time.Time `json:"deadline"`
+	TimeBank   int       `json:"time_bank"`
+	CanRaise   bool      `json:"can_raise"`
+	MinRaiseTo int       `json:"min_raise_to"`
+	MaxRaiseTo int       `json:"max_raise_to"`
 }
 
 func NewHoldem(game *Game) *Holdem {
@@ -177,6 +254,10 @@ func NewHoldem(game *Game) *Holdem {
 			CurrentBet:       0,
 			RoundComplete:    false,
 
+			ButtonPosition:     -1,
+			SmallBlindPosition: -1,
+			BigBlindPosition:   -1,
+
 			DealerSeat:     nil,
 			CurrentSeat:    nil,
 			SmallBlindSeat: nil,
@@ -186,17 +267,113 @@ func NewHoldem(game *Game) *Holdem {
 			Seats:                   make(map[int]*TableSeat),
 			PlayerBets:              make(map[string]int),
 			PlayerTotalContribution: make(map[string]int),
+			SidePots:                []SidePot{},
 			CommunityCards:          make([]models.Card, 0),
 		},
-		actionsChannel: game.ActionChan,
+		variant:        TexasHoldem,
+		betting:        NoLimit{},
+		timeBanks:      timeBanks{banks: make(map[string]time.Duration)},
 		messageChannel: game.MessageChan,
 		doneChannel:    make(chan bool),
 		deck:           models.NewDeck(),
 		game:           game,
+		blindLevel:     -1,
 		Mu:             sync.RWMutex{},
 	}
 }
 
+// SetBettingStructure changes the table's betting structure. An empty type
+// keeps the default of the game.
+func (h *Holdem) SetBettingStructure(structureType BettingStructureType) error {
+	if structureType == "" {
+		structureType = h.variant.Betting
+	}
+
+	betting, err := NewBettingStructure(structureType, h.State.BigBlindAmount)
+	if err != nil {
+		return err
+	}
+
+	h.betting = betting
+	return nil
+}
+
+// SetBlinds changes the blinds and the ante from the next hand on, at once
+// when no hand is played. Fixed limit bet sizes follow the big blind. The
+// caller holds the game's Mu.
+func (h *Holdem) SetBlinds(smallBlind, bigBlind, ante int) error {
+	if smallBlind <= 0 || bigBlind < smallBlind || ante < 0 || ante >= bigBlind {
+		return ErrorGameInvalidBlinds
+	}
+
+	betting, err := NewBettingStructure(h.betting.Type(), bigBlind)
+	if err != nil {
+		return err
+	}
+
+	h.pendingBlinds = &holdemBlinds{smallBlind: smallBlind, bigBlind: bigBlind, ante: ante, betting: betting}
+	if h.game.Status == GameStatusWaiting {
+		h.applyPendingBlinds()
+	}
+
+	return nil
+}
+
+// applyPendingBlinds puts the blinds set during the last hand in play. The
+// caller holds the game's Mu.
+func (h *Holdem) applyPendingBlinds() {
+	if h.pendingBlinds == nil {
+		return
+	}
+
+	h.State.SmallBlindAmount = h.pendingBlinds.smallBlind
+	h.State.BigBlindAmount = h.pendingBlinds.bigBlind
+	h.State.AnteAmount = h.pendingBlinds.ante
+	h.betting = h.pendingBlinds.betting
+	h.game.MinBet = h.pendingBlinds.bigBlind
+	h.pendingBlinds = nil
+
+	log.Printf("[INFO] Blinds changed - GameID: %s, SmallBlind: %d, BigBlind: %d, Ante: %d", h.game.ID, h.State.SmallBlindAmount, h.State.BigBlindAmount, h.State.AnteAmount)
+}
+
+// followBlindClock puts the level of the game's blind clock in play when it
+// moved on since the last hand, and tells the table how long the level has
+// left. The clock of a cash table starts with its first hand. The caller
+// holds the game's Mu.
+func (h *Holdem) followBlindClock() {
+	clock := h.game.BlindClock
+	if clock == nil {
+		return
+	}
+
+	clock.start()
+	level, blinds, endsAt := clock.current()
+	msg := HoldemBlindLevelMessage{
+		Level:      level + 1,
+		SmallBlind: blinds.SmallBlind,
+		BigBlind:   blinds.BigBlind,
+		Ante:       blinds.Ante,
+	}
+	if !endsAt.IsZero() {
+		msg.SecondsLeft = max(int(time.Until(endsAt).Seconds()), 0)
+		msg.Next = &clock.levels[level+1]
+	}
+
+	if level != h.blindLevel {
+		if err := h.SetBlinds(blinds.SmallBlind, blinds.BigBlind, blinds.Ante); err != nil {
+			log.Printf("[ERROR] Failed to follow blind clock - GameID: %s, Level: %d, Error: %v", h.game.ID, level+1, err)
+			return
+		}
+
+		h.applyPendingBlinds()
+		h.blindLevel = level
+		h.SendMessage(HoldemMessageBlindLevelUp, msg)
+		return
+	}
+
+	h.SendMessage(HoldemMessageBlindLevelTime, msg)
+}
+
 func (h *Holdem) RefreshState() {
 	// h.game.Mu.Lock()
 	// defer h.game.Mu.Unlock()
@@ -207,7 +384,12 @@ func (h *Holdem) RefreshState() {
 	h.State.Pot = 0
 	h.State.CurrentBet = 0
 	h.State.RoundComplete = false
+	h.State.LastRaiseSize = 0
+	h.State.BetCount = 0
 
+	h.State.ButtonPosition = -1
+	h.State.SmallBlindPosition = -1
+	h.State.BigBlindPosition = -1
 	h.State.DealerSeat = nil
 	h.State.CurrentSeat = nil
 	h.State.SmallBlindSeat = nil
@@ -216,8 +398,10 @@ func (h *Holdem) RefreshState() {
 
 	h.State.PlayerBets = make(map[string]int)
 	h.State.PlayerTotalContribution = make(map[string]int)
+	h.State.SidePots = []SidePot{}
 	h.State.Seats = make(map[int]*TableSeat)
 	h.State.PlayerLastAction = make(map[string]HoldemActionType)
+	h.State.PlayerActedAt = make(map[string]int)
 	h.State.CommunityCards = make([]models.Card, 0)
 }
 
@@ -227,6 +411,15 @@ func (h *Holdem) ProcessAction(msg json.RawMessage) error {
 		return err
 	}
 
+	if action.Action == HoldemActionRunItTwiceAccept || action.Action == HoldemActionRunItTwiceDecline {
+		return h.VoteRunItTwice(action)
+	}
+
+	return h.turn.submit(action)
+}
+
+// ApplyAction carries out the action of the player whose turn it is.
+func (h *Holdem) ApplyAction(action HoldemActionMessage) error {
 	player := h.State.CurrentSeat.Player
 	log.Printf("[INFO] Player %s processing action: %+v", player.Client.User.Player.ID, action)
 	if action.PlayerID != player.Client.User.Player.ID {
@@ -242,6 +435,7 @@ func (h *Holdem) ProcessAction(msg json.RawMessage) error {
 	playerBet := h.State.PlayerBets[player.Client.User.Player.ID]
 	toCall := min(h.State.CurrentBet-playerBet, player.Balance)
 
+	amount := 0 // Total bet for the action, recorded in the hand history
 	switch action.Action {
 	case HoldemActionFold:
 		h.State.CurrentSeat.Hand = nil
@@ -264,25 +458,33 @@ func (h *Holdem) ProcessAction(msg json.RawMessage) error {
 		h.State.Pot += toCall
 		h.UpdatePlayerBet(player.Client.User.Player.ID, toCall)
 		h.State.PlayerLastAction[player.Client.User.Player.ID] = HoldemActionCall
+		amount = toCall
 		log.Printf("[INFO] Player %s calls %d", player.Client.User.Player.ID, toCall)
 
 	case HoldemActionBet:
 		if h.State.CurrentBet > 0 {
 			return errors.New("cannot bet, must raise")
 		}
-		if action.Amount < h.State.BigBlindAmount {
-			return errors.New("bet must be at least the big blind")
+		minBet, maxBet := h.BetLimits(player)
+		if action.Amount < minBet {
+			return errors.New("bet is below the minimum")
 		}
 		if action.Amount > player.Balance {
 			return errors.New("insufficient balance to bet")
 		}
+		if action.Amount > maxBet {
+			return errors.New("bet exceeds the maximum")
+		}
 
 		player.Balance -= action.Amount
 		h.State.Pot += action.Amount
 		h.UpdatePlayerBet(player.Client.User.Player.ID, action.Amount)
+		h.State.LastRaiseSize = action.Amount
+		h.State.BetCount++
 		h.State.CurrentBet = action.Amount
 		h.State.LastRaiserSeat = h.State.CurrentSeat
 		h.State.PlayerLastAction[player.Client.User.Player.ID] = HoldemActionBet
+		amount = action.Amount
 
 		log.Printf("[INFO] Player %s bets %d", player.Client.User.Player.ID, action.Amount)
 
@@ -290,21 +492,33 @@ func (h *Holdem) ProcessAction(msg json.RawMessage) error {
 		if h.State.CurrentBet == 0 {
 			return errors.New("cannot raise, must bet")
 		}
-		minRaise := h.State.CurrentBet*2 - playerBet
+		if !h.betting.CanRaise(&h.State) {
+			return errors.New("betting is capped for this round")
+		}
+		if !h.RaiseReopened(player) {
+			return errors.New("betting is not reopened, call or fold")
+		}
+		minRaise, maxRaise := h.BetLimits(player)
 		if action.Amount < minRaise {
-			return errors.New("raise must be at least double the current bet")
+			return errors.New("raise is below the minimum")
 		}
-		if action.Amount > player.Balance {
+		if action.Amount-playerBet > player.Balance {
 			return errors.New("insufficient balance to raise")
 		}
+		if action.Amount > maxRaise {
+			return errors.New("raise exceeds the maximum")
+		}
 
 		toRaise := action.Amount - playerBet
 		player.Balance -= toRaise
 		h.State.Pot += toRaise
 		h.UpdatePlayerBet(player.Client.User.Player.ID, toRaise)
+		h.State.LastRaiseSize = action.Amount - h.State.CurrentBet
+		h.State.BetCount++
 		h.State.CurrentBet = action.Amount
 		h.State.LastRaiserSeat = h.State.CurrentSeat
 		h.State.PlayerLastAction[player.Client.User.Player.ID] = HoldemActionRaise
+		amount = action.Amount
 		log.Printf("[INFO] Player %s raises to %d", player.Client.User.Player.ID, action.Amount)
 
 	case HoldemActionAllIn:
@@ -313,19 +527,44 @@ func (h *Holdem) ProcessAction(msg json.RawMessage) error {
 		}
 
 		allInAmount := player.Balance + playerBet
-		h.State.Pot += player.Balance
-		h.UpdatePlayerBet(player.Client.User.Player.ID, player.Balance)
-
 		if allInAmount > h.State.CurrentBet {
-			h.State.CurrentBet = allInAmount
-			h.State.LastRaiserSeat = h.State.CurrentSeat
+			if !h.betting.CanRaise(&h.State) {
+				return errors.New("betting is capped for this round")
+			}
+			if !h.RaiseReopened(player) {
+				return errors.New("betting is not reopened, call or fold")
+			}
+			if minRaise, maxRaise := h.BetLimits(player); allInAmount > maxRaise {
+				return errors.New("all-in exceeds the maximum")
+			} else if allInAmount >= minRaise {
+				// Only a full raise counts towards the cap and the next
+				// minimum raise, and reopens the betting for the players
+				// who already acted. A smaller all-in only has to be
+				// called.
+				h.State.LastRaiseSize = allInAmount - h.State.CurrentBet
+				h.State.BetCount++
+				h.State.LastRaiserSeat = h.State.CurrentSeat
+			}
 		}
 
+		h.State.Pot += player.Balance
+		h.UpdatePlayerBet(player.Client.User.Player.ID, player.Balance)
+		h.State.CurrentBet = max(h.State.CurrentBet, allInAmount)
+
 		player.Balance = 0
 		h.State.PlayerLastAction[player.Client.User.Player.ID] = HoldemActionAllIn
+		amount = allInAmount
 		log.Printf("[INFO] Player %s goes all-in with %d", player.Client.User.Player.ID, allInAmount)
 	}
 
+	h.State.PlayerActedAt[player.Client.User.Player.ID] = h.State.BetCount
+	h.UpdateSidePots()
+	h.RecordAction(HoldemActionMessage{
+		PlayerID: player.Client.User.Player.ID,
+		Action:   action.Action,
+		Amount:   amount,
+	})
+
 	h.SendMessage(HoldemMessagePlayerAction, HoldemActionMessage{
 		PlayerID: player.Client.User.Player.ID,
 		Action:   action.Action,
@@ -337,41 +576,47 @@ func (h *Holdem) ProcessAction(msg json.RawMessage) error {
 	return nil
 }
 
+// RaiseReopened reports whether the player may raise: they have not acted yet
+// this round, or a full bet or raise came after they did. An all-in for less
+// than a full raise does not reopen the betting.
+func (h *Holdem) RaiseReopened(player *GamePlayer) bool {
+	actedAt, acted := h.State.PlayerActedAt[player.Client.User.Player.ID]
+	return !acted || h.State.BetCount > actedAt
+}
+
+// BetLimits returns the range the player may bet or raise to under the
+// table's betting structure.
+func (h *Holdem) BetLimits(player *GamePlayer) (int, int) {
+	playerBet := h.State.PlayerBets[player.Client.User.Player.ID]
+	return h.betting.BetLimits(&h.State, playerBet, player.Balance)
+}
+
 func (h *Holdem) DealCards() error {
 	for _, seat := range h.State.Seats {
 		seat.Hand = seat.Hand[:0]
 	}
 
-	currentSeat := h.State.DealerSeat.Next
-	startPos := currentSeat.Position
-	for {
-		if currentSeat.Player.Status == GamePlayerStatusActive {
-			card, err := h.deck.Draw()
-			if err != nil {
-				return err
+	startSeat := h.State.DealerSeat.Next
+	for i := 0; i < h.variant.HoleCards; i++ {
+		currentSeat := startSeat
+		for {
+			if currentSeat.Player.Status == GamePlayerStatusActive {
+				card, err := h.deck.Draw()
+				if err != nil {
+					return err
+				}
+				currentSeat.Hand = append(currentSeat.Hand, card)
+			}
+			currentSeat = currentSeat.Next
+			if currentSeat == startSeat {
+				break
 			}
-			currentSeat.Hand = append(currentSeat.Hand, card)
-		}
-		currentSeat = currentSeat.Next
-		if currentSeat.Position == startPos {
-			break
 		}
 	}
 
-	currentSeat = h.State.DealerSeat.Next
-	for {
-		if currentSeat.Player.Status == GamePlayerStatusActive {
-			card, err := h.deck.Draw()
-			if err != nil {
-				return err
-			}
-			currentSeat.Hand = append(currentSeat.Hand, card)
-			log.Printf("[INFO] Dealt cards to player %s: %v",
-				currentSeat.Player.Client.User.Player.ID, currentSeat.Hand)
-		}
-		currentSeat = currentSeat.Next
-		if currentSeat.Position == startPos {
-			break
+	for _, seat := range h.sortedSeats() {
+		if seat.Player.Status == GamePlayerStatusActive {
+			log.Printf("[INFO] Dealt cards to player %s: %v", seat.Player.Client.User.Player.ID, seat.Hand)
 		}
 	}
 
@@ -385,74 +630,139 @@ func (h *Holdem) DealCards() error {
 func (h *Holdem) PlayRound() {
 	log.Printf("[INFO] Starting new round")
 	h.HandlePlayers()
+	h.MoveButton()
 	log.Printf("[INFO] Players after handling: %v", &h.game.Players)
-	h.deck = models.NewDeck()
-
-	h.StartPreFlopRound()
-	if !h.CanGameContinue() {
-		h.GraduallyEndTheGame()
+	if len(h.State.Seats) < 2 {
+		log.Printf("[INFO] Not enough players dealt in, waiting for players")
+		h.End()
 		return
 	}
 
-	communityCards := h.State.CommunityCards
-	h.StartFlopRound(communityCards)
-	if !h.CanGameContinue() {
-		h.GraduallyEndTheGame()
+	if err := h.PrepareDeck(); err != nil {
+		log.Printf("[ERROR] Failed to prepare deck: %v", err)
+		h.End()
 		return
 	}
 
-	h.StartTurnRound(communityCards)
+	h.StartPreFlopRound()
 	if !h.CanGameContinue() {
 		h.GraduallyEndTheGame()
 		return
 	}
 
-	h.StartRiverRound(communityCards)
-	h.StartShowdownRound()
+	communityCards := h.State.CommunityCards
+	streets := []func([]models.Card){h.StartFlopRound, h.StartTurnRound, h.StartRiverRound}
+	ranTwice := false
+	for i, street := range streets {
+		h.BroadcastEquity()
+		if ranTwice = h.RunItTwice(communityCards); ranTwice {
+			break
+		}
+
+		street(communityCards)
+		if i < len(streets)-1 && !h.CanGameContinue() {
+			h.GraduallyEndTheGame()
+			return
+		}
+	}
+
+	if !ranTwice {
+		h.StartShowdownRound()
+	}
 	h.LogGameState("HAND COMPLETE")
 
 	timer := time.NewTimer(1 * time.Second)
 	<-timer.C
 
-	if h.CanGameContinue() {
+	if h.CanStart() {
 		h.PlayRound()
 	} else {
 		h.End()
 	}
 }
 
+// PrepareDeck shuffles the deck for the next hand from a fresh server seed and
+// the players' client seeds, then publishes the commitment before any card is
+// dealt. The server seed stays private until RevealDeck.
+func (h *Holdem) PrepareDeck() error {
+	serverSeed, err := models.NewServerSeed()
+	if err != nil {
+		return err
+	}
+
+	h.State.HandID = uuid.New().String()
+	clientSeed := h.ClientSeed()
+
+	h.serverSeed = serverSeed
+	h.deck = models.NewSeededDeck(serverSeed, clientSeed)
+	h.shuffleProof = models.ShuffleProof{
+		HandID:     h.State.HandID,
+		Commitment: models.Commitment(serverSeed, clientSeed),
+		ClientSeed: clientSeed,
+	}
+
+	log.Printf("[FAIRNESS] Hand %s commitment: %s", h.State.HandID, h.shuffleProof.Commitment)
+	h.SendMessage(HoldemMessageDeckCommitment, h.shuffleProof)
+	return nil
+}
+
+// ClientSeed combines the hand ID with the seeds contributed by the seated
+// players, ordered by position.
+func (h *Holdem) ClientSeed() string {
+	parts := []string{h.State.HandID}
+	for _, seat := range h.sortedSeats() {
+		parts = append(parts, seat.Player.ClientSeed)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// RevealDeck publishes the server seed of the finished hand so players can
+// check it against the commitment and rebuild the deck.
+func (h *Holdem) RevealDeck() {
+	if h.serverSeed == "" {
+		return
+	}
+
+	h.shuffleProof.ServerSeed = h.serverSeed
+	h.serverSeed = ""
+
+	h.SendMessage(HoldemMessageDeckReveal, h.shuffleProof)
+}
+
 func (h *Holdem) StartPreFlopRound() {
 	h.State.CurrentRound = PreFlop
 	h.State.RoundComplete = false
 	h.State.Pot = 0
 	h.State.CurrentBet = 0
 	h.State.LastRaiserSeat = nil
+	h.State.LastRaiseSize = 0
+	h.State.BetCount = 0
 	h.State.PlayerBets = make(map[string]int)
 	h.State.PlayerTotalContribution = make(map[string]int)
+	h.State.SidePots = []SidePot{}
 	h.State.PlayerLastAction = make(map[string]HoldemActionType)
-
-	h.RotateDealerButton()
-	if !h.SetBlindPositions() {
-		log.Printf("[ERROR] Not enough players to set blind positions")
-		return
-	}
+	h.State.PlayerActedAt = make(map[string]int)
 
 	if err := h.DealCards(); err != nil {
 		log.Printf("[ERROR] Failed to deal cards: %v", err)
 		return
 	}
 
+	h.BeginHandRecord()
+	h.RefillTimeBanks()
 	h.PostBlinds()
 
 	for _, seat := range h.State.Seats {
 		msg := HoldemRoundStartResponse{
-			SmallBlind:       h.State.SmallBlindSeat.Position,
-			BigBlind:         h.State.BigBlindSeat.Position,
+			SmallBlind:       h.State.SmallBlindPosition,
+			BigBlind:         h.State.BigBlindPosition,
 			Pot:              h.State.Pot,
 			CurrentBet:       h.State.CurrentBet,
-			DealerSeat:       h.State.DealerSeat.Position,
+			DealerSeat:       h.State.ButtonPosition,
 			SmallBlindAmount: h.State.SmallBlindAmount,
 			BigBlindAmount:   h.State.BigBlindAmount,
+			AnteAmount:       h.State.AnteAmount,
 		}
 
 		if seat.Player.Status == GamePlayerStatusActive {
@@ -471,13 +781,12 @@ func (h *Holdem) StartPreFlopRound() {
 	})
 
 	h.State.CurrentSeat = h.State.BigBlindSeat.Next
-	h.LogGameState(fmt.Sprintf("HAND STARTED - PRE-FLOP BETTING BEGINS Small Blind: %d, Big Blind: %d", h.State.SmallBlindSeat.Position, h.State.BigBlindSeat.Position))
+	h.LogGameState(fmt.Sprintf("HAND STARTED - PRE-FLOP BETTING BEGINS Button: %d, Small Blind: %d, Big Blind: %d", h.State.ButtonPosition, h.State.SmallBlindPosition, h.State.BigBlindPosition))
 	if err := h.BettingRound(); err != nil {
 		log.Printf("[ERROR] Betting round error: %v", err)
 		return
 	}
 
-	_ = h.UpdatePlayerChipsForBet()
 	h.LogGameState("PRE-FLOP BETTING COMPLETE")
 }
 
@@ -486,8 +795,11 @@ func (h *Holdem) StartFlopRound(communityCards []models.Card) {
 	h.State.RoundComplete = false
 	h.State.CurrentBet = 0
 	h.State.LastRaiserSeat = nil
+	h.State.LastRaiseSize = 0
+	h.State.BetCount = 0
 	h.State.PlayerBets = make(map[string]int)
 	h.State.PlayerLastAction = make(map[string]HoldemActionType)
+	h.State.PlayerActedAt = make(map[string]int)
 
 	h.State.CommunityCards = communityCards[:3]
 	h.State.CurrentSeat = h.State.DealerSeat.Next
@@ -504,7 +816,6 @@ func (h *Holdem) StartFlopRound(communityCards []models.Card) {
 		return
 	}
 
-	_ = h.UpdatePlayerChipsForBet()
 	h.LogGameState("FLOP BETTING COMPLETE")
 }
 
@@ -513,8 +824,11 @@ func (h *Holdem) StartTurnRound(communityCards []models.Card) {
 	h.State.RoundComplete = false
 	h.State.CurrentBet = 0
 	h.State.LastRaiserSeat = nil
+	h.State.LastRaiseSize = 0
+	h.State.BetCount = 0
 	h.State.PlayerBets = make(map[string]int)
 	h.State.PlayerLastAction = make(map[string]HoldemActionType)
+	h.State.PlayerActedAt = make(map[string]int)
 	h.State.CommunityCards = communityCards[:4]
 	h.State.CurrentSeat = h.State.DealerSeat.Next
 
@@ -530,7 +844,6 @@ func (h *Holdem) StartTurnRound(communityCards []models.Card) {
 		return
 	}
 
-	_ = h.UpdatePlayerChipsForBet()
 	h.LogGameState("TURN BETTING COMPLETE")
 }
 
@@ -539,8 +852,11 @@ func (h *Holdem) StartRiverRound(communityCards []models.Card) {
 	h.State.RoundComplete = false
 	h.State.CurrentBet = 0
 	h.State.LastRaiserSeat = nil
+	h.State.LastRaiseSize = 0
+	h.State.BetCount = 0
 	h.State.PlayerBets = make(map[string]int)
 	h.State.PlayerLastAction = make(map[string]HoldemActionType)
+	h.State.PlayerActedAt = make(map[string]int)
 
 	h.State.CommunityCards = communityCards[:5]
 	h.State.CurrentSeat = h.State.DealerSeat.Next
@@ -557,7 +873,6 @@ func (h *Holdem) StartRiverRound(communityCards []models.Card) {
 		return
 	}
 
-	_ = h.UpdatePlayerChipsForBet()
 	h.LogGameState("RIVER BETTING COMPLETE")
 }
 
@@ -573,30 +888,56 @@ func (h *Holdem) StartShowdownRound() {
 	results, err := h.EvaluateHands()
 	if err != nil {
 		log.Printf("[ERROR] Failed to evaluate hands: %v", err)
+		h.FinishHand(nil)
 		return
 	}
 
-	_ = h.UpdatePlayerChipsShowdown(results)
+	h.FinishHand(results)
 }
 
+// PostBlinds posts the antes, the small blind unless it is dead and the big
+// blind, then the blinds owed by players who chose to post now.
 func (h *Holdem) PostBlinds() {
-	smallBlindAmount := min(h.State.SmallBlindAmount, h.State.SmallBlindSeat.Player.Balance)
-	h.State.SmallBlindSeat.Player.Balance -= smallBlindAmount
-	h.State.Pot += smallBlindAmount
-	h.UpdatePlayerBet(h.State.SmallBlindSeat.Player.Client.User.Player.ID, smallBlindAmount)
+	if h.State.AnteAmount > 0 {
+		for _, seat := range h.sortedSeats() {
+			h.postAnte(seat.Player, h.State.AnteAmount)
+		}
+	}
+
+	if h.State.SmallBlindSeat != nil {
+		h.postBlind(h.State.SmallBlindSeat.Player, HandRecordSmallBlind, h.State.SmallBlindAmount)
+	}
+
+	bigBlind := h.State.BigBlindSeat.Player
+	h.postBlind(bigBlind, HandRecordBigBlind, h.State.BigBlindAmount)
+	h.State.BetCount = 1
+	bigBlind.MissedBigBlind = false
+	bigBlind.MissedSmallBlind = false
 
-	bigBlindAmount := min(h.State.BigBlindAmount, h.State.BigBlindSeat.Player.Balance)
-	h.State.BigBlindSeat.Player.Balance -= bigBlindAmount
-	h.State.Pot += bigBlindAmount
-	h.State.CurrentBet = bigBlindAmount
-	h.UpdatePlayerBet(h.State.BigBlindSeat.Player.Client.User.Player.ID, bigBlindAmount)
+	for _, seat := range h.sortedSeats() {
+		player := seat.Player
+		if player.MissedBigBlind {
+			h.postBlind(player, HandRecordBigBlind, h.State.BigBlindAmount)
+		}
+		if player.MissedSmallBlind {
+			h.postDeadBlind(player, h.State.SmallBlindAmount)
+		}
+
+		player.MissedBigBlind = false
+		player.MissedSmallBlind = false
+	}
 
-	log.Printf("[BLINDS] Player %s posts small blind %d", h.State.SmallBlindSeat.Player.Client.User.Player.ID, smallBlindAmount)
-	log.Printf("[BLINDS] Player %s posts big blind: %d", h.State.BigBlindSeat.Player.Client.User.Player.ID, bigBlindAmount)
+	h.UpdateSidePots()
 }
 
 func (h *Holdem) BettingRound() error {
 	log.Printf("[INFO] Starting betting round for %v", h.State.CurrentRound)
+	if h.BettingClosed() {
+		h.State.RoundComplete = true
+		log.Printf("[INFO] No player left to act in %v", h.State.CurrentRound)
+		return nil
+	}
+
 	for !h.State.RoundComplete {
 		if h.State.CurrentSeat == nil {
 			return errors.New("no current seat")
@@ -608,41 +949,14 @@ func (h *Holdem) BettingRound() error {
 			continue
 		}
 
-		h.SendMessage(HoldemMessagePlayerTurn, HoldemPlayerTurnMessage{
-			PlayerID: player.Client.User.Player.ID,
-			Timeout:  10, // 10 seconds timeout for action
-		})
-
 		playerBet := h.State.PlayerBets[player.Client.User.Player.ID]
 		toCall := h.State.CurrentBet - playerBet
-		log.Printf("[ACTION] Player %s to act | Current bet: $%d | Player bet: $%d | To call: $%d | Balance: $%d", player.Client.User.Player.ID, h.State.CurrentBet, playerBet, toCall, player.Balance)
-
-		timer := time.NewTimer(5 * time.Second)
-		actionReceived := make(chan bool, 1)
-		go func() {
-			select {
-			case action := <-h.actionsChannel:
-				if action.PlayerID == player.Client.User.Player.ID {
-					if err := h.ProcessAction(action.Data); err != nil {
-						log.Printf("[ERROR] Failed to process action: %v", err)
-						fold := HoldemActionMessage{
-							PlayerID: player.Client.User.Player.ID,
-							Action:   HoldemActionFold,
-						}
-						foldData, _ := json.Marshal(fold)
-						h.ProcessAction(foldData)
-					}
-					actionReceived <- true
-				}
-			case <-timer.C:
-				log.Printf("[INFO] Player %s timed out", player.Client.User.Player.ID)
-				h.handleTimeoutAction(player, toCall)
-				actionReceived <- true
-			}
-		}()
-
-		<-actionReceived
-		timer.Stop()
+		if player.Client.Connected() {
+			h.AwaitAction(player, toCall)
+		} else {
+			log.Printf("[INFO] Player %s is disconnected, acting for them", player.Client.User.Player.ID)
+			h.CheckOrFold(player, toCall)
+		}
 
 		if h.CheckRoundComplete() {
 			h.State.RoundComplete = true
@@ -658,40 +972,58 @@ func (h *Holdem) BettingRound() error {
 	return nil
 }
 
-func (h *Holdem) handleTimeoutAction(player *GamePlayer, toCall int) {
-	var autoAction HoldemActionMessage
-	random := rand.Intn(100)
-
-	if toCall > 0 && toCall <= player.Balance {
-		autoAction = HoldemActionMessage{
-			PlayerID: player.Client.User.Player.ID,
-			Action:   HoldemActionCall,
-			Amount:   toCall,
-		}
-	} else if random >= 50 {
-		autoAction = HoldemActionMessage{
-			PlayerID: player.Client.User.Player.ID,
-			Action:   HoldemActionRaise,
-			Amount:   player.Balance * 2 / 100,
-		}
-	} else if toCall == 0 && random < 50 {
-		autoAction = HoldemActionMessage{
-			PlayerID: player.Client.User.Player.ID,
-			Action:   HoldemActionCheck,
-		}
-	} else {
-		autoAction = HoldemActionMessage{
-			PlayerID: player.Client.User.Player.ID,
-			Action:   HoldemActionFold,
+// BettingClosed reports whether nobody can act any more this hand: everyone
+// still in is all-in, except at most one player who has nothing to call.
+func (h *Holdem) BettingClosed() bool {
+	withChips := []*TableSeat{}
+	for _, seat := range h.State.Seats {
+		if len(seat.Hand) > 0 && seat.Player.Balance > 0 {
+			withChips = append(withChips, seat)
 		}
 	}
 
-	autoData, _ := json.Marshal(autoAction)
-	h.ProcessAction(autoData)
+	switch len(withChips) {
+	case 0:
+		return true
+	case 1:
+		playerID := withChips[0].Player.Client.User.Player.ID
+		return h.State.PlayerBets[playerID] >= h.State.CurrentBet
+	}
+
+	return false
+}
+
+// handleTimeoutAction acts for a player who let their time run out: it checks
+// when it can and folds otherwise. A player who runs out of time
+// SitOutAfterTimeouts turns in a row sits out from the next hand.
+func (h *Holdem) handleTimeoutAction(player *GamePlayer, toCall int) {
+	h.CheckOrFold(player, toCall)
+
+	player.Timeouts++
+	limit := h.game.ActionTimer.SitOutAfterTimeouts
+	if limit <= 0 || player.Timeouts < limit || player.SittingOut {
+		return
+	}
+
+	playerID := player.Client.User.Player.ID
+	log.Printf("[INFO] Player %s timed out %d times in a row", playerID, player.Timeouts)
+	if err := h.game.SitOut(playerID); err != nil {
+		log.Printf("[ERROR] Failed to sit out player %s: %v", playerID, err)
+		return
+	}
+
+	h.game.Room.BroadcastToRoom(models.Response{
+		Type: models.MessageTypeSitOut,
+		Data: models.MessageSitOutResponse{
+			RoomID:   h.game.Room.ID,
+			PlayerID: playerID,
+			State:    h.game.Room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	})
 }
 
 func (h *Holdem) DealPlayerCards() error {
-	h.deck.Shuffle()
 	for pos := range h.State.Seats {
 		h.State.Seats[pos].Hand = h.State.Seats[pos].Hand[:0]
 	}
@@ -787,27 +1119,50 @@ func (h *Holdem) HandlePlayers() {
 	h.game.Mu.Lock()
 	defer h.game.Mu.Unlock()
 
-	h.game.Players = slices.DeleteFunc(h.game.Players, func(p *GamePlayer) bool {
-		return p.Status == GamePlayerStatusInactive || p.Balance < h.game.MinBet
-	})
+	h.applyPendingBlinds()
+	for _, player := range h.game.Players {
+		h.game.applyTopUp(player)
+	}
+	h.game.removeLeftPlayers()
 
+	// Only players dealt into the hand keep a seat in it, so players sitting
+	// out are skipped for the button, the blinds and the cards
+	seated := make(map[int]bool)
 	for _, player := range h.game.Players {
-		if player.Status == GamePlayerStatusWaiting {
+		switch {
+		case player.SittingOut:
+			player.Status = GamePlayerStatusSittingOut
+			continue
+		case player.Status == GamePlayerStatusWaiting || player.Status == GamePlayerStatusSittingOut:
 			player.Status = GamePlayerStatusActive
 		}
 
-		_, ok := h.State.Seats[player.Position]
-		if !ok {
+		seated[player.Position] = true
+		// A seat taken by another player since the last hand is set up anew
+		seat, ok := h.State.Seats[player.Position]
+		if !ok || seat.Player != player {
 			h.State.Seats[player.Position] = &TableSeat{
 				Position: player.Position,
 				Player:   player,
 			}
 		}
 
-		h.State.Seats[player.Position].Hand = make([]models.Card, 0, 2)
+		h.State.Seats[player.Position].Hand = make([]models.Card, 0, h.variant.HoleCards)
 
 	}
 
+	for position := range h.State.Seats {
+		if !seated[position] {
+			delete(h.State.Seats, position)
+		}
+	}
+
+	// Players leaving between games do not deal a hand
+	if h.game.Status == GameStatusStarted && len(h.State.Seats) >= 2 {
+		h.game.HandsDealt++
+		h.followBlindClock()
+	}
+
 	h.LinkSeats()
 }
 
@@ -864,7 +1219,7 @@ func (h *Holdem) CheckRoundComplete() bool {
 	// If we have gone all the way around back to the startSeat, we are done
 	for {
 		// Skip folded or all-in players
-		if currentSeat.Player != nil && currentSeat.Hand != nil && len(currentSeat.Hand) > 0 {
+		if currentSeat.Player != nil && currentSeat.Hand != nil && len(currentSeat.Hand) > 0 && currentSeat.Player.Balance > 0 {
 			playerBet, exists := h.State.PlayerBets[currentSeat.Player.Client.User.Player.ID]
 			_, lastActionExists := h.State.PlayerLastAction[currentSeat.Player.Client.User.Player.ID]
 			if !exists || playerBet < highestBet || !lastActionExists { // If player hasn't bet or their bet is less than the highest bet, round is not complete
@@ -920,7 +1275,13 @@ func (h *Holdem) Start() error {
 		}
 	}
 
+	// Tournament tables that waited between hands, for the other tables or
+	// for players to move in, carry on with their button
+	button, smallBlind, bigBlind := h.State.ButtonPosition, h.State.SmallBlindPosition, h.State.BigBlindPosition
 	h.RefreshState()
+	if h.game.Tournament != nil {
+		h.State.ButtonPosition, h.State.SmallBlindPosition, h.State.BigBlindPosition = button, smallBlind, bigBlind
+	}
 	go h.StartMessageChannel()
 
 	h.LogGameState("GAME STARTING")
@@ -936,9 +1297,7 @@ func (h *Holdem) End() error {
 	h.game.Mu.Lock()
 	defer h.game.Mu.Unlock()
 
-	h.game.Players = slices.DeleteFunc(h.game.Players, func(p *GamePlayer) bool {
-		return p.Status == GamePlayerStatusInactive || p.Balance < h.game.MinBet
-	})
+	h.game.removeLeftPlayers()
 
 	h.game.Status = GameStatusWaiting
 	log.Printf("[INFO] Ending holdem game")
@@ -952,6 +1311,16 @@ func (h *Holdem) End() error {
 }
 
 func (h *Holdem) CanStart() bool {
+	// Paused and closed rooms finish the hand being played but deal no more
+	if h.game.Room != nil && h.game.Room.GetStatus() != RoomStatusActive {
+		return false
+	}
+
+	// Tournament tables playing hand for hand wait for the others
+	if h.game.Tournament != nil && !h.game.Tournament.CanDeal(h.game) {
+		return false
+	}
+
 	activePlayers := 0
 	for _, player := range h.game.Players {
 		if player.Status == GamePlayerStatusActive || player.Status == GamePlayerStatusWaiting {
@@ -975,6 +1344,9 @@ func (h *Holdem) PlayersNotFoldedCount() int {
 
 func (h *Holdem) OnPlayerJoin(player *GamePlayer) error {
 	player.Status = GamePlayerStatusWaiting
+	if h.game.Status == GameStatusStarted && h.State.BigBlindPosition >= 0 {
+		player.MissedBigBlind = true
+	}
 	log.Printf("[INFO] Player %s joined the game", player.Client.User.Player.ID)
 
 	if h.game.Status == GameStatusWaiting && h.CanStart() {
@@ -997,56 +1369,9 @@ func (h *Holdem) OnPlayerLeave(player *GamePlayer) error {
 	return nil
 }
 
-func (h *Holdem) RotateDealerButton() {
-	if len(h.State.Seats) == 0 {
-		return
-	}
-
-	if h.State.DealerSeat == nil || h.game.Status == GameStatusStarting {
-		// Find the lowest position number for initial dealer
-		minPos := math.MaxInt32
-		for pos := range h.State.Seats {
-			if pos < minPos {
-				minPos = pos
-				h.State.DealerSeat = h.State.Seats[pos]
-			}
-		}
-	} else {
-		h.State.DealerSeat = h.State.DealerSeat.Next
-	}
-}
-
-func (h *Holdem) SetBlindPositions() bool {
-	if len(h.State.Seats) < 2 {
-		return false
-	}
-
-	if len(h.State.Seats) == 2 { // Heads-up
-		h.State.SmallBlindSeat = h.State.DealerSeat // In heads-up, dealer is SB
-		h.State.BigBlindSeat = h.State.DealerSeat.Next
-	} else {
-		h.State.SmallBlindSeat = h.State.DealerSeat.Next
-		h.State.BigBlindSeat = h.State.SmallBlindSeat.Next
-	}
-
-	return true
-}
-
 func (h *Holdem) EvaluateHands() ([]HandResult, error) {
 	log.Println("[INFO] Evaluating hands")
-	activePlayers := []*TableSeat{}
-	currentSeat := h.State.DealerSeat
-	startPos := currentSeat.Position
-
-	for {
-		if currentSeat.Hand != nil && len(currentSeat.Hand) > 0 && currentSeat.Player.Status != GamePlayerStatusInactive {
-			activePlayers = append(activePlayers, currentSeat)
-		}
-		currentSeat = currentSeat.Next
-		if currentSeat.Position == startPos {
-			break
-		}
-	}
+	activePlayers := h.ShowdownSeats()
 
 	if len(activePlayers) == 0 {
 		log.Println("[ERROR] No active players to evaluate hands")
@@ -1055,74 +1380,42 @@ func (h *Holdem) EvaluateHands() ([]HandResult, error) {
 
 	if len(activePlayers) == 1 {
 		winner := activePlayers[0]
-		winner.Player.Balance += h.State.Pot
-		log.Printf("[INFO] Player %s wins %d (uncontested)", winner.Player.Client.User.Player.ID, h.State.Pot)
-
-		h.SendMessage(HoldemMessageWinner, HoldemWinnerMessage{
-			WinnerID: winner.Player.Client.User.Player.ID,
-			Amount:   h.State.Pot,
-			Reason:   "uncontested",
-		})
-
-		return []HandResult{{
+		results := []HandResult{{
 			Rank:      HighCard,
 			HighCards: nil,
 			PlayerID:  winner.Player.Client.User.Player.ID,
-			Amount:    h.State.Pot,
-		}}, nil
-	}
-
-	results := []HandResult{}
-	for _, seat := range activePlayers {
-		allCards := append([]models.Card{}, seat.Hand...)
-		allCards = append(allCards, h.State.CommunityCards...)
-
-		handRank, highCards := evaluateBestHand(allCards)
-		results = append(results, HandResult{
-			Rank:      handRank,
-			HighCards: highCards,
-			PlayerID:  seat.Player.Client.User.Player.ID,
 			Amount:    0,
+		}}
+		h.RecordPots(h.DistributePots(results))
+		log.Printf("[INFO] Player %s wins %d (uncontested)", winner.Player.Client.User.Player.ID, results[0].Amount)
+
+		h.SendMessage(HoldemMessageWinner, HoldemWinnerMessage{
+			WinnerID: winner.Player.Client.User.Player.ID,
+			Amount:   results[0].Amount,
+			Reason:   "uncontested",
 		})
 
-		log.Printf("[INFO] Player %s has %v (rank: %d)", seat.Player.Client.User.Player.ID, seat.Hand, handRank)
+		return results, nil
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Rank != results[j].Rank {
-			return results[i].Rank > results[j].Rank
-		}
-
-		for k := 0; k < len(results[i].HighCards) && k < len(results[j].HighCards); k++ {
-			if results[i].HighCards[k] != results[j].HighCards[k] {
-				return results[i].HighCards[k] > results[j].HighCards[k]
-			}
-		}
-		return false
-	})
+	results := h.EvaluateBoard(activePlayers, h.State.CommunityCards)
 
-	winners := []HandResult{results[0]}
-	for i := 1; i < len(results); i++ {
-		if compareHands(results[i], results[0]) == 0 {
-			winners = append(winners, results[i])
-		} else {
-			break
-		}
-	}
+	// Every pot goes to the best hand among its eligible players, so a
+	// player can win a side pot without holding the best hand overall.
+	pots := h.DistributePots(results)
+	h.RecordPots(pots)
 
-	// Distribute pot among winners using side pot logic
-	h.DistributeSidePots(winners)
-
-	if len(winners) == 1 {
-		log.Printf("[INFO] Player %s wins $%d with hand rank %d", winners[0].PlayerID, winners[0].Amount, winners[0].Rank)
-	} else {
-		for _, winner := range winners {
-			log.Printf("[INFO] Player %s wins $%d with hand rank %d", winner.PlayerID, winner.Amount, winner.Rank)
+	winners := []HandResult{}
+	for _, result := range results {
+		if result.Amount > 0 {
+			winners = append(winners, result)
+			log.Printf("[INFO] Player %s wins $%d with hand rank %d", result.PlayerID, result.Amount, result.Rank)
 		}
 	}
 
 	h.SendMessage(HoldemMessageShowdown, HoldemShowdownMessage{
 		Winners:   winners,
+		Pots:      pots,
 		Pot:       h.State.Pot,
 		GameState: h.GetGameState(),
 	})
@@ -1132,339 +1425,78 @@ func (h *Holdem) EvaluateHands() ([]HandResult, error) {
 	return winners, nil
 }
 
-// Returns: 1 if hand1 > hand2, 0 if equal, -1 if hand1 < hand2
-func compareHands(hand1, hand2 HandResult) int {
-	// Compare hand ranks
-	if hand1.Rank > hand2.Rank {
-		return 1
-	}
-	if hand1.Rank < hand2.Rank {
-		return -1
-	}
-
-	// Same rank, compare high cards
-	for i := 0; i < len(hand1.HighCards) && i < len(hand2.HighCards); i++ {
-		if hand1.HighCards[i] > hand2.HighCards[i] {
-			return 1
-		}
-		if hand1.HighCards[i] < hand2.HighCards[i] {
-			return -1
-		}
-	}
-
-	// Hands are equal
-	return 0
-}
-
-// Evaluate the best 5-card hand from the given cards
-func evaluateBestHand(cards []models.Card) (HandRank, []int) {
-	if rank, highCards := checkStraightFlush(cards); rank != HighCard {
-		return rank, highCards
-	}
-
-	if highCards := checkFourOfAKind(cards); highCards != nil {
-		return FourOfAKind, highCards
-	}
-
-	if highCards := checkFullHouse(cards); highCards != nil {
-		return FullHouse, highCards
-	}
-
-	if highCards := checkFlush(cards); highCards != nil {
-		return Flush, highCards
-	}
-
-	if highCards := checkStraight(cards); highCards != nil {
-		return Straight, highCards
-	}
-
-	if highCards := checkThreeOfAKind(cards); highCards != nil {
-		return ThreeOfAKind, highCards
-	}
-
-	if highCards := checkTwoPair(cards); highCards != nil {
-		return TwoPair, highCards
-	}
-
-	if highCards := checkOnePair(cards); highCards != nil {
-		return OnePair, highCards
-	}
-
-	return HighCard, getHighCards(cards, 5)
-}
-
-func checkStraightFlush(cards []models.Card) (HandRank, []int) {
-	cardsBySuit := make(map[string][]models.Card)
-	for _, card := range cards {
-		cardsBySuit[card.Suit] = append(cardsBySuit[card.Suit], card)
-	}
-
-	for _, suitCards := range cardsBySuit {
-		if len(suitCards) >= 5 {
-			if highCard := checkStraight(suitCards); highCard != nil {
-				if highCard[0] == 14 {
-					return RoyalFlush, highCard
-				}
-				return StraightFlush, highCard
-			}
+// ShowdownSeats returns the seats still in the hand, starting from the dealer.
+func (h *Holdem) ShowdownSeats() []*TableSeat {
+	seats := []*TableSeat{}
+	currentSeat := h.State.DealerSeat
+	for {
+		if len(currentSeat.Hand) > 0 && currentSeat.Player.Status != GamePlayerStatusInactive {
+			seats = append(seats, currentSeat)
 		}
-	}
-
-	return HighCard, nil
-}
-
-func checkFourOfAKind(cards []models.Card) []int {
-	valueCounts := make(map[int]int)
-	for _, card := range cards {
-		valueCounts[card.Value]++
-	}
-
-	var fourOfAKind int
-	for value, count := range valueCounts {
-		if count >= 4 {
-			fourOfAKind = value
+		currentSeat = currentSeat.Next
+		if currentSeat == h.State.DealerSeat {
 			break
 		}
 	}
 
-	if fourOfAKind > 0 {
-		kicker := 0
-		for value := range valueCounts {
-			if value != fourOfAKind && value > kicker {
-				kicker = value
-			}
-		}
-
-		return []int{fourOfAKind, kicker}
-	}
-
-	return nil
-}
-
-func checkFullHouse(cards []models.Card) []int {
-	valueCounts := make(map[int]int)
-	for _, card := range cards {
-		valueCounts[card.Value]++
-	}
-
-	var threeKind, pair int
-	for value, count := range valueCounts {
-		if count >= 3 && value > threeKind {
-			threeKind = value
-		}
-	}
-
-	for value, count := range valueCounts {
-		if value != threeKind && count >= 2 && value > pair {
-			pair = value
-		}
-	}
-
-	if threeKind > 0 && pair > 0 {
-		return []int{threeKind, pair}
-	}
-
-	return nil
-}
-
-func checkFlush(cards []models.Card) []int {
-	cardsBySuit := make(map[string][]models.Card)
-	for _, card := range cards {
-		cardsBySuit[card.Suit] = append(cardsBySuit[card.Suit], card)
-	}
-
-	for _, suitCards := range cardsBySuit {
-		if len(suitCards) >= 5 {
-			return getHighCards(suitCards, 5)
-		}
-	}
-
-	return nil
+	return seats
 }
 
-func checkStraight(cards []models.Card) []int {
-	valueSet := make(map[int]bool)
-	for _, card := range cards {
-		valueSet[card.Value] = true
-	}
-
-	if valueSet[14] && valueSet[5] && valueSet[4] && valueSet[3] && valueSet[2] {
-		return []int{5} // 5-high straight
-	}
-
-	values := make([]int, 0, len(valueSet))
-	for value := range valueSet {
-		values = append(values, value)
-	}
-	sort.Sort(sort.Reverse(sort.IntSlice(values)))
-
-	for i := 0; i <= len(values)-5; i++ {
-		if values[i]-values[i+4] == 4 {
-			return []int{values[i]} // Return highest card
-		}
-	}
-
-	return nil
-}
-
-func checkThreeOfAKind(cards []models.Card) []int {
-	valueCounts := make(map[int]int)
-	for _, card := range cards {
-		valueCounts[card.Value]++
-	}
-
-	var threeKind int
-	for value, count := range valueCounts {
-		if count >= 3 && value > threeKind {
-			threeKind = value
-		}
-	}
-
-	if threeKind > 0 {
-		kickers := []int{}
-		for value := range valueCounts {
-			if value != threeKind {
-				kickers = append(kickers, value)
-			}
-		}
-
-		sort.Sort(sort.Reverse(sort.IntSlice(kickers)))
-		if len(kickers) > 2 {
-			kickers = kickers[:2]
+// EvaluateBoard evaluates the hands of the given seats against board, best
+// hand first.
+func (h *Holdem) EvaluateBoard(seats []*TableSeat, board []models.Card) []HandResult {
+	results := []HandResult{}
+	for _, seat := range seats {
+		strength := h.variant.EvaluateHand(seat.Hand, board)
+		if strength == 0 {
+			log.Printf("[ERROR] Could not evaluate hand %v of player %s on board %v", seat.Hand, seat.Player.Client.User.Player.ID, board)
 		}
+		results = append(results, HandResult{
+			Rank:      HandRank(strength.Category()),
+			HighCards: strength.HighCards(),
+			PlayerID:  seat.Player.Client.User.Player.ID,
+			Amount:    0,
+			Strength:  strength,
+		})
 
-		return append([]int{threeKind}, kickers...)
-	}
-
-	return nil
-}
-
-func checkTwoPair(cards []models.Card) []int {
-	valueCounts := make(map[int]int)
-	for _, card := range cards {
-		valueCounts[card.Value]++
-	}
-
-	pairs := []int{}
-	for value, count := range valueCounts {
-		if count >= 2 {
-			pairs = append(pairs, value)
-		}
+		log.Printf("[INFO] Player %s has %v (rank: %d)", seat.Player.Client.User.Player.ID, seat.Hand, strength.Category())
 	}
 
-	if len(pairs) >= 2 {
-		sort.Sort(sort.Reverse(sort.IntSlice(pairs)))
-
-		// Take highest two pairs
-		highPairs := pairs
-		if len(highPairs) > 2 {
-			highPairs = pairs[:2]
-		}
-
-		kicker := 0
-		for value := range valueCounts {
-			if value != highPairs[0] && value != highPairs[1] && value > kicker {
-				kicker = value
-			}
-		}
-
-		return append(highPairs, kicker)
-	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Strength > results[j].Strength
+	})
 
-	return nil
+	return results
 }
 
-func checkOnePair(cards []models.Card) []int {
-	valueCounts := make(map[int]int)
-	for _, card := range cards {
-		valueCounts[card.Value]++
-	}
-
-	var pair int
-	for value, count := range valueCounts {
-		if count >= 2 && value > pair {
-			pair = value
-		}
-	}
-
-	if pair > 0 {
-		kickers := []int{}
-		for value := range valueCounts {
-			if value != pair {
-				kickers = append(kickers, value)
-			}
-		}
-
-		sort.Sort(sort.Reverse(sort.IntSlice(kickers)))
-		if len(kickers) > 3 {
-			kickers = kickers[:3]
-		}
-
-		return append([]int{pair}, kickers...)
+// SendMessage sends the message to everyone in the room. Each recipient gets
+// its own copy of the state so hole cards are only visible to their owner.
+// Spectators get the public state after the room's spectator delay.
+func (h *Holdem) SendMessage(msgType HoldemMessageType, data interface{}) {
+	for _, playerID := range h.game.Room.GetPlayerIDs() {
+		h.SendMessageToPlayer(playerID, msgType, data)
 	}
 
-	return nil
+	state := h.GetGameState()
+	h.game.Room.BroadcastToSpectators(h.newResponse("", state, msgType, data), state)
 }
 
-func getHighCards(cards []models.Card, count int) []int {
-	values := []int{}
-	for _, card := range cards {
-		values = append(values, card.Value)
-	}
-
-	sort.Sort(sort.Reverse(sort.IntSlice(values)))
-
-	unique := []int{}
-	seen := make(map[int]bool)
-
-	for _, value := range values {
-		if !seen[value] {
-			seen[value] = true
-			unique = append(unique, value)
-		}
-	}
-
-	if len(unique) > count {
-		return unique[:count]
-	}
-	return unique
+func (h *Holdem) SendMessageToPlayer(playerID string, msgType HoldemMessageType, data interface{}) {
+	h.messageChannel <- h.newResponse(playerID, h.GetPlayerState(playerID), msgType, data)
 }
 
-func (h *Holdem) SendMessage(msgType HoldemMessageType, data interface{}) {
-
-	// jsonMsg, err := json.Marshal(msg)
-	// if err != nil {
-	// 	log.Printf("[ERROR] Failed to marshal message: %v", err)
-	// 	return
-	// }
-
-	response := models.Response{
-		Type: models.MessageTypeGameHoldemAction,
+func (h *Holdem) newResponse(playerID string, state any, msgType HoldemMessageType, data interface{}) models.Response {
+	return models.Response{
+		Type:     models.MessageTypeGameHoldemAction,
+		PlayerID: playerID,
 		Data: HoldemResponse{
 			RoomID: h.game.Room.ID,
-			State:  h.GetGameState(),
+			State:  state,
 			Type:   msgType,
 			Data:   data,
 		},
 		Timestamp: time.Now().UTC(),
 	}
-
-	h.messageChannel <- response
-}
-
-func (h *Holdem) SendMessageToPlayer(playerID string, msgType HoldemMessageType, data interface{}) {
-	response := models.Response{
-		Type:     models.MessageTypeGameHoldemAction,
-		PlayerID: playerID,
-		Data: HoldemResponse{
-			State: h.GetGameState(),
-			Type:  msgType,
-			Data:  data,
-		},
-		Timestamp: time.Now().UTC(),
-	}
-
-	h.messageChannel <- response
 }
 
 func (h *Holdem) StartMessageChannel() {
@@ -1516,9 +1548,10 @@ func (h *Holdem) UpdatePlayerBet(playerID string, amount int) {
 
 func (h *Holdem) GraduallyEndTheGame() error {
 	log.Printf("[INFO] Only one player remains - hand complete")
-	h.EvaluateHands()
+	results, _ := h.EvaluateHands()
+	h.FinishHand(results)
 
-	if h.CanGameContinue() {
+	if h.CanStart() {
 		h.PlayRound()
 	} else {
 		h.End()
@@ -1539,6 +1572,9 @@ type PlayerView struct {
 	IsSmallBlind      bool             `json:"is_small_blind"`
 	IsBigBlind        bool             `json:"is_big_blind"`
 	IsCurrentTurn     bool             `json:"is_current_turn"`
+	IsDisconnected    bool             `json:"is_disconnected"`
+	OwesBlinds        bool             `json:"owes_blinds"`
+	TimeBank          int              `json:"time_bank"`
 	CurrentBetInRound int              `json:"current_bet_in_round"`
 }
 
@@ -1546,22 +1582,30 @@ type GameView struct {
 	Players          []PlayerView
 	CommunityCards   []models.Card
 	Pot              int
+	SidePots         []SidePot
 	CurrentBet       int
 	CurrentRound     HoldemRound
 	SmallBlindAmount int
 	BigBlindAmount   int
+	AnteAmount       int
+	BettingStructure BettingStructureType
+	ButtonPosition   int
 }
 
+// GetGameState returns the public view of the table. Hole cards are hidden
+// for everyone except the hands revealed at showdown.
 func (h *Holdem) GetGameState() any {
-	visibleCards := []models.Card{}
-	switch h.State.CurrentRound {
-	case Flop:
-		visibleCards = h.State.CommunityCards[:3]
-	case Turn:
-		visibleCards = h.State.CommunityCards[:4]
-	case River, Showdown:
-		visibleCards = h.State.CommunityCards[:5]
-	}
+	return h.buildGameView("")
+}
+
+// GetPlayerState returns the table as seen by the given player, which is the
+// public view plus the player's own hole cards.
+func (h *Holdem) GetPlayerState(playerID string) any {
+	return h.buildGameView(playerID)
+}
+
+func (h *Holdem) buildGameView(viewerID string) GameView {
+	visibleCards := h.VisibleCommunityCards()
 
 	playerViews := []PlayerView{}
 	for _, player := range h.game.Players {
@@ -1574,6 +1618,9 @@ func (h *Holdem) GetGameState() any {
 			Name:              player.Client.User.Player.Username,
 			Balance:           player.Balance,
 			Hand:              []models.Card{},
+			IsDisconnected:    !player.Client.Connected(),
+			OwesBlinds:        player.MissedBigBlind || player.MissedSmallBlind,
+			TimeBank:          int(h.TimeBank(player.Client.User.Player.ID).Seconds()),
 			CurrentBetInRound: playerBetInRound,
 		}
 
@@ -1583,10 +1630,14 @@ func (h *Holdem) GetGameState() any {
 			continue
 		}
 
-		playerView.Hand = seat.Hand
+		if player.Client.User.Player.ID == viewerID || h.IsHandRevealed(seat) {
+			playerView.Hand = seat.Hand
+		} else {
+			playerView.Hand = models.HiddenCards(len(seat.Hand))
+		}
 		playerView.IsFolded = player.Status != GamePlayerStatusWaiting && seat.Hand == nil
 		playerView.IsAllIn = player.Status != GamePlayerStatusWaiting && player.Balance == 0
-		playerView.IsDealer = h.State.DealerSeat != nil && seat.Position == h.State.DealerSeat.Position
+		playerView.IsDealer = seat.Position == h.State.ButtonPosition
 		playerView.IsSmallBlind = h.State.SmallBlindSeat != nil && seat.Position == h.State.SmallBlindSeat.Position
 		playerView.IsBigBlind = h.State.BigBlindSeat != nil && seat.Position == h.State.BigBlindSeat.Position
 		playerView.IsCurrentTurn = h.State.CurrentSeat != nil && seat.Position == h.State.CurrentSeat.Position
@@ -1597,29 +1648,26 @@ func (h *Holdem) GetGameState() any {
 		Players:          playerViews,
 		CommunityCards:   visibleCards,
 		Pot:              h.State.Pot,
+		SidePots:         h.State.SidePots,
 		CurrentBet:       h.State.CurrentBet,
 		CurrentRound:     h.State.CurrentRound,
 		SmallBlindAmount: h.State.SmallBlindAmount,
 		BigBlindAmount:   h.State.BigBlindAmount,
+		AnteAmount:       h.State.AnteAmount,
+		BettingStructure: h.betting.Type(),
+		ButtonPosition:   h.State.ButtonPosition,
 	}
 }
 
-func (h *Holdem) GetPlayerState(playerID string) GameView {
-	baseState := h.GetGameState()
-
-	// Find the player's hand
-	// var playerHand []models.Card
-	for _, player := range h.game.Players {
-		if player.Client.User.Player.ID == playerID {
-			// playerHand = h.State.Seats[player.Position].Hand
-			break
-		}
+// IsHandRevealed reports whether the seat's hole cards are public. Only hands
+// that are still live against at least one other hand are shown, at showdown
+// or as soon as betting is closed by an all-in.
+func (h *Holdem) IsHandRevealed(seat *TableSeat) bool {
+	if len(seat.Hand) == 0 || h.PlayersNotFoldedCount() < 2 {
+		return false
 	}
 
-	// Add player's hand to the state
-	// baseState.Players[playerID].Hand = playerHand
-
-	return baseState.(GameView)
+	return h.State.CurrentRound == Showdown || h.BettingClosed()
 }
 
 func (h *Holdem) LogGameState(message string) {
@@ -1650,7 +1698,7 @@ func (h *Holdem) LogGameState(message string) {
 	}
 
 	log.Printf("[PLAYERS] Status:")
-	for i, player := range h.game.Players {
+	for _, player := range h.game.Players {
 		seat, ok := h.State.Seats[player.Position]
 		if ok {
 			status := "Active"
@@ -1664,16 +1712,16 @@ func (h *Holdem) LogGameState(message string) {
 			}
 
 			position := ""
-			if i == h.State.DealerSeat.Position {
+			if player.Position == h.State.ButtonPosition {
 				position += "Dealer "
 			}
-			if i == h.State.SmallBlindSeat.Position {
+			if player.Position == h.State.SmallBlindPosition && h.State.SmallBlindSeat != nil {
 				position += "SB "
 			}
-			if i == h.State.BigBlindSeat.Position {
+			if player.Position == h.State.BigBlindPosition {
 				position += "BB "
 			}
-			if i == h.State.CurrentSeat.Position {
+			if h.State.CurrentSeat != nil && player.Position == h.State.CurrentSeat.Position {
 				position += "Acting "
 			}
 
@@ -1707,124 +1755,3 @@ func Where[T any](players []T, condition func(player T) bool) []T {
 	}
 	return filteredPlayers
 }
-
-func (h *Holdem) UpdatePlayerChipsForBet() error {
-	playerChanges := []mq.PlayerChipChange{}
-	for playerID, bet := range h.State.PlayerBets {
-		playerChanges = append(playerChanges, mq.PlayerChipChange{
-			PlayerID: playerID,
-			Change:   -bet,
-		})
-	}
-
-	return h.game.UpdatePlayerChips(playerChanges)
-}
-
-func (h *Holdem) UpdatePlayerChipsShowdown(winners []HandResult) error {
-	playerChanges := []mq.PlayerChipChange{}
-	for _, winner := range winners {
-		playerChanges = append(playerChanges, mq.PlayerChipChange{
-			PlayerID: winner.PlayerID,
-			Change:   winner.Amount,
-		})
-	}
-	return h.game.UpdatePlayerChips(playerChanges)
-}
-
-func (h *Holdem) DistributeSidePots(winners []HandResult) {
-	// Simple side pot distribution using existing PlayerTotalContribution
-	// Example: Player A ($50), B ($200), C ($100) - all go all-in
-	// Level $50: All 3 eligible → $150 distributed among A,B,C winners
-	// Level $100: B,C eligible → $100 distributed among B,C winners
-	// Level $200: Only B eligible → $100 goes to B
-
-	contributionLevels := make(map[int]bool)
-	activePlayers := []string{}
-
-	for _, seat := range h.State.Seats {
-		if seat.Hand != nil && len(seat.Hand) > 0 {
-			playerID := seat.Player.Client.User.Player.ID
-			contribution := h.State.PlayerTotalContribution[playerID]
-			contributionLevels[contribution] = true
-			activePlayers = append(activePlayers, playerID)
-		}
-	}
-
-	levels := make([]int, 0, len(contributionLevels))
-	for level := range contributionLevels {
-		levels = append(levels, level)
-	}
-	sort.Ints(levels)
-
-	prevLevel := 0
-	totalDistributed := 0
-
-	for _, level := range levels {
-		if level == 0 {
-			continue
-		}
-
-		eligiblePlayers := []string{}
-		for _, playerID := range activePlayers {
-			if h.State.PlayerTotalContribution[playerID] >= level {
-				eligiblePlayers = append(eligiblePlayers, playerID)
-			}
-		}
-
-		if len(eligiblePlayers) == 0 {
-			continue
-		}
-
-		eligibleWinners := []HandResult{}
-		eligibleWinnerIndices := []int{} // Track original indices
-		for i, winner := range winners {
-			for _, eligiblePlayerID := range eligiblePlayers {
-				if winner.PlayerID == eligiblePlayerID {
-					eligibleWinners = append(eligibleWinners, winner)
-					eligibleWinnerIndices = append(eligibleWinnerIndices, i)
-					break
-				}
-			}
-		}
-
-		if len(eligibleWinners) == 0 {
-			continue
-		}
-
-		potAmount := (level - prevLevel) * len(eligiblePlayers)
-		winAmount := potAmount / len(eligibleWinners)
-		remaining := potAmount % len(eligibleWinners)
-
-		log.Printf("[SIDE POT] Level $%d: $%d among %d winners ($%d each + $%d remainder)",
-			level, potAmount, len(eligibleWinners), winAmount, remaining)
-
-		for i, winner := range eligibleWinners {
-			// Update player balance
-			for _, seat := range h.State.Seats {
-				if seat.Player.Client.User.Player.ID == winner.PlayerID {
-					seat.Player.Balance += winAmount
-					if i == 0 && remaining > 0 {
-						seat.Player.Balance += remaining
-					}
-					break
-				}
-			}
-
-			// Update the original winners slice using the tracked indices
-			originalIndex := eligibleWinnerIndices[i]
-			winners[originalIndex].Amount += winAmount
-			if i == 0 && remaining > 0 {
-				winners[originalIndex].Amount += remaining
-			}
-
-			totalDistributed += winAmount
-			if i == 0 {
-				totalDistributed += remaining
-			}
-		}
-
-		prevLevel = level
-	}
-
-	log.Printf("[DISTRIBUTION] Total distributed: $%d, Original pot: $%d", totalDistributed, h.State.Pot)
-}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"log"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+// The button and the blinds move the TDA way, with a moving big blind and a
+// dead button. The big blind moves on to the next player dealt in, the small
+// blind takes last hand's big blind seat and the button last hand's small
+// blind seat, even when those seats are empty by now. The small blind of an
+// empty seat is not posted and the button deals from the empty seat.
+//
+// Players who sit out while the big blind passes them, and players who join
+// after the first hand, owe the blinds. They are dealt in when the big blind
+// reaches them or, if they chose to post now, straight away unless they sit
+// between the button and the small blind.
+
+// MoveButton moves the button and the blinds for the next hand and takes the
+// players who have to wait for their big blind out of it. It runs after
+// HandlePlayers, which seats the players that can play.
+func (h *Holdem) MoveButton() {
+	h.game.Mu.Lock()
+	defer h.game.Mu.Unlock()
+
+	seats := h.sortedSeats()
+	if len(seats) < 2 {
+		return
+	}
+
+	if h.State.BigBlindPosition < 0 {
+		h.placeButton(seats, seats[0])
+		return
+	}
+
+	prevSmallBlind, prevBigBlind := h.State.SmallBlindPosition, h.State.BigBlindPosition
+	bigBlind := nextSeat(seats, prevBigBlind)
+	h.chargeMissedBlinds(prevBigBlind, bigBlind.Position)
+
+	button, smallBlind := prevSmallBlind, prevBigBlind
+	dealtIn := make([]*TableSeat, 0, len(seats))
+	for _, seat := range seats {
+		if h.canBeDealtIn(seat, bigBlind, button, smallBlind) {
+			dealtIn = append(dealtIn, seat)
+		}
+	}
+
+	// Too few players who do not owe blinds to play, everyone is dealt in
+	// for free and the button moves on as on a new table
+	if len(dealtIn) < 2 {
+		h.placeButton(seats, nextSeat(seats, h.State.ButtonPosition))
+		return
+	}
+
+	for _, seat := range seats {
+		if !h.canBeDealtIn(seat, bigBlind, button, smallBlind) {
+			log.Printf("[INFO] Player %s waits for the big blind", seat.Player.Client.User.Player.ID)
+			seat.Player.Status = GamePlayerStatusWaiting
+			delete(h.State.Seats, seat.Position)
+		}
+	}
+	h.LinkSeats()
+
+	// Heads-up the button posts the small blind, the big blind still moves
+	// on so nobody posts it twice in a row
+	if len(dealtIn) == 2 {
+		h.setButton(bigBlind.Next.Position, bigBlind.Next.Position, bigBlind)
+		return
+	}
+
+	if button == bigBlind.Position {
+		h.placeButton(dealtIn, nextSeat(dealtIn, h.State.ButtonPosition))
+		return
+	}
+
+	h.setButton(button, smallBlind, bigBlind)
+}
+
+// placeButton puts the button on the seat and the blinds on the players after
+// it, forgiving any blinds the players owe. The caller holds the game's Mu.
+func (h *Holdem) placeButton(seats []*TableSeat, button *TableSeat) {
+	for _, seat := range seats {
+		seat.Player.MissedBigBlind = false
+		seat.Player.MissedSmallBlind = false
+	}
+
+	if len(seats) == 2 {
+		h.setButton(button.Position, button.Position, button.Next)
+		return
+	}
+
+	h.setButton(button.Position, button.Next.Position, button.Next.Next)
+}
+
+func (h *Holdem) setButton(button, smallBlind int, bigBlind *TableSeat) {
+	h.State.ButtonPosition = button
+	h.State.SmallBlindPosition = smallBlind
+	h.State.BigBlindPosition = bigBlind.Position
+
+	h.State.SmallBlindSeat = h.State.Seats[smallBlind] // nil when the small blind is dead
+	h.State.BigBlindSeat = bigBlind
+
+	// The dealer seat is the last player dealt in at or before the button,
+	// so the action after the flop starts with the player after the button
+	h.State.DealerSeat = h.State.Seats[button]
+	if h.State.DealerSeat == nil {
+		h.State.DealerSeat = nextSeat(h.sortedSeats(), button).Prev
+	}
+}
+
+// chargeMissedBlinds records the blinds players sitting out miss as the big
+// blind moves from one position to the other. The caller holds the game's Mu.
+func (h *Holdem) chargeMissedBlinds(prevBigBlind, bigBlind int) {
+	for _, player := range h.game.Players {
+		if _, ok := h.State.Seats[player.Position]; ok || player.Status == GamePlayerStatusInactive {
+			continue
+		}
+
+		switch {
+		case clockwiseBetween(player.Position, prevBigBlind, bigBlind):
+			player.MissedBigBlind = true
+			player.MissedSmallBlind = true
+		case player.Position == prevBigBlind:
+			player.MissedSmallBlind = true
+		}
+	}
+}
+
+// canBeDealtIn reports whether the seated player plays the next hand. Players
+// who owe blinds play from their big blind, or when they chose to post now
+// from any seat but those between the button and the small blind.
+func (h *Holdem) canBeDealtIn(seat *TableSeat, bigBlind *TableSeat, button, smallBlind int) bool {
+	player := seat.Player
+	if (!player.MissedBigBlind && !player.MissedSmallBlind) || seat == bigBlind {
+		return true
+	}
+
+	if player.BlindEntry != models.BlindEntryPostNow {
+		return false
+	}
+
+	return seat.Position != button && seat.Position != smallBlind && !clockwiseBetween(seat.Position, button, smallBlind)
+}
+
+// postBlind puts a live blind in for the player, which counts towards the
+// player's bet in the round.
+func (h *Holdem) postBlind(player *GamePlayer, blindType HandRecordBlindType, amount int) {
+	playerID := player.Client.User.Player.ID
+	amount = min(amount, player.Balance)
+	player.Balance -= amount
+	h.State.Pot += amount
+	h.UpdatePlayerBet(playerID, amount)
+	h.State.CurrentBet = max(h.State.CurrentBet, h.State.PlayerBets[playerID])
+
+	h.RecordBlind(playerID, blindType, amount)
+	log.Printf("[BLINDS] Player %s posts %s %d", playerID, blindType, amount)
+}
+
+// postDeadBlind puts a missed small blind in the pot. It does not count
+// towards the player's bet.
+func (h *Holdem) postDeadBlind(player *GamePlayer, amount int) {
+	playerID := player.Client.User.Player.ID
+	amount = min(amount, player.Balance)
+	player.Balance -= amount
+	h.State.Pot += amount
+	h.State.PlayerTotalContribution[playerID] += amount
+
+	h.RecordBlind(playerID, HandRecordDeadSmallBlind, amount)
+	log.Printf("[BLINDS] Player %s posts dead small blind %d", playerID, amount)
+}
+
+// postAnte puts the player's ante in the pot. Like a dead blind it does not
+// count towards their bet.
+func (h *Holdem) postAnte(player *GamePlayer, amount int) {
+	playerID := player.Client.User.Player.ID
+	amount = min(amount, player.Balance)
+	player.Balance -= amount
+	h.State.Pot += amount
+	h.State.PlayerTotalContribution[playerID] += amount
+
+	h.RecordBlind(playerID, HandRecordAnte, amount)
+	log.Printf("[BLINDS] Player %s posts ante %d", playerID, amount)
+}
+
+// nextSeat returns the first of the sorted seats after the position,
+// clockwise.
+func nextSeat(seats []*TableSeat, position int) *TableSeat {
+	for _, seat := range seats {
+		if seat.Position > position {
+			return seat
+		}
+	}
+
+	return seats[0]
+}
+
+// clockwiseBetween reports whether the position is strictly between from and
+// to going clockwise round the table.
+func clockwiseBetween(position, from, to int) bool {
+	switch {
+	case from < to:
+		return position > from && position < to
+	case from > to:
+		return position > from || position < to
+	default:
+		return position != from
+	}
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"log"
+
+	"github.com/ahmetkoprulu/rtrp/game/internal/evaluator"
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+type PlayerEquity struct {
+	PlayerID string `json:"player_id"`
+	evaluator.Equity
+}
+
+type HoldemEquityMessage struct {
+	Round   HoldemRound    `json:"round"`
+	Cards   []models.Card  `json:"cards"`
+	Exact   bool           `json:"exact"`
+	Players []PlayerEquity `json:"players"`
+}
+
+// BroadcastEquity sends every live player's chance of winning once betting is
+// closed with two or more players left, before each street still to come.
+// Only the live hands and the board are known, folded and burnt cards are
+// treated as part of the deck.
+func (h *Holdem) BroadcastEquity() {
+	seats := h.ShowdownSeats()
+	board := h.VisibleCommunityCards()
+	if len(seats) < 2 || len(board) >= 5 || !h.BettingClosed() {
+		return
+	}
+
+	hands := make([][]evaluator.Card, len(seats))
+	for i, seat := range seats {
+		hands[i] = evaluator.FromModels(seat.Hand)
+	}
+
+	equities, exact := evaluator.CalculateEquity(hands, evaluator.FromModels(board), nil, h.variant.Evaluator)
+	message := HoldemEquityMessage{
+		Round:   h.State.CurrentRound,
+		Cards:   board,
+		Exact:   exact,
+		Players: make([]PlayerEquity, len(seats)),
+	}
+
+	for i, seat := range seats {
+		message.Players[i] = PlayerEquity{
+			PlayerID: seat.Player.Client.User.Player.ID,
+			Equity:   equities[i],
+		}
+		log.Printf("[EQUITY] Player %s: win %.1f%% tie %.1f%%", message.Players[i].PlayerID, equities[i].Win*100, equities[i].Tie*100)
+	}
+
+	h.SendMessage(HoldemMessageEquity, message)
+}
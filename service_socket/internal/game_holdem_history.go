@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+type HandRecordBlindType string
+
+const (
+	HandRecordSmallBlind HandRecordBlindType = "small_blind"
+	HandRecordBigBlind   HandRecordBlindType = "big_blind"
+	// Missed small blinds are posted dead and do not count towards the bet
+	HandRecordDeadSmallBlind HandRecordBlindType = "dead_small_blind"
+	HandRecordAnte           HandRecordBlindType = "ante"
+)
+
+// HandRecord is the full history of a single hand, captured from the deal to
+// the showdown and published as a hand_complete event.
+type HandRecord struct {
+	HandID             string               `json:"hand_id"`
+	RoomID             string               `json:"room_id"`
+	RoomName           string               `json:"room_name"`
+	GameType           GameType             `json:"game_type"`
+	BettingStructure   BettingStructureType `json:"betting_structure"`
+	MaxPlayers         int                  `json:"max_players"`
+	StartedAt          time.Time            `json:"started_at"`
+	EndedAt            time.Time            `json:"ended_at"`
+	DealerPosition     int                  `json:"dealer_position"`
+	SmallBlindPosition int                  `json:"small_blind_position"`
+	BigBlindPosition   int                  `json:"big_blind_position"`
+	SmallBlindAmount   int                  `json:"small_blind_amount"`
+	BigBlindAmount     int                  `json:"big_blind_amount"`
+	AnteAmount         int                  `json:"ante_amount"`
+	Seats              []HandRecordSeat     `json:"seats"`
+	Blinds             []HandRecordBlind    `json:"blinds"`
+	Actions            []HandRecordAction   `json:"actions"`
+	Board              []models.Card        `json:"board"`
+	SecondBoard        []models.Card        `json:"second_board,omitempty"`
+	LastRound          HoldemRound          `json:"last_round"`
+	TotalPot           int                  `json:"total_pot"`
+	Pots               []PotResult          `json:"pots"`
+	Winners            []HandResult         `json:"winners"`
+	Shuffle            models.ShuffleProof  `json:"shuffle"`
+}
+
+type HandRecordSeat struct {
+	Position       int           `json:"position"`
+	PlayerID       string        `json:"player_id"`
+	Username       string        `json:"username"`
+	StartingStack  int           `json:"starting_stack"`
+	FinalStack     int           `json:"final_stack"`
+	HoleCards      []models.Card `json:"hole_cards"`
+	WentToShowdown bool          `json:"went_to_showdown"`
+}
+
+type HandRecordBlind struct {
+	PlayerID string              `json:"player_id"`
+	Type     HandRecordBlindType `json:"type"`
+	Amount   int                 `json:"amount"`
+}
+
+type HandRecordAction struct {
+	HoldemActionMessage
+	Round     HoldemRound `json:"round"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// BeginHandRecord starts the record of the current hand. It must run after
+// the cards are dealt and before the blinds are posted so the starting stacks
+// are the stacks the players sat down with.
+func (h *Holdem) BeginHandRecord() {
+	record := &HandRecord{
+		HandID:             h.State.HandID,
+		RoomID:             h.game.Room.ID,
+		RoomName:           h.game.Room.Name,
+		GameType:           h.game.GameType,
+		BettingStructure:   h.betting.Type(),
+		MaxPlayers:         h.game.MaxPlayers,
+		StartedAt:          time.Now().UTC(),
+		DealerPosition:     h.State.ButtonPosition,
+		SmallBlindPosition: -1,
+		BigBlindPosition:   h.State.BigBlindPosition,
+		SmallBlindAmount:   h.State.SmallBlindAmount,
+		BigBlindAmount:     h.State.BigBlindAmount,
+		AnteAmount:         h.State.AnteAmount,
+		Seats:              []HandRecordSeat{},
+		Blinds:             []HandRecordBlind{},
+		Actions:            []HandRecordAction{},
+	}
+
+	if h.State.SmallBlindSeat != nil {
+		record.SmallBlindPosition = h.State.SmallBlindPosition
+	}
+
+	for _, seat := range h.sortedSeats() {
+		if len(seat.Hand) == 0 {
+			continue
+		}
+
+		record.Seats = append(record.Seats, HandRecordSeat{
+			Position:      seat.Position,
+			PlayerID:      seat.Player.Client.User.Player.ID,
+			Username:      seat.Player.Client.User.Player.Username,
+			StartingStack: seat.Player.Balance,
+			HoleCards:     append([]models.Card{}, seat.Hand...),
+		})
+	}
+
+	h.history = record
+}
+
+func (h *Holdem) RecordBlind(playerID string, blindType HandRecordBlindType, amount int) {
+	if h.history == nil {
+		return
+	}
+
+	h.history.Blinds = append(h.history.Blinds, HandRecordBlind{
+		PlayerID: playerID,
+		Type:     blindType,
+		Amount:   amount,
+	})
+}
+
+// RecordPots stores the pots of the hand and who won them.
+func (h *Holdem) RecordPots(pots []PotResult) {
+	if h.history == nil {
+		return
+	}
+
+	h.history.Pots = pots
+}
+
+// RecordSecondBoard stores the second runout of a hand that was run twice.
+func (h *Holdem) RecordSecondBoard(board []models.Card) {
+	if h.history == nil {
+		return
+	}
+
+	h.history.SecondBoard = append([]models.Card{}, board...)
+}
+
+func (h *Holdem) RecordAction(action HoldemActionMessage) {
+	if h.history == nil {
+		return
+	}
+
+	h.history.Actions = append(h.history.Actions, HandRecordAction{
+		HoldemActionMessage: action,
+		Round:               h.State.CurrentRound,
+		Timestamp:           time.Now().UTC(),
+	})
+}
+
+// FinishHand reveals the deck seed and publishes the record of the hand that
+// just ended. winners is the result of EvaluateHands.
+func (h *Holdem) FinishHand(winners []HandResult) {
+	h.RevealDeck()
+
+	record := h.history
+	h.history = nil
+	if record == nil {
+		return
+	}
+
+	record.EndedAt = time.Now().UTC()
+	record.Board = append([]models.Card{}, h.VisibleCommunityCards()...)
+	record.LastRound = h.State.CurrentRound
+	if record.Pots == nil {
+		for _, pot := range h.CalculatePots() {
+			record.Pots = append(record.Pots, PotResult{SidePot: pot, Winners: []PotShare{}})
+		}
+	}
+	record.Winners = winners
+	record.Shuffle = h.shuffleProof
+
+	for _, pot := range record.Pots {
+		record.TotalPot += pot.Amount
+	}
+
+	showdown := h.State.CurrentRound == Showdown && h.PlayersNotFoldedCount() > 1
+	for i := range record.Seats {
+		seat := &record.Seats[i]
+		if tableSeat, ok := h.State.Seats[seat.Position]; ok {
+			seat.FinalStack = tableSeat.Player.Balance
+			seat.WentToShowdown = showdown && len(tableSeat.Hand) > 0
+		}
+	}
+
+	if err := h.game.PublishHandComplete(record); err != nil {
+		log.Printf("[ERROR] Failed to publish hand history for hand %s: %v", record.HandID, err)
+	}
+}
+
+// VisibleCommunityCards returns the community cards dealt so far in the
+// current round.
+func (h *Holdem) VisibleCommunityCards() []models.Card {
+	switch h.State.CurrentRound {
+	case Flop:
+		return h.State.CommunityCards[:3]
+	case Turn:
+		return h.State.CommunityCards[:4]
+	case River, Showdown:
+		return h.State.CommunityCards[:5]
+	}
+
+	return []models.Card{}
+}
+
+func (h *Holdem) sortedSeats() []*TableSeat {
+	positions := make([]int, 0, len(h.State.Seats))
+	for pos := range h.State.Seats {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+
+	seats := make([]*TableSeat, 0, len(positions))
+	for _, pos := range positions {
+		seats = append(seats, h.State.Seats[pos])
+	}
+
+	return seats
+}
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"log"
+	"sort"
+
+	"github.com/ahmetkoprulu/rtrp/game/internal/evaluator"
+)
+
+// SidePot is a pot that can only be won by the listed players. The first pot
+// of a hand is the main pot.
+type SidePot struct {
+	Amount            int      `json:"amount"`
+	EligiblePlayerIDs []string `json:"eligible_player_ids"`
+}
+
+// PotShare is the part of a pot won by a player.
+type PotShare struct {
+	PlayerID string `json:"player_id"`
+	Amount   int    `json:"amount"`
+}
+
+// PotResult is a pot and the players it was awarded to.
+type PotResult struct {
+	SidePot
+	Winners []PotShare `json:"winners"`
+}
+
+// CalculatePots splits the chips put in this hand into the main pot and side
+// pots. A new pot starts at every all-in amount: players who are all-in are
+// only eligible for the pots up to their own contribution, while players who
+// still have chips are eligible for all of them. Folded players' chips stay in
+// the pots they went into but they can not win them.
+func (h *Holdem) CalculatePots() []SidePot {
+	seats := h.sortedSeats()
+	livePlayers := make(map[string]bool)
+	allInPlayers := make(map[string]bool)
+	levels := []int{}
+	seen := make(map[int]bool)
+	for _, seat := range seats {
+		if len(seat.Hand) == 0 {
+			continue
+		}
+
+		playerID := seat.Player.Client.User.Player.ID
+		livePlayers[playerID] = true
+		if seat.Player.Balance > 0 {
+			continue
+		}
+
+		allInPlayers[playerID] = true
+		level := h.State.PlayerTotalContribution[playerID]
+		if level > 0 && !seen[level] {
+			seen[level] = true
+			levels = append(levels, level)
+		}
+	}
+	sort.Ints(levels)
+
+	pots := []SidePot{}
+	prevLevel := 0
+	for _, level := range levels {
+		pot := SidePot{EligiblePlayerIDs: []string{}}
+		for _, seat := range seats {
+			playerID := seat.Player.Client.User.Player.ID
+			contribution := h.State.PlayerTotalContribution[playerID]
+			pot.Amount += max(min(contribution, level)-prevLevel, 0)
+			if livePlayers[playerID] && (!allInPlayers[playerID] || contribution >= level) {
+				pot.EligiblePlayerIDs = append(pot.EligiblePlayerIDs, playerID)
+			}
+		}
+
+		if pot.Amount > 0 {
+			pots = append(pots, pot)
+		}
+		prevLevel = level
+	}
+
+	// Everything above the biggest all-in goes to the players who still
+	// have chips. With nobody left to win it, it is folded players' money
+	// and joins the last pot.
+	top := SidePot{EligiblePlayerIDs: []string{}}
+	for _, seat := range seats {
+		playerID := seat.Player.Client.User.Player.ID
+		top.Amount += max(h.State.PlayerTotalContribution[playerID]-prevLevel, 0)
+		if livePlayers[playerID] && !allInPlayers[playerID] {
+			top.EligiblePlayerIDs = append(top.EligiblePlayerIDs, playerID)
+		}
+	}
+
+	switch {
+	case top.Amount == 0:
+	case len(top.EligiblePlayerIDs) > 0 || len(pots) == 0:
+		pots = append(pots, top)
+	default:
+		pots[len(pots)-1].Amount += top.Amount
+	}
+
+	return pots
+}
+
+// UpdateSidePots recalculates the pots shown to the table. It runs after every
+// chip that goes into the pot.
+func (h *Holdem) UpdateSidePots() {
+	h.State.SidePots = h.CalculatePots()
+}
+
+// DistributePots awards the pots of the hand, see AwardPots.
+func (h *Holdem) DistributePots(results []HandResult) []PotResult {
+	return h.AwardPots(h.CalculatePots(), results)
+}
+
+// AwardPots awards every pot to the best hands among its eligible players and
+// splits ties evenly, odd chips going to the winners first to the left of the
+// dealer. results must hold every player still in the hand. The won chips are
+// added to the players' balances and to their results.
+func (h *Holdem) AwardPots(pots []SidePot, results []HandResult) []PotResult {
+	resultIndex := make(map[string]int, len(results))
+	for i, result := range results {
+		resultIndex[result.PlayerID] = i
+	}
+
+	seats := make(map[string]*TableSeat)
+	order := make(map[string]int)
+	seat := h.State.DealerSeat.Next
+	for i := 0; i < len(h.State.Seats); i++ {
+		playerID := seat.Player.Client.User.Player.ID
+		seats[playerID] = seat
+		order[playerID] = i
+		seat = seat.Next
+	}
+
+	potResults := []PotResult{}
+	for i, pot := range pots {
+		best := evaluator.Strength(0)
+		winners := []string{}
+		for _, playerID := range pot.EligiblePlayerIDs {
+			index, ok := resultIndex[playerID]
+			if !ok {
+				continue
+			}
+
+			strength := results[index].Strength
+			if len(winners) == 0 || strength > best {
+				best = strength
+				winners = []string{playerID}
+			} else if strength == best {
+				winners = append(winners, playerID)
+			}
+		}
+
+		if len(winners) == 0 {
+			log.Printf("[ERROR] No eligible winner for pot %d of $%d", i, pot.Amount)
+			continue
+		}
+
+		sort.Slice(winners, func(a, b int) bool {
+			return order[winners[a]] < order[winners[b]]
+		})
+
+		potResult := PotResult{SidePot: pot, Winners: []PotShare{}}
+		share := pot.Amount / len(winners)
+		remainder := pot.Amount % len(winners)
+		for k, playerID := range winners {
+			amount := share
+			if k < remainder {
+				amount++
+			}
+
+			results[resultIndex[playerID]].Amount += amount
+			if seat, ok := seats[playerID]; ok {
+				seat.Player.Balance += amount
+			}
+			potResult.Winners = append(potResult.Winners, PotShare{PlayerID: playerID, Amount: amount})
+		}
+
+		log.Printf("[SIDE POT] Pot %d: $%d among %d winners ($%d each + $%d remainder)", i, pot.Amount, len(winners), share, remainder)
+		potResults = append(potResults, potResult)
+	}
+
+	return potResults
+}
@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+// RunItTwiceTimeout is how long the players in an all-in pot have to agree to
+// run the board twice. Not answering counts as declining.
+const RunItTwiceTimeout = 10 * time.Second
+
+type runItTwiceOffer struct {
+	mu      sync.Mutex
+	handID  string // Hand the last offer was made in, it is only made once
+	pending map[string]bool
+	votes   chan HoldemActionMessage
+}
+
+type HoldemRunItTwiceOfferMessage struct {
+	PlayerIDs []string `json:"player_ids"`
+	Timeout   int      `json:"timeout"`
+}
+
+type HoldemRunItTwiceDecisionMessage struct {
+	Agreed bool `json:"agreed"`
+}
+
+type HoldemRunItTwiceBoardMessage struct {
+	Run   int           `json:"run"`
+	Cards []models.Card `json:"cards"`
+}
+
+// HoldemRunout is the result of one of the boards of a pot that was run twice.
+// Each runout plays for half of every pot.
+type HoldemRunout struct {
+	Run     int           `json:"run"`
+	Board   []models.Card `json:"board"`
+	Winners []HandResult  `json:"winners"`
+	Pots    []PotResult   `json:"pots"`
+}
+
+type HoldemRunItTwiceShowdownMessage struct {
+	Runouts   []HoldemRunout `json:"runouts"`
+	Winners   []HandResult   `json:"winners"`
+	Pot       int            `json:"pot"`
+	GameState interface{}    `json:"game_state"`
+}
+
+// RunItTwice offers to run the rest of the board twice when betting is over
+// before the river, and plays the hand to the end if every player all-in in
+// it agrees. It returns false when the hand should continue normally.
+func (h *Holdem) RunItTwice(board []models.Card) bool {
+	seats := h.ShowdownSeats()
+	if h.State.CurrentRound == River || len(seats) < 2 || !h.BettingClosed() {
+		return false
+	}
+
+	if h.runItTwice.handID == h.State.HandID {
+		return false
+	}
+	h.runItTwice.handID = h.State.HandID
+
+	visible := len(h.VisibleCommunityCards())
+	if len(h.deck.Cards) < secondBoardSize(visible) {
+		return false
+	}
+
+	playerIDs := make([]string, 0, len(seats))
+	for _, seat := range seats {
+		if seat.Player.Balance == 0 {
+			playerIDs = append(playerIDs, seat.Player.Client.User.Player.ID)
+		}
+	}
+
+	if len(playerIDs) == 0 || !h.AskRunItTwice(playerIDs) {
+		return false
+	}
+
+	second, err := h.DealSecondBoard(board, visible)
+	if err != nil {
+		log.Printf("[ERROR] Failed to deal the second board: %v", err)
+		return false
+	}
+
+	h.State.CurrentRound = Showdown
+	h.State.CommunityCards = board[:5]
+	h.SendMessage(HoldemMessageRoundProgress, HoldemRoundProgressResponse{
+		Round: Showdown,
+		Cards: h.State.CommunityCards,
+		Pot:   h.State.Pot,
+	})
+
+	boards := [][]models.Card{board[:5], second}
+	for run, cards := range boards {
+		h.SendMessage(HoldemMessageRunItTwiceBoard, HoldemRunItTwiceBoardMessage{
+			Run:   run + 1,
+			Cards: cards,
+		})
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	h.ShowdownTwice(seats, boards)
+	h.LogGameState("RAN IT TWICE")
+	return true
+}
+
+// AskRunItTwice sends the offer to the given players and waits until all of
+// them accept, one declines or the offer times out.
+func (h *Holdem) AskRunItTwice(playerIDs []string) bool {
+	offer := &h.runItTwice
+	offer.mu.Lock()
+	offer.pending = make(map[string]bool, len(playerIDs))
+	for _, playerID := range playerIDs {
+		offer.pending[playerID] = true
+	}
+	offer.votes = make(chan HoldemActionMessage, len(playerIDs))
+	votes := offer.votes
+	offer.mu.Unlock()
+
+	h.SendMessage(HoldemMessageRunItTwiceOffer, HoldemRunItTwiceOfferMessage{
+		PlayerIDs: playerIDs,
+		Timeout:   int(RunItTwiceTimeout.Seconds()),
+	})
+
+	timer := time.NewTimer(RunItTwiceTimeout)
+	defer timer.Stop()
+
+	accepted := 0
+wait:
+	for accepted < len(playerIDs) {
+		select {
+		case vote := <-votes:
+			if vote.Action == HoldemActionRunItTwiceDecline {
+				log.Printf("[INFO] Player %s declined to run it twice", vote.PlayerID)
+				break wait
+			}
+			accepted++
+		case <-timer.C:
+			log.Printf("[INFO] Run it twice offer timed out")
+			break wait
+		}
+	}
+
+	offer.mu.Lock()
+	offer.pending = nil
+	offer.mu.Unlock()
+
+	agreed := accepted == len(playerIDs)
+	h.SendMessage(HoldemMessageRunItTwiceDecision, HoldemRunItTwiceDecisionMessage{Agreed: agreed})
+	return agreed
+}
+
+// VoteRunItTwice records a player's answer to the current offer. The offer
+// is only made to the players all-in in the hand and each of them can answer
+// once. The player ID is the authenticated player's, set by the message
+// handler.
+func (h *Holdem) VoteRunItTwice(action HoldemActionMessage) error {
+	offer := &h.runItTwice
+	offer.mu.Lock()
+	defer offer.mu.Unlock()
+
+	if !offer.pending[action.PlayerID] {
+		return errors.New("run it twice is not offered to this player, only players all-in in the hand vote")
+	}
+
+	delete(offer.pending, action.PlayerID)
+	offer.votes <- action
+	return nil
+}
+
+// DealSecondBoard deals the second runout from the same deck. The cards
+// already on the table are shared, the rest are dealt with the usual burns.
+func (h *Holdem) DealSecondBoard(board []models.Card, visible int) ([]models.Card, error) {
+	second := append([]models.Card{}, board[:visible]...)
+	for len(second) < 5 {
+		if _, err := h.deck.Draw(); err != nil {
+			return nil, err
+		}
+
+		count := 1
+		if len(second) == 0 {
+			count = 3
+		}
+
+		for i := 0; i < count; i++ {
+			card, err := h.deck.Draw()
+			if err != nil {
+				return nil, err
+			}
+			second = append(second, card)
+		}
+	}
+
+	log.Printf("[INFO] Second board: %v", second)
+	return second, nil
+}
+
+// secondBoardSize is the number of cards, burns included, needed to finish a
+// second board from the given number of shared cards.
+func secondBoardSize(visible int) int {
+	switch visible {
+	case 0:
+		return 8
+	case 3:
+		return 4
+	case 4:
+		return 2
+	}
+
+	return 0
+}
+
+// ShowdownTwice evaluates the hands on both boards and awards half of every
+// pot to the winners of each, the odd chip going to the first board.
+func (h *Holdem) ShowdownTwice(seats []*TableSeat, boards [][]models.Card) {
+	pots := h.CalculatePots()
+	runouts := []HoldemRunout{}
+	winners := []HandResult{}
+	winnerIndex := make(map[string]int)
+	merged := make([]PotResult, len(pots))
+	for i, pot := range pots {
+		merged[i] = PotResult{SidePot: pot, Winners: []PotShare{}}
+	}
+
+	for run, board := range boards {
+		results := h.EvaluateBoard(seats, board)
+		potResults := []PotResult{}
+		for i, pot := range pots {
+			runPot := pot
+			runPot.Amount = pot.Amount / len(boards)
+			if run == 0 {
+				runPot.Amount += pot.Amount % len(boards)
+			}
+
+			for _, potResult := range h.AwardPots([]SidePot{runPot}, results) {
+				merged[i].Winners = mergeShares(merged[i].Winners, potResult.Winners)
+				potResults = append(potResults, potResult)
+			}
+		}
+
+		runWinners := []HandResult{}
+		for _, result := range results {
+			if result.Amount == 0 {
+				continue
+			}
+
+			runWinners = append(runWinners, result)
+			if index, ok := winnerIndex[result.PlayerID]; ok {
+				winners[index].Amount += result.Amount
+			} else {
+				winnerIndex[result.PlayerID] = len(winners)
+				winners = append(winners, result)
+			}
+			log.Printf("[INFO] Player %s wins $%d on board %d with hand rank %d", result.PlayerID, result.Amount, run+1, result.Rank)
+		}
+
+		runouts = append(runouts, HoldemRunout{
+			Run:     run + 1,
+			Board:   board,
+			Winners: runWinners,
+			Pots:    potResults,
+		})
+	}
+
+	h.SendMessage(HoldemMessageRunItTwiceShowdown, HoldemRunItTwiceShowdownMessage{
+		Runouts:   runouts,
+		Winners:   winners,
+		Pot:       h.State.Pot,
+		GameState: h.GetGameState(),
+	})
+
+	h.State.Pot = 0
+	h.RecordPots(merged)
+	h.RecordSecondBoard(boards[1])
+	h.FinishHand(winners)
+}
+
+func mergeShares(shares []PotShare, more []PotShare) []PotShare {
+	for _, share := range more {
+		found := false
+		for i := range shares {
+			if shares[i].PlayerID == share.PlayerID {
+				shares[i].Amount += share.Amount
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			shares = append(shares, share)
+		}
+	}
+
+	return shares
+}
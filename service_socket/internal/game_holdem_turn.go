@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrorNotYourTurn   = errors.New("it is not your turn")
+	ErrorActionPending = errors.New("an action is already being processed")
+	ErrorTurnOver      = errors.New("the turn is over")
+)
+
+type HoldemTimeBankMessage struct {
+	PlayerID string    `json:"player_id"`
+	Deadline time.Time `json:"deadline"`
+	TimeBank int       `json:"time_bank"`
+}
+
+type pendingAction struct {
+	action HoldemActionMessage
+	result chan error
+}
+
+// actionTurn hands the actions players send to the betting round waiting for
+// them. Only the player whose turn it is can act.
+type actionTurn struct {
+	mu       sync.Mutex
+	playerID string
+	actions  chan pendingAction
+}
+
+func (t *actionTurn) open(playerID string) chan pendingAction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.playerID = playerID
+	t.actions = make(chan pendingAction, 1)
+	return t.actions
+}
+
+// close ends the turn and fails an action that arrived too late to be used.
+func (t *actionTurn) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.playerID = ""
+	select {
+	case pending := <-t.actions:
+		pending.result <- ErrorTurnOver
+	default:
+	}
+}
+
+// submit passes the action to the betting round and waits until it has been
+// carried out or rejected.
+func (t *actionTurn) submit(action HoldemActionMessage) error {
+	result := make(chan error, 1)
+
+	t.mu.Lock()
+	if t.playerID == "" || t.playerID != action.PlayerID {
+		t.mu.Unlock()
+		return ErrorNotYourTurn
+	}
+
+	select {
+	case t.actions <- pendingAction{action: action, result: result}:
+	default:
+		t.mu.Unlock()
+		return ErrorActionPending
+	}
+	t.mu.Unlock()
+
+	return <-result
+}
+
+// timeBanks is the extra thinking time each player has left. Players who
+// have not acted at the table yet have a full bank.
+type timeBanks struct {
+	mu    sync.Mutex
+	banks map[string]time.Duration
+	hands int
+}
+
+// TimeBank returns how much time bank the player has left.
+func (h *Holdem) TimeBank(playerID string) time.Duration {
+	h.timeBanks.mu.Lock()
+	defer h.timeBanks.mu.Unlock()
+
+	bank, ok := h.timeBanks.banks[playerID]
+	if !ok {
+		return h.game.ActionTimer.TimeBank
+	}
+
+	return bank
+}
+
+func (h *Holdem) spendTimeBank(playerID string, used time.Duration) {
+	bank := max(h.TimeBank(playerID)-used, 0)
+
+	h.timeBanks.mu.Lock()
+	h.timeBanks.banks[playerID] = bank
+	h.timeBanks.mu.Unlock()
+}
+
+// RefillTimeBanks counts a new hand and tops up the banks of the seated
+// players every TimeBankRefillHands hands.
+func (h *Holdem) RefillTimeBanks() {
+	settings := h.game.ActionTimer
+	h.timeBanks.mu.Lock()
+	defer h.timeBanks.mu.Unlock()
+
+	h.timeBanks.hands++
+	if settings.TimeBankRefillHands <= 0 || h.timeBanks.hands%settings.TimeBankRefillHands != 0 {
+		return
+	}
+
+	for _, seat := range h.State.Seats {
+		playerID := seat.Player.Client.User.Player.ID
+		if bank, ok := h.timeBanks.banks[playerID]; ok {
+			h.timeBanks.banks[playerID] = min(bank+settings.TimeBankRefill, settings.TimeBank)
+		}
+	}
+}
+
+// AwaitAction tells the table it is the player's turn and waits for their
+// action. Invalid actions are sent back and the player can try again until
+// the deadline. Once the action timeout runs out the player's time bank
+// starts, and when that is gone too the player is acted for. Tournament
+// players who left are acted for straight away.
+func (h *Holdem) AwaitAction(player *GamePlayer, toCall int) {
+	if player.Away {
+		h.CheckOrFold(player, toCall)
+		return
+	}
+
+	playerID := player.Client.User.Player.ID
+	settings := h.game.ActionTimer
+	if settings.ActionTimeout <= 0 {
+		settings.ActionTimeout = DefaultActionTimerSettings.ActionTimeout
+	}
+
+	actions := h.turn.open(playerID)
+	defer h.turn.close()
+
+	bank := h.TimeBank(playerID)
+	deadline := time.Now().Add(settings.ActionTimeout)
+	minRaiseTo, maxRaiseTo := h.BetLimits(player)
+	h.SendMessage(HoldemMessagePlayerTurn, HoldemPlayerTurnMessage{
+		PlayerID:   playerID,
+		Timeout:    int(settings.ActionTimeout.Seconds()),
+		Deadline:   deadline.UTC(),
+		TimeBank:   int(bank.Seconds()),
+		CanRaise:   h.betting.CanRaise(&h.State) && h.RaiseReopened(player) && maxRaiseTo > h.State.CurrentBet,
+		MinRaiseTo: minRaiseTo,
+		MaxRaiseTo: maxRaiseTo,
+	})
+
+	log.Printf("[ACTION] Player %s to act | Current bet: $%d | Player bet: $%d | To call: $%d | Balance: $%d", playerID, h.State.CurrentBet, h.State.PlayerBets[playerID], toCall, player.Balance)
+
+	timer := time.NewTimer(settings.ActionTimeout)
+	defer timer.Stop()
+
+	var bankStarted time.Time
+	for {
+		select {
+		case pending := <-actions:
+			err := h.ApplyAction(pending.action)
+			pending.result <- err
+			if err != nil {
+				log.Printf("[ERROR] Failed to process action: %v", err)
+				continue
+			}
+
+			player.Timeouts = 0
+			if !bankStarted.IsZero() {
+				h.spendTimeBank(playerID, time.Since(bankStarted))
+			}
+			return
+		case <-timer.C:
+			if bankStarted.IsZero() && bank > 0 && player.Client.Connected() {
+				bankStarted = time.Now()
+				timer.Reset(bank)
+				log.Printf("[INFO] Player %s is using their time bank of %s", playerID, bank)
+				h.SendMessage(HoldemMessageTimeBank, HoldemTimeBankMessage{
+					PlayerID: playerID,
+					Deadline: bankStarted.Add(bank).UTC(),
+					TimeBank: int(bank.Seconds()),
+				})
+				continue
+			}
+
+			if !bankStarted.IsZero() {
+				h.spendTimeBank(playerID, bank)
+			}
+
+			log.Printf("[INFO] Player %s timed out", playerID)
+			h.handleTimeoutAction(player, toCall)
+			return
+		}
+	}
+}
+
+// CheckOrFold checks for the player when there is nothing to call and folds
+// otherwise.
+func (h *Holdem) CheckOrFold(player *GamePlayer, toCall int) {
+	action := HoldemActionMessage{
+		PlayerID: player.Client.User.Player.ID,
+		Action:   HoldemActionFold,
+	}
+	if toCall <= 0 {
+		action.Action = HoldemActionCheck
+	}
+
+	if err := h.ApplyAction(action); err != nil {
+		log.Printf("[ERROR] Failed to act for player %s: %v", player.Client.User.Player.ID, err)
+	}
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"github.com/ahmetkoprulu/rtrp/game/internal/evaluator"
+)
+
+// MaxOmahaPlayers is the most players an Omaha table can deal to, four hole
+// cards each plus the board and burn cards.
+const MaxOmahaPlayers = 10
+
+var PotLimitOmaha = HoldemVariant{
+	HoleCards: 4,
+	Betting:   BettingPotLimit,
+	Evaluator: evaluator.EvaluateOmaha,
+}
+
+// Omaha is pot-limit Omaha played on the Holdem engine. Players get four hole
+// cards and must use exactly two of them with exactly three from the board.
+type Omaha struct {
+	*Holdem
+}
+
+func NewOmaha(game *Game) *Omaha {
+	holdem := NewHoldem(game)
+	holdem.variant = PotLimitOmaha
+	holdem.betting = PotLimit{}
+
+	return &Omaha{Holdem: holdem}
+}
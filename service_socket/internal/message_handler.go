@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/ahmetkoprulu/rtrp/game/models"
@@ -12,6 +13,9 @@ import (
 type MessageHandler struct {
 	server      *Server
 	roomManager *RoomManager
+
+	quickSeats   map[string]*quickSeatTicket // Players waiting for a seat by ticket ID
+	quickSeatsMu sync.Mutex
 }
 
 // NewMessageHandler creates a new message handler
@@ -19,6 +23,7 @@ func NewMessageHandler(server *Server, roomManager *RoomManager) *MessageHandler
 	return &MessageHandler{
 		server:      server,
 		roomManager: roomManager,
+		quickSeats:  make(map[string]*quickSeatTicket),
 	}
 }
 
@@ -59,12 +64,102 @@ func (h *MessageHandler) HandleMessage(client *Client, message []byte) error {
 			return err
 		}
 		return h.handleLeaveGame(client, *message)
+	case models.MessageTypeSitOut:
+		message, err := ParseData[models.MessageSitOut](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleSitOut(client, *message)
+	case models.MessageTypeSitIn:
+		message, err := ParseData[models.MessageSitIn](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleSitIn(client, *message)
+	case models.MessageTypeTopUp:
+		message, err := ParseData[models.MessageTopUp](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleTopUp(client, *message)
+	case models.MessageTypeSpectateJoin:
+		message, err := ParseData[models.MessageSpectateJoin](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleSpectateJoin(client, *message)
+	case models.MessageTypeSpectateLeave:
+		message, err := ParseData[models.MessageSpectateLeave](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleSpectateLeave(client, *message)
 	case models.MessageTypeGameAction:
 		message, err := ParseData[models.MessageGameAction](msg.Data)
 		if err != nil {
 			return err
 		}
 		return h.handleGameAction(client, *message)
+	case models.MessageTypeGameClientSeed:
+		message, err := ParseData[models.MessageGameClientSeed](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleGameClientSeed(client, *message)
+	case models.MessageTypeQuickSeat:
+		message, err := ParseData[models.MessageQuickSeat](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleQuickSeat(client, *message)
+	case models.MessageTypeQuickSeatCancel:
+		message, err := ParseData[models.MessageQuickSeatCancel](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleQuickSeatCancel(client, *message)
+	case models.MessageTypePrivateCreate:
+		message, err := ParseData[models.MessagePrivateCreate](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handlePrivateCreate(client, *message)
+	case models.MessageTypePrivateKick:
+		message, err := ParseData[models.MessagePrivateHost](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handlePrivateKick(client, *message)
+	case models.MessageTypePrivatePause:
+		message, err := ParseData[models.MessagePrivateHost](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handlePrivatePause(client, *message)
+	case models.MessageTypePrivateResume:
+		message, err := ParseData[models.MessagePrivateHost](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handlePrivateResume(client, *message)
+	case models.MessageTypePrivateBlinds:
+		message, err := ParseData[models.MessagePrivateHost](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handlePrivateBlinds(client, *message)
+	case models.MessageTypeTournamentRegister:
+		message, err := ParseData[models.MessageTournament](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleTournamentRegister(client, *message)
+	case models.MessageTypeTournamentUnregister:
+		message, err := ParseData[models.MessageTournament](msg.Data)
+		if err != nil {
+			return err
+		}
+		return h.handleTournamentUnregister(client, *message)
 	default:
 		log.Printf("Unknown message type: %s", msg.Type)
 		return nil
@@ -73,13 +168,14 @@ func (h *MessageHandler) HandleMessage(client *Client, message []byte) error {
 
 func (h *MessageHandler) handleRoomInfo(client *Client, message models.MessageRoomInfo) error {
 	room := h.server.GetRoom(message.RoomID)
-	if room == nil {
-		return h.sendError(client, "Room not found")
+	if room == nil || !room.IsAdmitted(client.User.Player.ID) {
+		return h.sendError(client, message.RoomID, "Room not found")
 	}
 
 	response := models.Response{
-		Type: models.MessageTypeRoomInfo,
-		Data: room.GetRoomState(),
+		Type:   models.MessageTypeRoomInfo,
+		RoomID: room.ID,
+		Data:   room.GetRoomStateForPlayer(client.User.Player.ID),
 	}
 
 	msgBytes, err := json.Marshal(response)
@@ -87,23 +183,30 @@ func (h *MessageHandler) handleRoomInfo(client *Client, message models.MessageRo
 		return err
 	}
 
-	client.send <- msgBytes
+	client.Send(msgBytes)
 	return nil
 }
 
 func (h *MessageHandler) handleJoinRoom(client *Client, data models.MessageJoinRoom) error {
 	room := h.server.GetRoom(data.RoomID)
+	if data.JoinCode != "" {
+		room, _ = h.roomManager.GetRoomByJoinCode(data.JoinCode)
+	}
 	if room == nil {
-		return h.sendError(client, "Room not found")
+		return h.sendError(client, data.RoomID, "Room not found")
+	}
+
+	if err := room.Admit(client.User.Player.ID, data.JoinCode, data.Password); err != nil {
+		return h.sendError(client, data.RoomID, fmt.Sprintf("Failed to join room: %v", err))
 	}
 
 	if err := h.server.JoinRoom(room.ID, client); err != nil {
-		return h.sendError(client, fmt.Sprintf("Failed to join room: %v", err))
+		return h.sendError(client, data.RoomID, fmt.Sprintf("Failed to join room: %v", err))
 	}
 
 	response := models.Response{
 		Type:      models.MessageTypeJoinRoomOk,
-		Data:      room.GetRoomState(),
+		Data:      room.GetRoomStateForPlayer(client.User.Player.ID),
 		Timestamp: time.Now().UTC(),
 	}
 
@@ -131,15 +234,16 @@ func (h *MessageHandler) handleJoinRoom(client *Client, data models.MessageJoinR
 func (h *MessageHandler) handleLeaveRoom(client *Client, msg models.MessageLeaveRoom) error {
 	room := h.server.GetRoom(msg.RoomID)
 	if room == nil {
-		return h.sendError(client, "Room not found")
+		return h.sendError(client, msg.RoomID, "Room not found")
 	}
 
 	if err := room.RemovePlayer(client.User.Player.ID); err != nil {
-		return h.sendError(client, fmt.Sprintf("Failed to leave room: %v", err))
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to leave room: %v", err))
 	}
 
 	response := models.Response{
 		Type:      models.MessageTypeLeaveRoomOk,
+		RoomID:    room.ID,
 		Timestamp: time.Now().UTC(),
 		Data:      room.ID,
 	}
@@ -165,17 +269,32 @@ func (h *MessageHandler) handleLeaveRoom(client *Client, msg models.MessageLeave
 func (h *MessageHandler) handleJoinGame(client *Client, msg models.MessageJoinGame) error {
 	room := h.server.GetRoom(msg.RoomID)
 	if room == nil {
-		return h.sendError(client, "Room not found")
+		return h.sendError(client, msg.RoomID, "Room not found")
+	}
+
+	if err := h.seatPlayer(client, room, msg.Position, msg.BlindEntry, msg.BuyIn); err != nil {
+		return h.sendError(client, msg.RoomID, err.Error())
+	}
+
+	return nil
+}
+
+// seatPlayer takes the client into the room, seats them at the position and
+// tells the room.
+func (h *MessageHandler) seatPlayer(client *Client, room *Room, position int, blindEntry models.BlindEntry, buyIn int) error {
+	playerID := client.User.Player.ID
+	if limit := h.server.MaxTablesPerPlayer; limit > 0 && !room.Game.IsSeated(playerID) && h.roomManager.CountTablesByPlayerID(playerID) >= limit {
+		return fmt.Errorf("Failed to join game: at most %d tables at a time", limit)
 	}
 
 	if err := h.server.JoinRoom(room.ID, client); err != nil {
-		return h.sendError(client, fmt.Sprintf("Failed to join room: %v", err))
+		return fmt.Errorf("Failed to join room: %w", err)
 	}
 
-	if err := room.Game.AddPlayer(msg.Position, client); err != nil {
+	if err := room.Game.AddPlayer(position, client, blindEntry, buyIn); err != nil {
 		log.Printf("[ERROR] Failed to add player to game - RoomID: %s, PlayerID: %s, Error: %v", room.ID, client.User.Player.ID, err)
 		room.RemovePlayer(client.User.Player.ID)
-		return h.sendError(client, fmt.Sprintf("Failed to join game: %v", err))
+		return fmt.Errorf("Failed to join game: %w", err)
 	}
 
 	log.Printf("[INFO] Player joined successfully - RoomID: %s, PlayerID: %s, GameID: %s, PlayerCount: %d", room.ID, client.User.Player.ID, room.Game.ID, len(room.Game.Players))
@@ -185,8 +304,8 @@ func (h *MessageHandler) handleJoinGame(client *Client, msg models.MessageJoinGa
 		Data: models.MessageJoinGameResponse{
 			RoomID:   room.ID,
 			Player:   client.User.Player,
-			Position: msg.Position,
-			State:    room.GetRoomState(),
+			Position: position,
+			State:    room.GetRoomStateForPlayer(client.User.Player.ID),
 		},
 		Timestamp: time.Now().UTC(),
 	}
@@ -200,14 +319,14 @@ func (h *MessageHandler) handleJoinGame(client *Client, msg models.MessageJoinGa
 		Data: models.MessageJoinGameResponse{
 			RoomID:   room.ID,
 			Player:   client.User.Player,
-			Position: msg.Position,
+			Position: position,
 			State:    room.GetRoomState(),
 		},
 		Timestamp: time.Now().UTC(),
 	}
 
 	if err := room.BroadcastToOthers(client.User.Player.ID, response); err != nil {
-		return err
+		log.Printf("[ERROR] Failed to broadcast join game - RoomID: %s, PlayerID: %s, Error: %v", room.ID, client.User.Player.ID, err)
 	}
 
 	return nil
@@ -217,12 +336,12 @@ func (h *MessageHandler) handleLeaveGame(client *Client, msg models.MessageLeave
 	room := h.server.GetRoom(msg.RoomID)
 	if room == nil {
 		log.Printf("[ERROR] Room not found for leave game - RoomID: %s, PlayerID: %s", msg.RoomID, client.User.Player.ID)
-		return h.sendError(client, "Room not found")
+		return h.sendError(client, msg.RoomID, "Room not found")
 	}
 
 	if err := room.Game.RemovePlayer(client.User.Player.ID); err != nil {
 		log.Printf("[ERROR] Failed to remove player from game - RoomID: %s, PlayerID: %s, Error: %v", room.ID, client.User.Player.ID, err)
-		return h.sendError(client, fmt.Sprintf("Failed to leave game: %v", err))
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to leave game: %v", err))
 	}
 
 	log.Printf("[INFO] Player left game - RoomID: %s, PlayerID: %s, RemainingPlayers: %d", room.ID, client.User.Player.ID, len(room.Game.Players))
@@ -244,17 +363,166 @@ func (h *MessageHandler) handleLeaveGame(client *Client, msg models.MessageLeave
 	return nil
 }
 
+func (h *MessageHandler) handleSitOut(client *Client, msg models.MessageSitOut) error {
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil {
+		return h.sendError(client, msg.RoomID, "Room not found")
+	}
+
+	if err := room.Game.SitOut(client.User.Player.ID); err != nil {
+		log.Printf("[ERROR] Failed to sit out - RoomID: %s, PlayerID: %s, Error: %v", room.ID, client.User.Player.ID, err)
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to sit out: %v", err))
+	}
+
+	response := models.Response{
+		Type: models.MessageTypeSitOut,
+		Data: models.MessageSitOutResponse{
+			RoomID:   room.ID,
+			PlayerID: client.User.Player.ID,
+			State:    room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	return room.BroadcastToRoom(response)
+}
+
+func (h *MessageHandler) handleSitIn(client *Client, msg models.MessageSitIn) error {
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil {
+		return h.sendError(client, msg.RoomID, "Room not found")
+	}
+
+	if err := room.Game.SitIn(client.User.Player.ID, msg.BlindEntry); err != nil {
+		log.Printf("[ERROR] Failed to sit in - RoomID: %s, PlayerID: %s, Error: %v", room.ID, client.User.Player.ID, err)
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to sit in: %v", err))
+	}
+
+	response := models.Response{
+		Type: models.MessageTypeSitIn,
+		Data: models.MessageSitInResponse{
+			RoomID:   room.ID,
+			PlayerID: client.User.Player.ID,
+			State:    room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	return room.BroadcastToRoom(response)
+}
+
+func (h *MessageHandler) handleTopUp(client *Client, msg models.MessageTopUp) error {
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil {
+		return h.sendError(client, msg.RoomID, "Room not found")
+	}
+
+	if err := room.Game.TopUp(client.User.Player.ID, msg.Amount); err != nil {
+		log.Printf("[ERROR] Failed to top up - RoomID: %s, PlayerID: %s, Error: %v", room.ID, client.User.Player.ID, err)
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to top up: %v", err))
+	}
+
+	response := models.Response{
+		Type: models.MessageTypeTopUp,
+		Data: models.MessageTopUpResponse{
+			RoomID:   room.ID,
+			PlayerID: client.User.Player.ID,
+			Amount:   msg.Amount,
+			State:    room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	return room.BroadcastToRoom(response)
+}
+
+func (h *MessageHandler) handleSpectateJoin(client *Client, msg models.MessageSpectateJoin) error {
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil {
+		return h.sendError(client, msg.RoomID, "Room not found")
+	}
+
+	if room.Game.IsSeated(client.User.Player.ID) {
+		return h.sendError(client, msg.RoomID, "Seated players can not spectate")
+	}
+
+	if err := room.AddSpectator(client); err != nil {
+		log.Printf("[ERROR] Failed to add spectator - RoomID: %s, PlayerID: %s, Error: %v", room.ID, client.User.Player.ID, err)
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to spectate room: %v", err))
+	}
+
+	log.Printf("[INFO] Spectator joined - RoomID: %s, PlayerID: %s, Spectators: %d", room.ID, client.User.Player.ID, room.SpectatorCount())
+
+	response := models.Response{
+		Type:   models.MessageTypeSpectateJoinOk,
+		RoomID: room.ID,
+		Data: models.MessageSpectateResponse{
+			RoomID:     room.ID,
+			Player:     client.User.Player,
+			Spectators: room.SpectatorCount(),
+			State:      room.GetRoomStateForPlayer(client.User.Player.ID),
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	client.Broadcast(response)
+	response = models.Response{
+		Type: models.MessageTypeSpectateJoin,
+		Data: models.MessageSpectateResponse{
+			RoomID:     room.ID,
+			Player:     client.User.Player,
+			Spectators: room.SpectatorCount(),
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	return room.BroadcastToOthers(client.User.Player.ID, response)
+}
+
+func (h *MessageHandler) handleSpectateLeave(client *Client, msg models.MessageSpectateLeave) error {
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil {
+		return h.sendError(client, msg.RoomID, "Room not found")
+	}
+
+	if err := room.RemoveSpectator(client.User.Player.ID); err != nil {
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to stop spectating: %v", err))
+	}
+
+	log.Printf("[INFO] Spectator left - RoomID: %s, PlayerID: %s, Spectators: %d", room.ID, client.User.Player.ID, room.SpectatorCount())
+
+	response := models.Response{
+		Type:      models.MessageTypeSpectateLeaveOk,
+		RoomID:    room.ID,
+		Data:      room.ID,
+		Timestamp: time.Now().UTC(),
+	}
+
+	client.Broadcast(response)
+	response = models.Response{
+		Type: models.MessageTypeSpectateLeave,
+		Data: models.MessageSpectateResponse{
+			RoomID:     room.ID,
+			Player:     client.User.Player,
+			Spectators: room.SpectatorCount(),
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	return room.BroadcastToOthers(client.User.Player.ID, response)
+}
+
 func (h *MessageHandler) handleGameAction(client *Client, msg models.MessageGameAction) error {
 	room := h.server.GetRoom(msg.RoomID)
 	if room == nil {
 		log.Printf("[ERROR] Room not found for game action - RoomID: %s, PlayerID: %s", msg.RoomID, client.User.Player.ID)
-		return h.sendError(client, "Room not found")
+		return h.sendError(client, msg.RoomID, "Room not found")
 	}
 
 	game := room.Game
 	if game == nil || game.Status != GameStatusStarted {
 		log.Printf("[ERROR] Invalid game state for action - RoomID: %s, GameID: %s, Status: %s", room.ID, game.ID, game.Status)
-		return h.sendError(client, "Game is not in progress")
+		return h.sendError(client, msg.RoomID, "Game is not in progress")
 	}
 
 	var currentPlayer *GamePlayer
@@ -267,7 +535,7 @@ func (h *MessageHandler) handleGameAction(client *Client, msg models.MessageGame
 
 	if currentPlayer == nil {
 		log.Printf("[ERROR] Player not found in game - GameID: %s, PlayerID: %s", game.ID, client.User.Player.ID)
-		return h.sendError(client, "Player not found in game")
+		return h.sendError(client, msg.RoomID, "Player not found in game")
 	}
 
 	var action GameAction
@@ -275,19 +543,57 @@ func (h *MessageHandler) handleGameAction(client *Client, msg models.MessageGame
 		return err
 	}
 
+	// Players can only act for themselves, whatever player the action names
+	data, err := withPlayerID(msg.Data, client.User.Player.ID)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[INFO] Processing game action - GameID: %s, PlayerID: %s, Action: %s", game.ID, client.User.Player.ID, action.ActionType)
 
-	if err := h.roomManager.ProcessAction(room.ID, msg.Data); err != nil {
-		return h.sendError(client, fmt.Sprintf("Failed to process action: %v", err))
+	if err := h.roomManager.ProcessAction(room.ID, data); err != nil {
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to process action: %v", err))
+	}
+
+	return nil
+}
+
+// withPlayerID returns the action with its player_id set to the player.
+func withPlayerID(action json.RawMessage, playerID string) (json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(action, &fields); err != nil {
+		return nil, err
+	}
+
+	id, err := json.Marshal(playerID)
+	if err != nil {
+		return nil, err
+	}
+	fields["player_id"] = id
+
+	return json.Marshal(fields)
+}
+
+func (h *MessageHandler) handleGameClientSeed(client *Client, msg models.MessageGameClientSeed) error {
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil {
+		return h.sendError(client, msg.RoomID, "Room not found")
+	}
+
+	if err := room.Game.SetPlayerClientSeed(client.User.Player.ID, msg.Seed); err != nil {
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to set client seed: %v", err))
 	}
 
+	log.Printf("[INFO] Client seed updated - RoomID: %s, PlayerID: %s", room.ID, client.User.Player.ID)
 	return nil
 }
 
-func (h *MessageHandler) sendError(client *Client, errorMsg string) error {
+// sendError tells the client the message about the room failed.
+func (h *MessageHandler) sendError(client *Client, roomID string, errorMsg string) error {
 	response := models.Response{
-		Type: models.MessageTypeError,
-		Data: map[string]string{"error": errorMsg},
+		Type:   models.MessageTypeError,
+		RoomID: roomID,
+		Data:   map[string]string{"error": errorMsg},
 	}
 
 	msgBytes, err := json.Marshal(response)
@@ -295,7 +601,7 @@ func (h *MessageHandler) sendError(client *Client, errorMsg string) error {
 		return err
 	}
 
-	client.send <- msgBytes
+	client.Send(msgBytes)
 	return nil
 }
 
@@ -310,9 +616,11 @@ func (h *MessageHandler) sendError(client *Client, errorMsg string) error {
 // }
 
 func (h *MessageHandler) broadcastRoomState(room *Room) {
+	state := room.GetRoomState()
 	stateMsg := models.Response{
-		Type: models.MessageTypeRoomInfo,
-		Data: room.GetRoomState(),
+		Type:   models.MessageTypeRoomInfo,
+		RoomID: room.ID,
+		Data:   state,
 	}
 
 	msgBytes, err := json.Marshal(stateMsg)
@@ -324,6 +632,7 @@ func (h *MessageHandler) broadcastRoomState(room *Room) {
 	log.Printf("[INFO] Broadcasting room state - RoomID: %s, GameID: %s, GameStatus: %s, PlayerCount: %d", room.ID, room.Game.ID, room.Game.Status, len(room.Game.Players))
 
 	h.server.BroadcastToRoom(room.ID, msgBytes)
+	room.BroadcastToSpectators(stateMsg, state.GameState)
 }
 func ParseData[T any](data json.RawMessage) (*T, error) {
 	var result T
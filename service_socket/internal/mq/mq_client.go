@@ -17,8 +17,8 @@ const (
 )
 
 const (
-	ChipUpdatesRoutingKey           string = "poker.game.*.chip_update.*" // poker.game.holdem.chip_update.room_123
-	GameAnalyticsRoutingKey         string = "poker.game.*.*.#"           // poker.game.holdem.hand_complete.room_123
+	ChipUpdatesRoutingKey           string = "poker.game.*.chip_update.*" // poker.game.1.chip_update.room_123
+	GameAnalyticsRoutingKey         string = "poker.game.*.*.#"           // poker.game.1.hand_complete.room_123
 	GameAuditsRoutingKey            string = "poker.game.*.hand_complete.*"
 	ChipUpdatesDeadLetterRoutingKey string = "poker.game.*.chip_update.*"
 )
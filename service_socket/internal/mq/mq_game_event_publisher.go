@@ -27,25 +27,32 @@ func NewGameEventPublisher() (*GameEventPublisher, error) {
 	}, err
 }
 
-func (p *GameEventPublisher) PublishChipUpdate(msg *ChipUpdateMessage) error {
+func (p *GameEventPublisher) PublishHandComplete(msg *HandCompleteMessage) error {
 	msg.MessageID = uuid.New().String()
 	msg.Timestamp = time.Now()
-	routingKey := fmt.Sprintf("poker.game.%s.chip_update.%s", msg.GameType, msg.RoomID)
+	routingKey := fmt.Sprintf("poker.game.%d.hand_complete.%s", msg.GameType, msg.RoomID)
 
 	return p.client.Provider.Publish(p.exchange, routingKey, msg)
 }
 
-type ChipUpdateMessage struct {
+// ChipTransactionType is why chips move between a player's wallet and a
+// table, recorded by the wallet with each reservation it makes and settles.
+type ChipTransactionType string
+
+const (
+	ChipTransactionBuyIn   ChipTransactionType = "buy_in"   // Wallet to table when the player sits down or registers
+	ChipTransactionTopUp   ChipTransactionType = "top_up"   // Wallet to table between hands
+	ChipTransactionCashOut ChipTransactionType = "cash_out" // Table to wallet when the player leaves
+	ChipTransactionPrize   ChipTransactionType = "prize"    // Tournament to wallet for the place the player finished in
+)
+
+type HandCompleteMessage struct {
 	MessageID string    `json:"message_id"`
 	Timestamp time.Time `json:"timestamp"`
-	GameType  int       `json:"game_type"` // "holdem", "tournament"
+	GameType  int       `json:"game_type"`
 
 	RoomID string `json:"room_id"`
+	HandID string `json:"hand_id"`
 
-	PlayerChanges []PlayerChipChange `json:"player_changes"`
-}
-
-type PlayerChipChange struct {
-	PlayerID string `json:"player_id"`
-	Change   int    `json:"change"`
+	Hand interface{} `json:"hand"`
 }
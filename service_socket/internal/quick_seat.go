@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+	"github.com/google/uuid"
+)
+
+// QuickSeatTimeout is how long a player waits in the quick seat queue.
+const QuickSeatTimeout = 5 * time.Minute
+
+// QuickSeatInterval is how often the queued players are matched to tables.
+const QuickSeatInterval = 2 * time.Second
+
+type quickSeatTicket struct {
+	ID        string
+	Client    *Client
+	Request   models.MessageQuickSeat
+	ExpiresAt time.Time
+}
+
+// FindSeats returns the rooms with a seat matching the request, best first:
+// tables of the preferred size, then the fullest tables.
+func (rm *RoomManager) FindSeats(playerID string, request models.MessageQuickSeat) []*Room {
+	rm.mu.RLock()
+	candidates := make([]*Room, 0)
+	for _, room := range rm.rooms {
+		if !room.isListed() || int(room.Game.GameType) != request.GameType ||
+			(request.MinStake > 0 && room.MinBet < request.MinStake) ||
+			(request.MaxStake > 0 && room.MinBet > request.MaxStake) {
+			continue
+		}
+		candidates = append(candidates, room)
+	}
+	rm.mu.RUnlock()
+
+	seated := make(map[*Room]int)
+	rooms := make([]*Room, 0, len(candidates))
+	for _, room := range candidates {
+		if !room.HasOpenSeat() || room.Game.IsSeated(playerID) {
+			continue
+		}
+
+		room.Game.Mu.RLock()
+		seated[room] = len(room.Game.Players)
+		room.Game.Mu.RUnlock()
+		rooms = append(rooms, room)
+	}
+
+	sort.Slice(rooms, func(i, j int) bool {
+		iSize := rooms[i].Game.MaxPlayers == request.TableSize
+		jSize := rooms[j].Game.MaxPlayers == request.TableSize
+		if iSize != jSize {
+			return iSize
+		}
+		if seated[rooms[i]] != seated[rooms[j]] {
+			return seated[rooms[i]] > seated[rooms[j]]
+		}
+		return rooms[i].ID < rooms[j].ID
+	})
+
+	return rooms
+}
+
+// RunQuickSeats seats the queued players as tables open up, until the server
+// stops.
+func (s *Server) RunQuickSeats() {
+	ticker := time.NewTicker(QuickSeatInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.handler.matchQuickSeats()
+	}
+}
+
+func (h *MessageHandler) handleQuickSeat(client *Client, msg models.MessageQuickSeat) error {
+	playerID := client.User.Player.ID
+	if limit := h.server.MaxTablesPerPlayer; limit > 0 && h.roomManager.CountTablesByPlayerID(playerID) >= limit {
+		return h.sendError(client, "", fmt.Sprintf("Failed to quick seat: at most %d tables at a time", limit))
+	}
+
+	ticket := &quickSeatTicket{
+		Client:  client,
+		Request: msg,
+	}
+
+	seated, err := h.quickSeat(ticket)
+	if err != nil {
+		return h.sendError(client, "", fmt.Sprintf("Failed to quick seat: %v", err))
+	}
+	if seated {
+		return nil
+	}
+
+	ticket.ID = uuid.New().String()
+	ticket.ExpiresAt = time.Now().Add(QuickSeatTimeout)
+
+	h.quickSeatsMu.Lock()
+	// A player waits with one ticket, the latest request
+	for id, queued := range h.quickSeats {
+		if queued.Client.User.Player.ID == playerID {
+			delete(h.quickSeats, id)
+		}
+	}
+	h.quickSeats[ticket.ID] = ticket
+	h.quickSeatsMu.Unlock()
+
+	log.Printf("[INFO] Player queued for quick seat - PlayerID: %s, TicketID: %s, GameType: %d, Stakes: %d-%d", playerID, ticket.ID, msg.GameType, msg.MinStake, msg.MaxStake)
+
+	client.Broadcast(models.Response{
+		Type: models.MessageTypeQuickSeatQueued,
+		Data: models.MessageQuickSeatQueuedResponse{
+			TicketID:  ticket.ID,
+			ExpiresAt: ticket.ExpiresAt,
+		},
+		Timestamp: time.Now().UTC(),
+	})
+
+	return nil
+}
+
+func (h *MessageHandler) handleQuickSeatCancel(client *Client, msg models.MessageQuickSeatCancel) error {
+	h.quickSeatsMu.Lock()
+	ticket, ok := h.quickSeats[msg.TicketID]
+	if ok && ticket.Client.User.Player.ID == client.User.Player.ID {
+		delete(h.quickSeats, msg.TicketID)
+	}
+	h.quickSeatsMu.Unlock()
+
+	if !ok || ticket.Client.User.Player.ID != client.User.Player.ID {
+		return h.sendError(client, "", "Quick seat ticket not found")
+	}
+
+	client.Broadcast(models.Response{
+		Type:      models.MessageTypeQuickSeatCancelOk,
+		Data:      msg.TicketID,
+		Timestamp: time.Now().UTC(),
+	})
+
+	return nil
+}
+
+// quickSeat seats the player at the best table for the ticket. It tells
+// whether they got a seat, and fails when they can not sit at any table.
+func (h *MessageHandler) quickSeat(ticket *quickSeatTicket) (bool, error) {
+	client := ticket.Client
+	for _, room := range h.roomManager.FindSeats(client.User.Player.ID, ticket.Request) {
+		position, ok := room.Game.FreePosition()
+		if !ok {
+			continue
+		}
+
+		err := h.seatPlayer(client, room, position, ticket.Request.BlindEntry, ticket.Request.BuyIn)
+		if errors.Is(err, ErrorGameInsufficientChips) || errors.Is(err, ErrorGameInvalidBuyIn) {
+			return false, err
+		}
+		if err != nil {
+			// The seat went to someone else first
+			continue
+		}
+
+		client.Broadcast(models.Response{
+			Type:   models.MessageTypeQuickSeatFound,
+			RoomID: room.ID,
+			Data: models.MessageQuickSeatFoundResponse{
+				TicketID: ticket.ID,
+				RoomID:   room.ID,
+				Position: position,
+			},
+			Timestamp: time.Now().UTC(),
+		})
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// matchQuickSeats seats the queued players who have a table now, oldest
+// ticket first, and drops the expired tickets.
+func (h *MessageHandler) matchQuickSeats() {
+	h.quickSeatsMu.Lock()
+	tickets := make([]*quickSeatTicket, 0, len(h.quickSeats))
+	for _, ticket := range h.quickSeats {
+		tickets = append(tickets, ticket)
+	}
+	h.quickSeatsMu.Unlock()
+
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].ExpiresAt.Before(tickets[j].ExpiresAt) })
+
+	for _, ticket := range tickets {
+		h.quickSeatsMu.Lock()
+		_, queued := h.quickSeats[ticket.ID]
+		h.quickSeatsMu.Unlock()
+		if !queued {
+			continue
+		}
+
+		if !ticket.Client.Connected() {
+			h.dropQuickSeat(ticket)
+			continue
+		}
+
+		if time.Now().After(ticket.ExpiresAt) {
+			h.dropQuickSeat(ticket)
+			ticket.Client.Broadcast(models.Response{
+				Type:      models.MessageTypeQuickSeatExpired,
+				Data:      ticket.ID,
+				Timestamp: time.Now().UTC(),
+			})
+			continue
+		}
+
+		seated, err := h.quickSeat(ticket)
+		if err != nil {
+			h.dropQuickSeat(ticket)
+			h.sendError(ticket.Client, "", fmt.Sprintf("Failed to quick seat: %v", err))
+			continue
+		}
+
+		if seated {
+			log.Printf("[INFO] Quick seat found - PlayerID: %s, TicketID: %s", ticket.Client.User.Player.ID, ticket.ID)
+			h.dropQuickSeat(ticket)
+		}
+	}
+}
+
+func (h *MessageHandler) dropQuickSeat(ticket *quickSeatTicket) {
+	h.quickSeatsMu.Lock()
+	defer h.quickSeatsMu.Unlock()
+
+	delete(h.quickSeats, ticket.ID)
+}
+
+// dropQuickSeats takes the client's tickets out of the queue.
+func (h *MessageHandler) dropQuickSeats(client *Client) {
+	h.quickSeatsMu.Lock()
+	defer h.quickSeatsMu.Unlock()
+
+	for id, ticket := range h.quickSeats {
+		if ticket.Client == client {
+			delete(h.quickSeats, id)
+		}
+	}
+}
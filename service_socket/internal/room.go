@@ -4,20 +4,40 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"sync"
+	"time"
 
 	"github.com/ahmetkoprulu/rtrp/game/models"
 )
 
 var (
-	ErrorRoomFull = errors.New("room is full")
+	ErrorRoomNotFound       = errors.New("room not found")
+	ErrorRoomExists         = errors.New("room already exists")
+	ErrorRoomInvalid        = errors.New("room needs an id, a name, a min bet and seats for the room to hold")
+	ErrorRoomInUse          = errors.New("room has seated players")
+	ErrorRoomClosed         = errors.New("room is closed")
+	ErrorRoomFull           = errors.New("room is full")
+	ErrorRoomSpectatorsFull = errors.New("room has no room for more spectators")
+	ErrorRoomNotSpectating  = errors.New("player is not spectating this room")
+	ErrorRoomPrivate        = errors.New("room is private")
 )
 
+// DefaultMaxSpectators is the number of clients that can watch a room, on top
+// of its MaxPlayers.
+const DefaultMaxSpectators = 50
+
+// DefaultSpectatorDelay is how long game messages are held back from
+// spectators, so that a spectator can not pass on a hand while it is played.
+const DefaultSpectatorDelay = 10 * time.Second
+
 type RoomStatus string
 
 const (
 	RoomStatusActive   RoomStatus = "active"
 	RoomStatusInactive RoomStatus = "inactive"
+	RoomStatusPaused   RoomStatus = "paused" // Deals no new hands, players keep their seats
+	RoomStatusClosed   RoomStatus = "closed" // Takes no one in and is taken down after the hand
 )
 
 type Room struct {
@@ -28,9 +48,44 @@ type Room struct {
 	MaxPlayers     int                  `json:"max_players"`
 	MinBet         int                  `json:"min_bet"`
 	Players        map[string]*Client   `json:"players"`
+	Spectators     map[string]*Client   `json:"spectators"`
+	MaxSpectators  int                  `json:"max_spectators"`
+	SpectatorDelay time.Duration        `json:"spectator_delay"`
 	ActionChannel  chan GameAction      `json:"-"`
 	MessageChannel chan models.Response `json:"-"`
-	mu             sync.Mutex           `json:"-"`
+	spectatorFeed  chan spectatorMessage
+	mu             sync.Mutex `json:"-"`
+
+	// spectatorState is the public game state spectators last saw, shown to
+	// those who start watching so they never see the table as it is now
+	spectatorState json.RawMessage
+
+	// done is closed when the room is closed, stopping its spectator feed
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// Definition is how the room was set up, kept to save changes to it
+	Definition models.RoomDefinition `json:"-"`
+	statusMu   sync.RWMutex
+
+	// PoolID is the pool that opened the room, empty for rooms opened by hand
+	PoolID string `json:"pool_id,omitempty"`
+
+	// Private is set on rooms only the players let in can get into
+	Private *PrivateRoom `json:"-"`
+
+	// SitAndGo is set on the tables of single table tournaments
+	SitAndGo *SitAndGo `json:"-"`
+
+	// Tournament is set on the tables of multi-table tournaments
+	Tournament *Tournament `json:"-"`
+}
+
+type spectatorMessage struct {
+	msg    []byte
+	state  json.RawMessage // Public game state the message carries, if any
+	except string          // Spectator the message is not sent to
+	sendAt time.Time
 }
 
 func NewRoom(id, name string, maxPlayers int, minBet int, gameType GameType) *Room {
@@ -41,32 +96,157 @@ func NewRoom(id, name string, maxPlayers int, minBet int, gameType GameType) *Ro
 		MaxPlayers:     maxPlayers,
 		MinBet:         minBet,
 		Players:        make(map[string]*Client),
+		Spectators:     make(map[string]*Client),
+		MaxSpectators:  DefaultMaxSpectators,
+		SpectatorDelay: DefaultSpectatorDelay,
 		ActionChannel:  make(chan GameAction),
 		MessageChannel: make(chan models.Response, 100),
+		spectatorFeed:  make(chan spectatorMessage, 256),
 		mu:             sync.Mutex{},
+		done:           make(chan struct{}),
 	}
 
+	go room.runSpectatorFeed()
 	return room
 }
 
+// SetSpectatorLimits sets how many clients can watch the room and how long
+// game messages are delayed for them.
+func (r *Room) SetSpectatorLimits(maxSpectators int, delay time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.MaxSpectators = maxSpectators
+	r.SpectatorDelay = delay
+}
+
 func (r *Room) AddPlayer(player *Client) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if len(r.Players) >= r.MaxPlayers {
+	if r.GetStatus() == RoomStatusClosed {
+		return ErrorRoomClosed
+	}
+
+	if _, ok := r.Players[player.User.Player.ID]; !ok && len(r.Players) >= r.MaxPlayers {
 		return ErrorRoomFull
 	}
 
+	if !r.isAdmitted(player.User.Player.ID) {
+		return ErrorRoomPrivate
+	}
+
 	// if player.CurrentRoom.ID == r.ID {
 	// 	return nil
 	// }
 
+	// Taking a seat ends spectating
+	delete(r.Spectators, player.User.Player.ID)
 	r.Players[player.User.Player.ID] = player
-	player.CurrentRoom = r
+	player.joinRoom(r)
+
+	return nil
+}
+
+// ReattachClient moves the player's place in the room and their seat in the
+// game to a new connection of the same player, and returns the connection it
+// replaced.
+func (r *Room) ReattachClient(client *Client) *Client {
+	r.mu.Lock()
+	playerID := client.User.Player.ID
+	previous := r.Players[playerID]
+	r.Players[playerID] = client
+	client.joinRoom(r)
+	if previous != nil && previous != client {
+		previous.leaveRoom(r)
+	}
+	r.mu.Unlock()
+
+	if r.Game != nil {
+		r.Game.ReattachClient(client)
+	}
+
+	return previous
+}
+
+// HasClient reports whether the client is the connection the room uses for
+// its player.
+func (r *Room) HasClient(client *Client) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.Players[client.User.Player.ID] == client
+}
+
+// HasPlayer reports whether the player is in the room, by any connection.
+func (r *Room) HasPlayer(playerID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.Players[playerID]
+	return ok
+}
+
+// AddSpectator lets the client watch the room. A client in the room that has
+// not taken a seat becomes a spectator instead.
+func (r *Room) AddSpectator(client *Client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	playerID := client.User.Player.ID
+	if _, ok := r.Spectators[playerID]; ok {
+		return nil
+	}
+
+	if r.GetStatus() == RoomStatusClosed {
+		return ErrorRoomClosed
+	}
+
+	if len(r.Spectators) >= r.MaxSpectators {
+		return ErrorRoomSpectatorsFull
+	}
+
+	if !r.isAdmitted(playerID) {
+		return ErrorRoomPrivate
+	}
+
+	delete(r.Players, playerID)
+	r.Spectators[playerID] = client
+	client.joinRoom(r)
+
+	return nil
+}
+
+func (r *Room) RemoveSpectator(playerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.Spectators[playerID]
+	if !ok {
+		return ErrorRoomNotSpectating
+	}
+
+	delete(r.Spectators, playerID)
+	client.leaveRoom(r)
 
 	return nil
 }
 
+func (r *Room) IsSpectator(playerID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.Spectators[playerID]
+	return ok
+}
+
+func (r *Room) SpectatorCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.Spectators)
+}
+
 func (r *Room) RemovePlayer(playerID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -75,24 +255,137 @@ func (r *Room) RemovePlayer(playerID string) error {
 		r.Game.RemovePlayer(playerID)
 	}
 
+	if client, ok := r.Players[playerID]; ok {
+		client.leaveRoom(r)
+	}
 	delete(r.Players, playerID)
 	return nil
 }
 
+func (r *Room) GetStatus() RoomStatus {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+
+	return r.Status
+}
+
+func (r *Room) SetStatus(status RoomStatus) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+
+	r.Status = status
+}
+
+// Close cashes out the players left at the table, tells everyone in the room
+// it closed, empties it and stops its spectator feed. It runs once no hand is
+// played.
+func (r *Room) Close() {
+	r.Game.Mu.Lock()
+	for _, player := range r.Game.Players {
+		player.Status = GamePlayerStatusInactive
+	}
+	r.Game.removeLeftPlayers()
+	// Tournaments stop the clock their tables share themselves
+	if r.Game.BlindClock != nil && r.Game.Tournament == nil {
+		r.Game.BlindClock.stop()
+	}
+	r.Game.Mu.Unlock()
+
+	closed := models.Response{
+		Type:      models.MessageTypeRoomClosed,
+		Data:      r.ID,
+		Timestamp: time.Now().UTC(),
+	}
+	r.BroadcastToRoom(closed)
+
+	// The feed stops with the room, so spectators are told right away
+	closed.RoomID = r.ID
+	msg, err := json.Marshal(closed)
+	if err != nil {
+		log.Printf("[ERROR] Failed to marshal room closed message - RoomID: %s, Error: %v", r.ID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, client := range r.Players {
+		client.leaveRoom(r)
+	}
+
+	for _, client := range r.Spectators {
+		if err == nil {
+			client.SendOrDrop(msg)
+		}
+		client.leaveRoom(r)
+	}
+
+	r.Players = make(map[string]*Client)
+	r.Spectators = make(map[string]*Client)
+	r.closeOnce.Do(func() { close(r.done) })
+}
+
+// HasOpenSeat tells whether the room deals and has a seat left at its table.
+func (r *Room) HasOpenSeat() bool {
+	if r.GetStatus() != RoomStatusActive {
+		return false
+	}
+
+	r.Game.Mu.RLock()
+	defer r.Game.Mu.RUnlock()
+
+	return len(r.Game.Players) < r.Game.MaxPlayers
+}
+
+// IsEmpty tells whether no one is seated at, in or watching the room.
+func (r *Room) IsEmpty() bool {
+	r.Game.Mu.RLock()
+	seated := len(r.Game.Players)
+	r.Game.Mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return seated == 0 && len(r.Players) == 0 && len(r.Spectators) == 0
+}
+
 func (r *Room) IsGameActive() bool {
 	return r.Game != nil && r.Game.Status == GameStatusStarted
 }
 
 func (r *Room) GetRoomState() RoomState {
-	gameState := r.Game.GetGameState()
+	return r.buildRoomState(r.Game.GetGameState())
+}
+
+// GetRoomStateForPlayer returns the room state with the game state as seen by
+// the given player. Spectators get the public view they are shown, see
+// GetSpectatorRoomState.
+func (r *Room) GetRoomStateForPlayer(playerID string) RoomState {
+	if r.IsSpectator(playerID) {
+		return r.GetSpectatorRoomState()
+	}
+
+	return r.buildRoomState(r.Game.GetPlayerState(playerID))
+}
 
+// GetSpectatorRoomState returns the room state with the game state spectators
+// last got from the feed, SpectatorDelay behind the table.
+func (r *Room) GetSpectatorRoomState() RoomState {
+	r.mu.Lock()
+	state := r.spectatorState
+	r.mu.Unlock()
+
+	return r.buildRoomState(state)
+}
+
+func (r *Room) buildRoomState(gameState any) RoomState {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	return RoomState{
 		RoomID:     r.ID,
-		Status:     r.Status,
+		Status:     r.GetStatus(),
 		Players:    r.GetPlayersState(),
+		Spectators: r.GetSpectatorsState(),
 		MaxPlayers: r.MaxPlayers,
 		MinBet:     r.MinBet,
 		GameType:   r.Game.GameType,
@@ -110,10 +403,33 @@ func (r *Room) GetPlayersState() []*models.Player {
 	return players
 }
 
+func (r *Room) GetSpectatorsState() []*models.Player {
+	spectators := make([]*models.Player, 0, len(r.Spectators))
+	for _, spectator := range r.Spectators {
+		spectators = append(spectators, spectator.User.Player)
+	}
+
+	return spectators
+}
+
+func (r *Room) GetPlayerIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	playerIDs := make([]string, 0, len(r.Players))
+	for playerID := range r.Players {
+		playerIDs = append(playerIDs, playerID)
+	}
+
+	return playerIDs
+}
+
 func (r *Room) GetRoomSummary() *RoomSummary {
 	return &RoomSummary{
 		Id:             r.ID,
-		Status:         r.Status,
+		Name:           r.Name,
+		Status:         r.GetStatus(),
+		PoolID:         r.PoolID,
 		MaxRoomPlayers: r.MaxPlayers,
 		PlayersInRoom:  len(r.Players),
 		GameStatus:     r.Game.Status,
@@ -121,6 +437,10 @@ func (r *Room) GetRoomSummary() *RoomSummary {
 		MinBet:         r.MinBet,
 		MaxGamePlayers: r.Game.MaxPlayers,
 		PlayersInGame:  len(r.Game.Players),
+		Spectators:     r.SpectatorCount(),
+		MaxSpectators:  r.MaxSpectators,
+		MinBuyIn:       r.Game.BuyIn.Min,
+		MaxBuyIn:       r.Game.BuyIn.Max,
 	}
 }
 
@@ -128,6 +448,8 @@ func (r *Room) BroadcastToPlayer(playerID string, response models.Response) erro
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	response.RoomID = r.ID
+
 	client, ok := r.Players[playerID]
 	if !ok {
 		return fmt.Errorf("player not found")
@@ -138,7 +460,7 @@ func (r *Room) BroadcastToPlayer(playerID string, response models.Response) erro
 		return fmt.Errorf("error marshalling message: %v", err)
 	}
 
-	client.send <- msg
+	client.Send(msg)
 	return nil
 }
 
@@ -146,6 +468,8 @@ func (r *Room) BroadcastToOthers(playerID string, response models.Response) erro
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	response.RoomID = r.ID
+
 	msg, err := json.Marshal(response)
 	if err != nil {
 		return fmt.Errorf("error marshalling message: %v", err)
@@ -153,10 +477,11 @@ func (r *Room) BroadcastToOthers(playerID string, response models.Response) erro
 
 	for _, p := range r.Players {
 		if p.User.Player.ID != playerID {
-			p.send <- msg
+			p.Send(msg)
 		}
 	}
 
+	r.queueForSpectators(spectatorMessage{msg: msg, except: playerID})
 	return nil
 }
 
@@ -164,18 +489,107 @@ func (r *Room) BroadcastToRoom(response models.Response) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	response.RoomID = r.ID
+
 	msg, err := json.Marshal(response)
 	if err != nil {
 		return fmt.Errorf("error marshalling message: %v", err)
 	}
 
 	for _, p := range r.Players {
-		p.send <- msg
+		p.Send(msg)
 	}
 
+	r.queueForSpectators(spectatorMessage{msg: msg})
 	return nil
 }
 
+// BroadcastToSpectators queues the message for the spectators, who get it
+// once the room's SpectatorDelay has passed. state is the public game state
+// the message was built with, shown to spectators who start watching after it
+// is delivered. Spectators get room messages only through this feed.
+func (r *Room) BroadcastToSpectators(response models.Response, state any) {
+	response.RoomID = r.ID
+	msg, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("[ERROR] Failed to marshal spectator message - RoomID: %s, Error: %v", r.ID, err)
+		return
+	}
+
+	// Taken now, the state may change before it is delivered
+	message := spectatorMessage{msg: msg}
+	if state != nil {
+		if message.state, err = json.Marshal(state); err != nil {
+			log.Printf("[ERROR] Failed to marshal spectator state - RoomID: %s, Error: %v", r.ID, err)
+			return
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queueForSpectators(message)
+}
+
+// queueForSpectators adds the message to the spectator feed. Messages are
+// delivered in the order they are queued. Messages for a closed room, or that
+// do not fit in the feed, are dropped so the game never waits on its
+// spectators. The caller holds mu.
+func (r *Room) queueForSpectators(message spectatorMessage) {
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+
+	message.sendAt = time.Now().Add(r.SpectatorDelay)
+	select {
+	case r.spectatorFeed <- message:
+	default:
+		log.Printf("[ERROR] Spectator feed full, dropping message - RoomID: %s", r.ID)
+	}
+}
+
+// runSpectatorFeed delivers the queued spectator messages until the room is
+// closed.
+func (r *Room) runSpectatorFeed() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		var message spectatorMessage
+		select {
+		case message = <-r.spectatorFeed:
+		case <-r.done:
+			return
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(message.sendAt))
+		select {
+		case <-timer.C:
+		case <-r.done:
+			return
+		}
+
+		r.mu.Lock()
+		if message.state != nil {
+			r.spectatorState = message.state
+		}
+		for playerID, spectator := range r.Spectators {
+			if playerID != message.except {
+				spectator.SendOrDrop(message.msg)
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
 // Reset disconnects all clients and resets room/game state
 func (r *Room) Reset() error {
 	r.mu.Lock()
@@ -193,15 +607,26 @@ func (r *Room) Reset() error {
 		}
 
 		// Close the send channel to stop the client goroutines
-		close(client.send)
+		client.closeSend()
 
 		// Clear client's room reference
-		client.CurrentRoom = nil
-		client.CurrentGame = nil
+		client.leaveRoom(r)
+	}
+
+	for spectatorID, client := range r.Spectators {
+		fmt.Printf("[ADMIN] Disconnecting spectator %s\n", spectatorID)
+
+		if client.Conn != nil {
+			client.Conn.Close()
+		}
+
+		client.closeSend()
+		client.leaveRoom(r)
 	}
 
-	// Clear all players from room
+	// Clear all players and spectators from room
 	r.Players = make(map[string]*Client)
+	r.Spectators = make(map[string]*Client)
 
 	// Reset game if it exists
 	if r.Game != nil {
@@ -221,6 +646,7 @@ type RoomState struct {
 	MaxPlayers     int              `json:"max_players"`
 	MaxGamePlayers int              `json:"max_game_players"`
 	Players        []*models.Player `json:"players"`
+	Spectators     []*models.Player `json:"spectators"`
 	MinBet         int              `json:"min_bet"`
 	GameType       GameType         `json:"game_type"`
 	GameStatus     GameStatus       `json:"game_status"`
@@ -229,7 +655,9 @@ type RoomState struct {
 
 type RoomSummary struct {
 	Id             string     `json:"id"`
+	Name           string     `json:"name"`
 	Status         RoomStatus `json:"status"`
+	PoolID         string     `json:"pool_id,omitempty"`
 	MaxRoomPlayers int        `json:"max_room_players"`
 	PlayersInRoom  int        `json:"players_in_room"`
 	GameStatus     GameStatus `json:"game_status"`
@@ -237,6 +665,10 @@ type RoomSummary struct {
 	MinBet         int        `json:"min_bet"`
 	MaxGamePlayers int        `json:"max_game_players"`
 	PlayersInGame  int        `json:"players_in_game"`
+	Spectators     int        `json:"spectators"`
+	MaxSpectators  int        `json:"max_spectators"`
+	MinBuyIn       int        `json:"min_buy_in"`
+	MaxBuyIn       int        `json:"max_buy_in"`
 }
 
 type RoomJoinOkResponse struct {
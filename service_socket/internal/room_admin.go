@@ -0,0 +1,360 @@
+package internal
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+// IRoomStore keeps the room definitions so the rooms survive restarts.
+type IRoomStore interface {
+	GetRooms() ([]models.RoomDefinition, error)
+	SaveRoom(room models.RoomDefinition) error
+	SetRoomStatus(roomID string, status string) error
+}
+
+// DefaultRooms are created when the store has no rooms yet.
+var DefaultRooms = []models.RoomDefinition{
+	{
+		ID:               "room_1",
+		Name:             "Default Room",
+		GameType:         int(GameTypeHoldem),
+		BettingStructure: string(BettingNoLimit),
+		MinBet:           10,
+		MaxPlayers:       100,
+		MaxGamePlayers:   5,
+	},
+	{
+		ID:               "room_2",
+		Name:             "Pot-Limit Omaha",
+		GameType:         int(GameTypeOmaha),
+		BettingStructure: string(BettingPotLimit),
+		MinBet:           10,
+		MaxPlayers:       100,
+		MaxGamePlayers:   6,
+		// Four hole cards take a little longer to read
+		ActionTimeoutSeconds: 15,
+	},
+}
+
+// roomClosePollInterval is how often a closed room checks whether the hand
+// being played is over.
+const roomClosePollInterval = time.Second
+
+// LoadRooms creates the rooms kept in the store. A store without rooms gets
+// the default rooms.
+func (rm *RoomManager) LoadRooms() error {
+	definitions := DefaultRooms
+	if rm.Store != nil {
+		stored, err := rm.Store.GetRooms()
+		if err != nil {
+			return err
+		}
+
+		if len(stored) > 0 {
+			definitions = stored
+		} else {
+			for _, definition := range DefaultRooms {
+				if err := rm.Store.SaveRoom(definition); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, definition := range definitions {
+		room, err := rm.buildRoom(definition)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load room - RoomID: %s, Error: %v", definition.ID, err)
+			continue
+		}
+		rm.RegisterRoom(room)
+	}
+
+	return nil
+}
+
+// AddRoom creates the room the definition describes and saves it.
+func (rm *RoomManager) AddRoom(definition models.RoomDefinition) (*Room, error) {
+	if _, err := rm.GetRoom(definition.ID); err == nil {
+		return nil, ErrorRoomExists
+	}
+
+	definition.Status = string(RoomStatusActive)
+	room, err := rm.buildRoom(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rm.saveRoom(room.Definition); err != nil {
+		return nil, err
+	}
+
+	rm.RegisterRoom(room)
+	return room, nil
+}
+
+// UpdateRoom changes the room to the definition. The game type, the betting
+// structure, the stakes, the blind schedule and the seats can only change
+// while nobody is seated, the game is set up again then.
+func (rm *RoomManager) UpdateRoom(definition models.RoomDefinition) (*Room, error) {
+	room, err := rm.GetRoom(definition.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.SitAndGo != nil || room.Tournament != nil {
+		return nil, ErrorTournamentTable
+	}
+
+	if err := validateRoomDefinition(definition); err != nil {
+		return nil, err
+	}
+
+	current := room.Definition
+	definition.Status = string(room.GetStatus())
+	rebuild := definition.GameType != current.GameType ||
+		definition.BettingStructure != current.BettingStructure ||
+		definition.MinBet != current.MinBet ||
+		definition.MaxGamePlayers != current.MaxGamePlayers ||
+		definition.BlindSchedule != current.BlindSchedule ||
+		!slices.Equal(definition.BlindLevels, current.BlindLevels)
+
+	game := room.Game
+	if rebuild {
+		game.Mu.RLock()
+		seated := len(game.Players)
+		game.Mu.RUnlock()
+		if seated > 0 || room.IsGameActive() {
+			return nil, ErrorRoomInUse
+		}
+
+		game, err = rm.newGame(room, definition.MaxGamePlayers, definition.MinBet, GameType(definition.GameType), BettingStructureType(definition.BettingStructure))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyGameDefinition(game, definition); err != nil {
+		return nil, err
+	}
+
+	if game != room.Game && room.Game.BlindClock != nil {
+		room.Game.BlindClock.stop()
+	}
+
+	if room.isKept() {
+		if err := rm.saveRoom(definition); err != nil {
+			return nil, err
+		}
+	}
+
+	room.mu.Lock()
+	room.Name = definition.Name
+	room.MaxPlayers = definition.MaxPlayers
+	room.MinBet = definition.MinBet
+	room.Game = game
+	room.Definition = definition
+	room.mu.Unlock()
+
+	log.Printf("[ADMIN] Room updated - RoomID: %s, GameType: %d, MinBet: %d, MaxGamePlayers: %d", room.ID, definition.GameType, definition.MinBet, definition.MaxGamePlayers)
+	return room, nil
+}
+
+// PauseRoom lets the hand being played finish and deals no more until the
+// room is resumed. Players keep their seats.
+func (rm *RoomManager) PauseRoom(roomID string) (*Room, error) {
+	room, err := rm.setRoomStatus(roomID, RoomStatusPaused)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ADMIN] Room paused - RoomID: %s", roomID)
+	return room, nil
+}
+
+// ResumeRoom deals again at a paused room.
+func (rm *RoomManager) ResumeRoom(roomID string) (*Room, error) {
+	room, err := rm.setRoomStatus(roomID, RoomStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	room.Game.Mu.Lock()
+	if room.Game.Status == GameStatusWaiting && room.Game.Playable.CanStart() {
+		if err := room.Game.Start(); err != nil {
+			log.Printf("[ERROR] Failed to start game after resume - RoomID: %s, Error: %v", roomID, err)
+		}
+	}
+	room.Game.Mu.Unlock()
+
+	log.Printf("[ADMIN] Room resumed - RoomID: %s", roomID)
+	return room, nil
+}
+
+// CloseRoom takes no one in anymore, lets the hand being played finish, then
+// cashes out the players and takes the room down. A Sit & Go gives the buy-ins
+// back before it starts and can not be closed while it runs.
+func (rm *RoomManager) CloseRoom(roomID string) (*Room, error) {
+	if room, err := rm.GetRoom(roomID); err == nil {
+		if room.SitAndGo != nil {
+			if err := room.SitAndGo.Cancel(); err != nil {
+				return nil, err
+			}
+		}
+
+		// The tables of a running tournament are taken down as it breaks them
+		if room.Tournament != nil && room.Tournament.IsRunning() {
+			return nil, ErrorTournamentRunning
+		}
+	}
+
+	room, err := rm.setRoomStatus(roomID, RoomStatusClosed)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ADMIN] Room closing - RoomID: %s", roomID)
+	go rm.takeDown(room)
+
+	return room, nil
+}
+
+func (rm *RoomManager) takeDown(room *Room) {
+	for room.Game.Status == GameStatusStarting || room.Game.Status == GameStatusStarted {
+		time.Sleep(roomClosePollInterval)
+	}
+
+	room.Close()
+
+	rm.mu.Lock()
+	if rm.rooms[room.ID] == room {
+		delete(rm.rooms, room.ID)
+	}
+	rm.mu.Unlock()
+
+	log.Printf("[ADMIN] Room closed - RoomID: %s", room.ID)
+}
+
+func (rm *RoomManager) setRoomStatus(roomID string, status RoomStatus) (*Room, error) {
+	room, err := rm.GetRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.GetStatus() == RoomStatusClosed {
+		return nil, ErrorRoomClosed
+	}
+
+	if rm.Store != nil && room.isKept() {
+		if err := rm.Store.SetRoomStatus(roomID, string(status)); err != nil {
+			return nil, err
+		}
+	}
+
+	room.SetStatus(status)
+	room.Definition.Status = string(status)
+	return room, nil
+}
+
+// isKept reports whether the store keeps the room. The tables of a pool are
+// opened again from its template, private rooms and tournament tables last
+// until they close.
+func (r *Room) isKept() bool {
+	return r.PoolID == "" && r.Private == nil && r.SitAndGo == nil && r.Tournament == nil
+}
+
+// isListed reports whether the room is one of the cash tables every player
+// can find and be seated at.
+func (r *Room) isListed() bool {
+	return r.Private == nil && r.SitAndGo == nil && r.Tournament == nil
+}
+
+func (rm *RoomManager) saveRoom(definition models.RoomDefinition) error {
+	if rm.Store == nil {
+		return nil
+	}
+
+	return rm.Store.SaveRoom(definition)
+}
+
+// buildRoom sets up the room the definition describes without registering
+// it.
+func (rm *RoomManager) buildRoom(definition models.RoomDefinition) (*Room, error) {
+	if err := validateRoomDefinition(definition); err != nil {
+		return nil, err
+	}
+
+	room := NewRoom(definition.ID, definition.Name, definition.MaxPlayers, definition.MinBet, GameType(definition.GameType))
+	game, err := rm.newGame(room, definition.MaxGamePlayers, definition.MinBet, GameType(definition.GameType), BettingStructureType(definition.BettingStructure))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyGameDefinition(game, definition); err != nil {
+		return nil, err
+	}
+
+	if definition.Status == "" {
+		definition.Status = string(RoomStatusActive)
+	}
+
+	room.Game = game
+	room.Definition = definition
+	room.SetStatus(RoomStatus(definition.Status))
+	return room, nil
+}
+
+// applyGameDefinition sets the timers, the buy-in limits and the blind
+// schedule of the game. A game keeps the clock of its schedule once set.
+func applyGameDefinition(game *Game, definition models.RoomDefinition) error {
+	levels, err := roomBlindSchedule(definition)
+	if err != nil {
+		return err
+	}
+
+	limits := DefaultBuyInLimits(definition.MinBet)
+	if definition.MinBuyIn > 0 {
+		limits.Min = definition.MinBuyIn
+	}
+	if definition.MaxBuyIn > 0 {
+		limits.Max = definition.MaxBuyIn
+	}
+
+	if limits.Min < definition.MinBet || limits.Min > limits.Max {
+		return ErrorGameInvalidBuyIn
+	}
+
+	timer := DefaultActionTimerSettings
+	if definition.ActionTimeoutSeconds > 0 {
+		timer.ActionTimeout = time.Duration(definition.ActionTimeoutSeconds) * time.Second
+	}
+	if definition.TimeBankSeconds > 0 {
+		timer.TimeBank = time.Duration(definition.TimeBankSeconds) * time.Second
+	}
+
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+
+	game.BuyIn = limits
+	game.ActionTimer = timer
+	if len(levels) > 0 && game.BlindClock == nil {
+		game.BlindClock = newBlindClock(levels, nil)
+	}
+	return nil
+}
+
+func validateRoomDefinition(definition models.RoomDefinition) error {
+	if definition.ID == "" || definition.Name == "" || definition.MinBet <= 0 ||
+		definition.MaxGamePlayers < 2 || definition.MaxPlayers < definition.MaxGamePlayers {
+		return ErrorRoomInvalid
+	}
+
+	if _, err := roomBlindSchedule(definition); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -9,26 +9,36 @@ import (
 )
 
 type RoomManager struct {
-	rooms map[string]*Room
-	mu    sync.RWMutex
+	rooms  map[string]*Room
+	mu     sync.RWMutex
+	Wallet IChipWallet // Reserves the chips players take to the rooms' tables
+	Store  IRoomStore  // Keeps the room definitions, nil keeps them in memory
+
+	pools   map[string]*roomPool
+	poolsMu sync.Mutex
+
+	tournaments   map[string]*Tournament
+	tournamentsMu sync.Mutex
 }
 
-func NewRoomManager() *RoomManager {
+// NewRoomManager returns a room manager whose tables move chips through the
+// wallet. Every buy-in, top-up, cash-out and prize is recorded by it.
+func NewRoomManager(wallet IChipWallet) *RoomManager {
 	return &RoomManager{
-		rooms: make(map[string]*Room),
+		Wallet:      wallet,
+		rooms:       make(map[string]*Room),
+		pools:       make(map[string]*roomPool),
+		tournaments: make(map[string]*Tournament),
 	}
 }
 
-func (rm *RoomManager) CreateRoom(id, name string, maxPlayers, maxGamePlayers, minBet int, gameType GameType) (*Room, error) {
+func (rm *RoomManager) CreateRoom(id, name string, maxPlayers, maxGamePlayers, minBet int, gameType GameType, betting BettingStructureType) (*Room, error) {
 	room := NewRoom(id, name, maxPlayers, minBet, gameType)
-
-	switch gameType {
-	case GameTypeHoldem:
-		room.Game = NewGame(room.ActionChannel, room.MessageChannel, room, maxGamePlayers, minBet, gameType)
-		room.Game.Playable = NewHoldem(room.Game)
-	default:
-		return nil, fmt.Errorf("unsupported game type: %d", gameType)
+	game, err := rm.newGame(room, maxGamePlayers, minBet, gameType, betting)
+	if err != nil {
+		return nil, err
 	}
+	room.Game = game
 
 	rm.mu.Lock()
 	rm.rooms[room.ID] = room
@@ -39,6 +49,35 @@ func (rm *RoomManager) CreateRoom(id, name string, maxPlayers, maxGamePlayers, m
 	return room, nil
 }
 
+// newGame builds the game played in the room.
+func (rm *RoomManager) newGame(room *Room, maxGamePlayers, minBet int, gameType GameType, betting BettingStructureType) (*Game, error) {
+	var game *Game
+	switch gameType {
+	case GameTypeHoldem:
+		game = NewGame(room.ActionChannel, room.MessageChannel, room, maxGamePlayers, minBet, gameType)
+		holdem := NewHoldem(game)
+		if err := holdem.SetBettingStructure(betting); err != nil {
+			return nil, err
+		}
+		game.Playable = holdem
+	case GameTypeOmaha:
+		if maxGamePlayers > MaxOmahaPlayers {
+			return nil, fmt.Errorf("omaha tables seat at most %d players", MaxOmahaPlayers)
+		}
+		game = NewGame(room.ActionChannel, room.MessageChannel, room, maxGamePlayers, minBet, gameType)
+		omaha := NewOmaha(game)
+		if err := omaha.SetBettingStructure(betting); err != nil {
+			return nil, err
+		}
+		game.Playable = omaha
+	default:
+		return nil, fmt.Errorf("unsupported game type: %d", gameType)
+	}
+
+	game.Wallet = rm.Wallet
+	return game, nil
+}
+
 func (rm *RoomManager) RegisterRoom(room *Room) {
 	if room == nil {
 		log.Printf("[ERROR] Attempted to register nil room")
@@ -58,7 +97,7 @@ func (rm *RoomManager) GetRoom(roomID string) (*Room, error) {
 
 	room, exists := rm.rooms[roomID]
 	if !exists {
-		return nil, errors.New("room not found")
+		return nil, ErrorRoomNotFound
 	}
 
 	return room, nil
@@ -70,7 +109,7 @@ func (rm *RoomManager) GetRoomsByGameType(gameType GameType) []*RoomSummary {
 
 	rooms := make([]*RoomSummary, 0, len(rm.rooms))
 	for _, room := range rm.rooms {
-		if room.Game.GameType == gameType {
+		if room.Game.GameType == gameType && room.isListed() {
 			rooms = append(rooms, room.GetRoomSummary())
 		}
 	}
@@ -78,19 +117,32 @@ func (rm *RoomManager) GetRoomsByGameType(gameType GameType) []*RoomSummary {
 	return rooms
 }
 
-func (rm *RoomManager) GetRoomByPlayerID(playerID string) (*Room, error) {
+// GetRoomsByPlayerID returns the rooms the player is in, a player can be in
+// several at once.
+func (rm *RoomManager) GetRoomsByPlayerID(playerID string) []*Room {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
 
+	rooms := make([]*Room, 0)
 	for _, room := range rm.rooms {
-		for _, player := range room.Players {
-			if player.User.Player.ID == playerID {
-				return room, nil
-			}
+		if room.HasPlayer(playerID) {
+			rooms = append(rooms, room)
+		}
+	}
+
+	return rooms
+}
+
+// CountTablesByPlayerID returns how many games the player has a seat in.
+func (rm *RoomManager) CountTablesByPlayerID(playerID string) int {
+	count := 0
+	for _, room := range rm.GetRoomsByPlayerID(playerID) {
+		if room.Game != nil && room.Game.IsSeated(playerID) {
+			count++
 		}
 	}
 
-	return nil, errors.New("room not found")
+	return count
 }
 
 func (rm *RoomManager) JoinRoom(roomID string, player *Client) error {
@@ -0,0 +1,278 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+var ErrorPoolInvalid = errors.New("pool needs an id, a valid table template and room for its open tables")
+
+// DefaultPoolIdleTimeout is how long a pool keeps an empty table it does not
+// need open.
+const DefaultPoolIdleTimeout = 5 * time.Minute
+
+// PoolScaleInterval is how often the pools open and take down tables.
+const PoolScaleInterval = 2 * time.Second
+
+// DefaultRoomPools are the stakes the server keeps tables open for.
+var DefaultRoomPools = []models.RoomPool{
+	{
+		ID: "nlh_10_20",
+		Template: models.RoomDefinition{
+			Name:             "NL Hold'em 10/20",
+			GameType:         int(GameTypeHoldem),
+			BettingStructure: string(BettingNoLimit),
+			MinBet:           20,
+			MaxPlayers:       50,
+			MaxGamePlayers:   6,
+		},
+		MinOpenTables: 1,
+		MaxTables:     50,
+	},
+	{
+		ID: "plo_10_20",
+		Template: models.RoomDefinition{
+			Name:                 "PL Omaha 10/20",
+			GameType:             int(GameTypeOmaha),
+			BettingStructure:     string(BettingPotLimit),
+			MinBet:               20,
+			MaxPlayers:           50,
+			MaxGamePlayers:       6,
+			ActionTimeoutSeconds: 15,
+		},
+		MinOpenTables: 1,
+		MaxTables:     20,
+	},
+}
+
+type roomPool struct {
+	policy    models.RoomPool
+	idleSince map[string]time.Time
+	next      int
+}
+
+// StakeSummary lists the tables of one stake and game type.
+type StakeSummary struct {
+	GameType         GameType       `json:"game_type"`
+	BettingStructure string         `json:"betting_structure"`
+	MinBet           int            `json:"min_bet"`
+	Tables           int            `json:"tables"`
+	OpenTables       int            `json:"open_tables"`
+	Players          int            `json:"players"`
+	Rooms            []*RoomSummary `json:"rooms"`
+}
+
+// SetPool adds the pool or replaces its policy. The tables the pool already
+// opened are kept.
+func (rm *RoomManager) SetPool(policy models.RoomPool) error {
+	template := policy.Template
+	template.ID = policy.ID
+	if policy.ID == "" || policy.MinOpenTables < 0 || policy.MaxTables <= 0 ||
+		policy.MinOpenTables > policy.MaxTables || validateRoomDefinition(template) != nil {
+		return ErrorPoolInvalid
+	}
+
+	rm.poolsMu.Lock()
+	defer rm.poolsMu.Unlock()
+
+	if pool, ok := rm.pools[policy.ID]; ok {
+		pool.policy = policy
+	} else {
+		rm.pools[policy.ID] = &roomPool{
+			policy:    policy,
+			idleSince: make(map[string]time.Time),
+		}
+	}
+
+	log.Printf("[INFO] Pool set - PoolID: %s, MinBet: %d, MinOpenTables: %d, MaxTables: %d", policy.ID, policy.Template.MinBet, policy.MinOpenTables, policy.MaxTables)
+	return nil
+}
+
+// RunPools opens and takes down the pools' tables until the server stops.
+func (rm *RoomManager) RunPools() {
+	ticker := time.NewTicker(PoolScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		rm.ScalePools()
+		<-ticker.C
+	}
+}
+
+// ScalePools opens tables for the pools short of tables with an empty seat
+// and takes down the empty tables they do not need once idle.
+func (rm *RoomManager) ScalePools() {
+	rm.poolsMu.Lock()
+	defer rm.poolsMu.Unlock()
+
+	for _, pool := range rm.pools {
+		rm.scalePool(pool)
+	}
+}
+
+func (rm *RoomManager) scalePool(pool *roomPool) {
+	now := time.Now()
+	idleTimeout := DefaultPoolIdleTimeout
+	if pool.policy.IdleTimeoutSeconds > 0 {
+		idleTimeout = time.Duration(pool.policy.IdleTimeoutSeconds) * time.Second
+	}
+
+	rooms := rm.getPoolRooms(pool.policy.ID)
+	open := 0
+	idle := make([]*Room, 0)
+	for _, room := range rooms {
+		if room.HasOpenSeat() {
+			open++
+		}
+
+		if !room.IsEmpty() {
+			delete(pool.idleSince, room.ID)
+			continue
+		}
+
+		if _, ok := pool.idleSince[room.ID]; !ok {
+			pool.idleSince[room.ID] = now
+		}
+		if now.Sub(pool.idleSince[room.ID]) >= idleTimeout {
+			idle = append(idle, room)
+		}
+	}
+
+	for id := range pool.idleSince {
+		if !containsRoom(rooms, id) {
+			delete(pool.idleSince, id)
+		}
+	}
+
+	tables := len(rooms)
+	for ; open < pool.policy.MinOpenTables && tables < pool.policy.MaxTables; open, tables = open+1, tables+1 {
+		if _, err := rm.openPoolRoom(pool); err != nil {
+			log.Printf("[ERROR] Failed to open pool table - PoolID: %s, Error: %v", pool.policy.ID, err)
+			return
+		}
+	}
+
+	for _, room := range idle {
+		if open <= pool.policy.MinOpenTables && tables <= pool.policy.MaxTables {
+			break
+		}
+
+		// A player may have come in since the room was found empty
+		wasOpen := room.HasOpenSeat()
+		room.SetStatus(RoomStatusClosed)
+		if !room.IsEmpty() {
+			room.SetStatus(RoomStatusActive)
+			continue
+		}
+
+		rm.takeDown(room)
+		delete(pool.idleSince, room.ID)
+		if wasOpen {
+			open--
+		}
+		tables--
+	}
+}
+
+func (rm *RoomManager) openPoolRoom(pool *roomPool) (*Room, error) {
+	definition := pool.policy.Template
+	for {
+		pool.next++
+		definition.ID = fmt.Sprintf("%s_%d", pool.policy.ID, pool.next)
+		if _, err := rm.GetRoom(definition.ID); err != nil {
+			break
+		}
+	}
+	definition.Name = fmt.Sprintf("%s #%d", pool.policy.Template.Name, pool.next)
+	definition.Status = string(RoomStatusActive)
+
+	room, err := rm.buildRoom(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	room.PoolID = pool.policy.ID
+	rm.RegisterRoom(room)
+	return room, nil
+}
+
+func (rm *RoomManager) getPoolRooms(poolID string) []*Room {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	rooms := make([]*Room, 0)
+	for _, room := range rm.rooms {
+		if room.PoolID == poolID {
+			rooms = append(rooms, room)
+		}
+	}
+
+	return rooms
+}
+
+// GetRoomsByStake groups the rooms of the game type by stake, every game type
+// when gameType is zero.
+func (rm *RoomManager) GetRoomsByStake(gameType GameType) []*StakeSummary {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	stakes := make(map[string]*StakeSummary)
+	for _, room := range rm.rooms {
+		if !room.isListed() || (gameType != 0 && room.Game.GameType != gameType) {
+			continue
+		}
+
+		key := fmt.Sprintf("%d/%s/%d", room.Game.GameType, room.Definition.BettingStructure, room.MinBet)
+		stake, ok := stakes[key]
+		if !ok {
+			stake = &StakeSummary{
+				GameType:         room.Game.GameType,
+				BettingStructure: room.Definition.BettingStructure,
+				MinBet:           room.MinBet,
+				Rooms:            make([]*RoomSummary, 0),
+			}
+			stakes[key] = stake
+		}
+
+		summary := room.GetRoomSummary()
+		stake.Tables++
+		stake.Players += summary.PlayersInGame
+		if room.HasOpenSeat() {
+			stake.OpenTables++
+		}
+		stake.Rooms = append(stake.Rooms, summary)
+	}
+
+	summaries := make([]*StakeSummary, 0, len(stakes))
+	for _, stake := range stakes {
+		sort.Slice(stake.Rooms, func(i, j int) bool { return stake.Rooms[i].Id < stake.Rooms[j].Id })
+		summaries = append(summaries, stake)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].GameType != summaries[j].GameType {
+			return summaries[i].GameType < summaries[j].GameType
+		}
+		if summaries[i].MinBet != summaries[j].MinBet {
+			return summaries[i].MinBet < summaries[j].MinBet
+		}
+		return summaries[i].BettingStructure < summaries[j].BettingStructure
+	})
+
+	return summaries
+}
+
+func containsRoom(rooms []*Room, roomID string) bool {
+	for _, room := range rooms {
+		if room.ID == roomID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,420 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrorRoomAccessDenied = errors.New("wrong join code or password")
+	ErrorRoomKicked       = errors.New("player was kicked from this room")
+	ErrorRoomNotHost      = errors.New("only the host can do this")
+	ErrorRoomHostLimit    = errors.New("player hosts too many private rooms")
+	ErrorRoomKickHost     = errors.New("host can not be kicked")
+)
+
+// PrivateRoomIdleTimeout is how long a private room stays open with no one
+// in it.
+const PrivateRoomIdleTimeout = 10 * time.Minute
+
+// PrivateRoomCheckInterval is how often the empty private rooms are looked
+// for.
+const PrivateRoomCheckInterval = 30 * time.Second
+
+// MaxPrivateRoomsPerHost is how many private rooms a player can host at the
+// same time.
+const MaxPrivateRoomsPerHost = 3
+
+// Join codes leave out the letters and digits that read alike.
+const (
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 6
+)
+
+// PrivateRoom is who can get into a private room. The host lets players in
+// by sharing the join code or the password.
+type PrivateRoom struct {
+	HostID   string
+	JoinCode string
+
+	passwordHash []byte // nil when the room has no password
+	admitted     map[string]bool
+	kicked       map[string]bool
+	emptySince   time.Time
+}
+
+// CreatePrivateRoom opens a private room hosted by the player. Private rooms
+// are not listed and are not kept across restarts.
+func (rm *RoomManager) CreatePrivateRoom(hostID string, msg models.MessagePrivateCreate) (*Room, error) {
+	if rm.countHostedRooms(hostID) >= MaxPrivateRoomsPerHost {
+		return nil, ErrorRoomHostLimit
+	}
+
+	definition := models.RoomDefinition{
+		ID:               "private_" + uuid.New().String(),
+		Name:             msg.Name,
+		GameType:         msg.GameType,
+		BettingStructure: msg.BettingStructure,
+		MinBet:           msg.MinBet,
+		MaxPlayers:       msg.MaxGamePlayers * 2, // Room for friends waiting for a seat
+		MaxGamePlayers:   msg.MaxGamePlayers,
+		BlindSchedule:    msg.BlindSchedule,
+	}
+
+	room, err := rm.buildRoom(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := rm.newJoinCode()
+	if err != nil {
+		return nil, err
+	}
+
+	room.Private = &PrivateRoom{
+		HostID:   hostID,
+		JoinCode: code,
+		admitted: map[string]bool{hostID: true},
+		kicked:   make(map[string]bool),
+	}
+	if msg.Password != "" {
+		hash := sha256.Sum256([]byte(msg.Password))
+		room.Private.passwordHash = hash[:]
+	}
+
+	rm.RegisterRoom(room)
+	log.Printf("[INFO] Private room created - RoomID: %s, HostID: %s", room.ID, hostID)
+
+	return room, nil
+}
+
+// GetRoomByJoinCode finds the private room the code lets into.
+func (rm *RoomManager) GetRoomByJoinCode(code string) (*Room, error) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	for _, room := range rm.rooms {
+		if room.Private != nil && room.Private.JoinCode == code {
+			return room, nil
+		}
+	}
+
+	return nil, ErrorRoomNotFound
+}
+
+// RunPrivateRooms closes the private rooms left empty, until the server
+// stops.
+func (rm *RoomManager) RunPrivateRooms() {
+	ticker := time.NewTicker(PrivateRoomCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rm.closeIdlePrivateRooms()
+	}
+}
+
+// RunPrivateRooms closes the private rooms no one used for a while.
+func (s *Server) RunPrivateRooms() {
+	s.roomManager.RunPrivateRooms()
+}
+
+func (rm *RoomManager) closeIdlePrivateRooms() {
+	rm.mu.RLock()
+	rooms := make([]*Room, 0)
+	for _, room := range rm.rooms {
+		if room.Private != nil {
+			rooms = append(rooms, room)
+		}
+	}
+	rm.mu.RUnlock()
+
+	now := time.Now()
+	for _, room := range rooms {
+		empty := room.IsEmpty()
+
+		room.mu.Lock()
+		if !empty {
+			room.Private.emptySince = time.Time{}
+		} else if room.Private.emptySince.IsZero() {
+			room.Private.emptySince = now
+		}
+		idle := empty && now.Sub(room.Private.emptySince) >= PrivateRoomIdleTimeout
+		room.mu.Unlock()
+
+		if !idle {
+			continue
+		}
+
+		if _, err := rm.CloseRoom(room.ID); err != nil && !errors.Is(err, ErrorRoomClosed) {
+			log.Printf("[ERROR] Failed to close idle private room - RoomID: %s, Error: %v", room.ID, err)
+		}
+	}
+}
+
+func (rm *RoomManager) countHostedRooms(hostID string) int {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	count := 0
+	for _, room := range rm.rooms {
+		if room.Private != nil && room.Private.HostID == hostID && room.GetStatus() != RoomStatusClosed {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (rm *RoomManager) newJoinCode() (string, error) {
+	for {
+		code := make([]byte, joinCodeLength)
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(joinCodeAlphabet))))
+			if err != nil {
+				return "", err
+			}
+			code[i] = joinCodeAlphabet[n.Int64()]
+		}
+
+		if _, err := rm.GetRoomByJoinCode(string(code)); err != nil {
+			return string(code), nil
+		}
+	}
+}
+
+// Admit lets the player into a private room when the join code or the
+// password is right. Players in public rooms need neither.
+func (r *Room) Admit(playerID, code, password string) error {
+	if r.Private == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Private.kicked[playerID] {
+		return ErrorRoomKicked
+	}
+
+	if r.isAdmitted(playerID) {
+		return nil
+	}
+
+	codeOk := code != "" && subtle.ConstantTimeCompare([]byte(code), []byte(r.Private.JoinCode)) == 1
+	passwordOk := false
+	if r.Private.passwordHash != nil && password != "" {
+		hash := sha256.Sum256([]byte(password))
+		passwordOk = subtle.ConstantTimeCompare(hash[:], r.Private.passwordHash) == 1
+	}
+
+	if !codeOk && !passwordOk {
+		return ErrorRoomAccessDenied
+	}
+
+	r.Private.admitted[playerID] = true
+	return nil
+}
+
+// IsAdmitted reports whether the player can see and get into the room.
+func (r *Room) IsAdmitted(playerID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.isAdmitted(playerID)
+}
+
+// isAdmitted is IsAdmitted for callers holding mu.
+func (r *Room) isAdmitted(playerID string) bool {
+	return r.Private == nil || r.Private.admitted[playerID]
+}
+
+// IsHost reports whether the player hosts the private room.
+func (r *Room) IsHost(playerID string) bool {
+	return r.Private != nil && r.Private.HostID == playerID
+}
+
+// Kick takes the player out of the private room for good. Their stack is
+// cashed out once the hand being played is over. It returns the connection
+// the player was in the room with, nil when they were not in it.
+func (r *Room) Kick(playerID string) (*Client, error) {
+	if r.Private == nil {
+		return nil, ErrorRoomNotHost
+	}
+
+	if r.IsHost(playerID) {
+		return nil, ErrorRoomKickHost
+	}
+
+	r.mu.Lock()
+	r.Private.kicked[playerID] = true
+	delete(r.Private.admitted, playerID)
+	client := r.Players[playerID]
+	if client == nil {
+		client = r.Spectators[playerID]
+	}
+	r.mu.Unlock()
+
+	r.RemovePlayer(playerID)
+	r.RemoveSpectator(playerID)
+
+	log.Printf("[INFO] Player kicked from private room - RoomID: %s, PlayerID: %s", r.ID, playerID)
+	return client, nil
+}
+
+// SetBlinds changes the blinds and the ante of the room from the next hand
+// on. The buy-in limits follow the big blind. Rooms playing a blind schedule
+// keep to it.
+func (r *Room) SetBlinds(smallBlind, bigBlind, ante int) error {
+	if smallBlind == 0 {
+		smallBlind = bigBlind / 2
+	}
+
+	r.Game.Mu.Lock()
+	defer r.Game.Mu.Unlock()
+
+	if r.Game.BlindClock != nil {
+		return ErrorBlindScheduleRunning
+	}
+
+	if err := r.Game.Playable.SetBlinds(smallBlind, bigBlind, ante); err != nil {
+		return err
+	}
+
+	r.MinBet = bigBlind
+	r.Definition.MinBet = bigBlind
+	r.Game.BuyIn = DefaultBuyInLimits(bigBlind)
+	return nil
+}
+
+func (h *MessageHandler) handlePrivateCreate(client *Client, msg models.MessagePrivateCreate) error {
+	room, err := h.roomManager.CreatePrivateRoom(client.User.Player.ID, msg)
+	if err != nil {
+		return h.sendError(client, "", fmt.Sprintf("Failed to create private room: %v", err))
+	}
+
+	if err := h.server.JoinRoom(room.ID, client); err != nil {
+		return h.sendError(client, room.ID, fmt.Sprintf("Failed to join room: %v", err))
+	}
+
+	client.Broadcast(models.Response{
+		Type:   models.MessageTypePrivateCreateOk,
+		RoomID: room.ID,
+		Data: models.MessagePrivateCreateResponse{
+			RoomID:   room.ID,
+			JoinCode: room.Private.JoinCode,
+			State:    room.GetRoomStateForPlayer(client.User.Player.ID),
+		},
+		Timestamp: time.Now().UTC(),
+	})
+
+	return nil
+}
+
+func (h *MessageHandler) handlePrivateKick(client *Client, msg models.MessagePrivateHost) error {
+	room, err := h.hostedRoom(client, msg.RoomID)
+	if err != nil {
+		return h.sendError(client, msg.RoomID, err.Error())
+	}
+
+	kicked, err := room.Kick(msg.PlayerID)
+	if err != nil {
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to kick player: %v", err))
+	}
+
+	response := models.Response{
+		Type: models.MessageTypePrivateKick,
+		Data: models.MessagePrivateHostResponse{
+			RoomID:   room.ID,
+			PlayerID: msg.PlayerID,
+			State:    room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	if kicked != nil {
+		kicked.Broadcast(models.Response{
+			Type:      models.MessageTypePrivateKicked,
+			RoomID:    room.ID,
+			Data:      room.ID,
+			Timestamp: time.Now().UTC(),
+		})
+	}
+
+	return room.BroadcastToRoom(response)
+}
+
+func (h *MessageHandler) handlePrivatePause(client *Client, msg models.MessagePrivateHost) error {
+	return h.handlePrivateStatus(client, msg, models.MessageTypePrivatePause, h.roomManager.PauseRoom)
+}
+
+func (h *MessageHandler) handlePrivateResume(client *Client, msg models.MessagePrivateHost) error {
+	return h.handlePrivateStatus(client, msg, models.MessageTypePrivateResume, h.roomManager.ResumeRoom)
+}
+
+func (h *MessageHandler) handlePrivateStatus(client *Client, msg models.MessagePrivateHost, messageType models.MessageType, change func(roomID string) (*Room, error)) error {
+	room, err := h.hostedRoom(client, msg.RoomID)
+	if err != nil {
+		return h.sendError(client, msg.RoomID, err.Error())
+	}
+
+	if _, err := change(room.ID); err != nil {
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to change room: %v", err))
+	}
+
+	return room.BroadcastToRoom(models.Response{
+		Type: messageType,
+		Data: models.MessagePrivateHostResponse{
+			RoomID: room.ID,
+			State:  room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func (h *MessageHandler) handlePrivateBlinds(client *Client, msg models.MessagePrivateHost) error {
+	room, err := h.hostedRoom(client, msg.RoomID)
+	if err != nil {
+		return h.sendError(client, msg.RoomID, err.Error())
+	}
+
+	if err := room.SetBlinds(msg.SmallBlind, msg.BigBlind, msg.Ante); err != nil {
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to change blinds: %v", err))
+	}
+
+	log.Printf("[INFO] Private room blinds changed - RoomID: %s, SmallBlind: %d, BigBlind: %d, Ante: %d", room.ID, msg.SmallBlind, msg.BigBlind, msg.Ante)
+
+	return room.BroadcastToRoom(models.Response{
+		Type: models.MessageTypePrivateBlinds,
+		Data: models.MessagePrivateHostResponse{
+			RoomID:     room.ID,
+			SmallBlind: msg.SmallBlind,
+			BigBlind:   msg.BigBlind,
+			Ante:       msg.Ante,
+			State:      room.GetRoomState(),
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// hostedRoom returns the private room the client hosts.
+func (h *MessageHandler) hostedRoom(client *Client, roomID string) (*Room, error) {
+	room := h.server.GetRoom(roomID)
+	if room == nil || !room.IsAdmitted(client.User.Player.ID) {
+		return nil, errors.New("Room not found")
+	}
+
+	if !room.IsHost(client.User.Player.ID) {
+		return nil, ErrorRoomNotHost
+	}
+
+	return room, nil
+}
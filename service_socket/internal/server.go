@@ -15,6 +15,10 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// DefaultMaxTablesPerPlayer is how many games a player can sit in at the same
+// time.
+const DefaultMaxTablesPerPlayer = 4
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -33,12 +37,26 @@ type Server struct {
 	handler        *MessageHandler
 	IdlePlayerTime time.Duration
 	ApiService     *api.ApiService
+
+	// ReconnectGracePeriod is how long a disconnected player keeps their
+	// seat. Zero removes players as soon as they disconnect.
+	ReconnectGracePeriod time.Duration
+	heldSeats            map[string]*heldSeat
+
+	// MaxTablesPerPlayer is how many games a player can sit in at the same
+	// time. Zero lifts the limit.
+	MaxTablesPerPlayer int
 }
 
 func NewServer() *Server {
 	apiService := api.NewApiService()
-	roomManager := NewRoomManager()
-	roomManager.CreateRoom("room_1", "Default Room", 100, 5, 10, GameTypeHoldem)
+	roomManager := NewRoomManager(apiService.ReservationService)
+	roomManager.Store = apiService.RoomService
+	for _, pool := range DefaultRoomPools {
+		if err := roomManager.SetPool(pool); err != nil {
+			log.Printf("[ERROR] Failed to set pool - PoolID: %s, Error: %v", pool.ID, err)
+		}
+	}
 
 	server := &Server{
 		clients:        make(map[string]*Client),
@@ -48,6 +66,10 @@ func NewServer() *Server {
 		unregister:     make(chan *Client),
 		IdlePlayerTime: 600 * time.Second,
 		ApiService:     apiService,
+
+		ReconnectGracePeriod: DefaultReconnectGracePeriod,
+		heldSeats:            make(map[string]*heldSeat),
+		MaxTablesPerPlayer:   DefaultMaxTablesPerPlayer,
 	}
 
 	server.handler = NewMessageHandler(server, roomManager)
@@ -64,15 +86,22 @@ func (s *Server) Run() {
 			s.mu.Unlock()
 
 		case client := <-s.unregister:
-			if _, ok := s.clients[client.User.Player.ID]; ok {
-				s.mu.Lock()
-				if client.CurrentRoom != nil {
-					client.CurrentRoom.RemovePlayer(client.User.Player.ID)
+			s.mu.Lock()
+			playerID := client.User.Player.ID
+			if s.clients[playerID] == client {
+				if held, ok := s.heldSeats[playerID]; !ok || held.client != client {
+					for _, room := range client.Rooms() {
+						if room.HasClient(client) {
+							room.RemovePlayer(playerID)
+						}
+					}
 				}
-				delete(s.clients, client.User.Player.ID)
-				close(client.send)
-				s.mu.Unlock()
+				delete(s.clients, playerID)
 			}
+			// A client replaced by a reconnect is closed too
+			s.handler.dropQuickSeats(client)
+			client.closeSend()
+			s.mu.Unlock()
 
 		case message := <-s.broadcast:
 			s.mu.RLock()
@@ -80,7 +109,7 @@ func (s *Server) Run() {
 				select {
 				case client.send <- message:
 				default:
-					close(client.send)
+					client.closeSend()
 					delete(s.clients, client.User.Player.ID)
 				}
 			}
@@ -126,25 +155,39 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		mu:             sync.Mutex{},
 		Server:         s,
 		send:           make(chan []byte, 256),
+		done:           make(chan struct{}),
 	}
 	client.Touch()
 	s.register <- client
 
 	go client.writePump()
 	go client.readPump()
+
+	s.ResumeSession(client)
 }
 
+// HandleRoomList lists the rooms grouped by stake, those of one game type
+// when game_type is given.
 func (s *Server) HandleRoomList(w http.ResponseWriter, r *http.Request) {
-	gameType := r.URL.Query().Get("game_type")
-	gameTypeInt, err := strconv.Atoi(gameType)
-
-	if err != nil {
-		http.Error(w, "Invalid game type", http.StatusBadRequest)
-		return
+	gameTypeInt := 0
+	if gameType := r.URL.Query().Get("game_type"); gameType != "" {
+		var err error
+		gameTypeInt, err = strconv.Atoi(gameType)
+		if err != nil {
+			http.Error(w, "Invalid game type", http.StatusBadRequest)
+			return
+		}
 	}
 
-	rooms := s.roomManager.GetRoomsByGameType(GameType(gameTypeInt))
-	json.NewEncoder(w).Encode(rooms)
+	stakes := s.roomManager.GetRoomsByStake(GameType(gameTypeInt))
+	json.NewEncoder(w).Encode(stakes)
+}
+
+// HandleTournamentList lists the Sit & Go tables open for registration or
+// being played, and the multi-table tournaments.
+func (s *Server) HandleTournamentList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.roomManager.GetTournamentLobby())
 }
 
 func (s *Server) GetRoom(roomID string) *Room {
@@ -166,11 +209,11 @@ func (s *Server) JoinRoom(roomID string, client *Client) error {
 		return err
 	}
 
-	room.AddPlayer(client)
-
-	return nil
+	return room.AddPlayer(client)
 }
 
+// BroadcastToRoom sends the message to the players in the room. Spectators
+// get room messages through the room's delayed feed instead.
 func (s *Server) BroadcastToRoom(roomID string, message []byte) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -184,11 +227,12 @@ func (s *Server) BroadcastToRoom(roomID string, message []byte) {
 			select {
 			case client.send <- message:
 			default:
-				close(client.send)
+				client.closeSend()
 				delete(s.clients, client.User.Player.ID)
 			}
 		}
 	}
+
 }
 
 func (s *Server) BroadcastToAll(message []byte) {
@@ -198,7 +242,7 @@ func (s *Server) BroadcastToAll(message []byte) {
 		select {
 		case client.send <- message:
 		default:
-			close(client.send)
+			client.closeSend()
 			delete(s.clients, client.User.Player.ID)
 		}
 	}
@@ -216,7 +260,7 @@ func (s *Server) BroadcastToGame(roomID string, message []byte) {
 
 	for _, gamePlayer := range game.Players {
 		if client, ok := s.clients[gamePlayer.Client.User.Player.ID]; ok {
-			client.send <- message
+			client.Send(message)
 		}
 	}
 }
@@ -267,9 +311,13 @@ func (s *Server) HandleReset(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Clear server's client map
+	// Clear server's client map and the seats kept for reconnects
 	s.mu.Lock()
 	s.clients = make(map[string]*Client)
+	for _, held := range s.heldSeats {
+		held.timer.Stop()
+	}
+	s.heldSeats = make(map[string]*heldSeat)
 	s.mu.Unlock()
 
 	log.Printf("[ADMIN] Server reset complete")
@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/internal/config"
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+// LoadRooms creates the rooms kept by the API, or the default rooms the first
+// time the server runs, and opens the default Sit & Gos.
+func (s *Server) LoadRooms() error {
+	if err := s.roomManager.LoadRooms(); err != nil {
+		return err
+	}
+
+	for _, definition := range DefaultSitAndGos {
+		if _, err := s.roomManager.OpenSitAndGo(definition); err != nil {
+			log.Printf("[ERROR] Failed to open sit and go - ID: %s, Error: %v", definition.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ReleaseStaleReservations gives the players back the chips an earlier run of
+// this server left reserved when it stopped without settling them, retrying
+// until the wallet answers. Run it when the server starts.
+func (s *Server) ReleaseStaleReservations() {
+	for attempt := 1; ; attempt++ {
+		released, err := s.ApiService.ReservationService.ReleaseStale()
+		if err == nil {
+			log.Printf("[INFO] Released stale chip reservations - Count: %d", released)
+			return
+		}
+
+		log.Printf("[ERROR] Failed to release stale chip reservations - Attempt: %d, Error: %v", attempt, err)
+		time.Sleep(walletBackoff(attempt))
+	}
+}
+
+// RunPools keeps the pools' tables open as players come and go.
+func (s *Server) RunPools() {
+	s.roomManager.RunPools()
+}
+
+// HandleCreateRoom opens a new room from the definition in the body.
+func (s *Server) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var definition models.RoomDefinition
+	if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+		http.Error(w, "Invalid room definition", http.StatusBadRequest)
+		return
+	}
+
+	room, err := s.roomManager.AddRoom(definition)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	log.Printf("[ADMIN] Room created - RoomID: %s, Name: %s", room.ID, room.Name)
+	writeAdminRoom(w, http.StatusCreated, room)
+}
+
+// HandleUpdateRoom replaces the definition of the room.
+func (s *Server) HandleUpdateRoom(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var definition models.RoomDefinition
+	if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+		http.Error(w, "Invalid room definition", http.StatusBadRequest)
+		return
+	}
+
+	definition.ID = r.PathValue("id")
+	room, err := s.roomManager.UpdateRoom(definition)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	writeAdminRoom(w, http.StatusOK, room)
+}
+
+func (s *Server) HandlePauseRoom(w http.ResponseWriter, r *http.Request) {
+	s.handleRoomStatus(w, r, s.roomManager.PauseRoom)
+}
+
+func (s *Server) HandleResumeRoom(w http.ResponseWriter, r *http.Request) {
+	s.handleRoomStatus(w, r, s.roomManager.ResumeRoom)
+}
+
+// HandleCloseRoom closes the room once the hand being played is over. The
+// players are cashed out.
+func (s *Server) HandleCloseRoom(w http.ResponseWriter, r *http.Request) {
+	s.handleRoomStatus(w, r, s.roomManager.CloseRoom)
+}
+
+func (s *Server) handleRoomStatus(w http.ResponseWriter, r *http.Request, change func(roomID string) (*Room, error)) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	room, err := change(r.PathValue("id"))
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	s.handler.broadcastRoomState(room)
+	writeAdminRoom(w, http.StatusOK, room)
+}
+
+// HandleSetPool adds the pool in the body or replaces its policy.
+func (s *Server) HandleSetPool(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var pool models.RoomPool
+	if err := json.NewDecoder(r.Body).Decode(&pool); err != nil {
+		http.Error(w, "Invalid pool", http.StatusBadRequest)
+		return
+	}
+
+	pool.ID = r.PathValue("id")
+	if err := s.roomManager.SetPool(pool); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	s.roomManager.ScalePools()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pool)
+}
+
+// HandleOpenSitAndGo opens a Sit & Go from the definition in the body.
+func (s *Server) HandleOpenSitAndGo(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var definition models.SitAndGoDefinition
+	if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+		http.Error(w, "Invalid sit and go definition", http.StatusBadRequest)
+		return
+	}
+
+	room, err := s.roomManager.OpenSitAndGo(definition)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	log.Printf("[ADMIN] Sit and go opened - RoomID: %s, Name: %s", room.ID, room.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(room.SitAndGo.Summary())
+}
+
+// HandleOpenTournament opens a multi-table tournament from the definition in
+// the body for registration.
+func (s *Server) HandleOpenTournament(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var definition models.TournamentDefinition
+	if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+		http.Error(w, "Invalid tournament definition", http.StatusBadRequest)
+		return
+	}
+
+	tournament, err := s.roomManager.OpenTournament(definition)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	log.Printf("[ADMIN] Tournament opened - TournamentID: %s, Name: %s", definition.ID, definition.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tournament.Summary())
+}
+
+// HandleCancelTournament gives the entrants their buy-ins back while the
+// tournament has not started.
+func (s *Server) HandleCancelTournament(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	tournament, err := s.roomManager.GetTournament(r.PathValue("id"))
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	if err := tournament.Cancel(); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	log.Printf("[ADMIN] Tournament cancelled - TournamentID: %s", tournament.Definition.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tournament.Summary())
+}
+
+// authorizeAdmin checks the admin token. The admin endpoints are off while
+// no token is configured.
+func authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	adminToken := config.GetConfig().AdminToken
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if adminToken == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		log.Printf("[ADMIN] Unauthorized request from %s", r.RemoteAddr)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrorRoomNotFound), errors.Is(err, ErrorTournamentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrorRoomExists), errors.Is(err, ErrorRoomInUse), errors.Is(err, ErrorTournamentRunning),
+		errors.Is(err, ErrorTournamentTable), errors.Is(err, ErrorTournamentExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrorRoomInvalid), errors.Is(err, ErrorRoomClosed), errors.Is(err, ErrorGameInvalidBuyIn),
+		errors.Is(err, ErrorPoolInvalid), errors.Is(err, ErrorTournamentInvalid), errors.Is(err, ErrorBlindScheduleInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		// The room store did not take the change
+		log.Printf("[ERROR] Room change failed: %v", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+func writeAdminRoom(w http.ResponseWriter, status int, room *Room) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(room.GetRoomSummary())
+}
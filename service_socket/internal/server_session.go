@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"log"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+// DefaultReconnectGracePeriod is how long a seated player who lost their
+// connection keeps the seat. Meanwhile they check when they can and fold
+// otherwise.
+const DefaultReconnectGracePeriod = 60 * time.Second
+
+type heldSeat struct {
+	client *Client
+	rooms  []*Room
+	timer  *time.Timer
+}
+
+// HoldSeats keeps the disconnected client's seats in the rooms for the grace
+// period. The player leaves the rooms when it runs out without a reconnect.
+func (s *Server) HoldSeats(client *Client, rooms []*Room) {
+	playerID := client.User.Player.ID
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if held, ok := s.heldSeats[playerID]; ok {
+		held.timer.Stop()
+	}
+
+	s.heldSeats[playerID] = &heldSeat{
+		client: client,
+		rooms:  rooms,
+		timer: time.AfterFunc(s.ReconnectGracePeriod, func() {
+			s.releaseSeats(playerID, client)
+		}),
+	}
+
+	for _, room := range rooms {
+		log.Printf("[INFO] Holding seat for disconnected player - RoomID: %s, PlayerID: %s, GracePeriod: %s", room.ID, playerID, s.ReconnectGracePeriod)
+	}
+}
+
+func (s *Server) releaseSeats(playerID string, client *Client) {
+	s.mu.Lock()
+	held, ok := s.heldSeats[playerID]
+	if !ok || held.client != client {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.heldSeats, playerID)
+	s.mu.Unlock()
+
+	for _, room := range held.rooms {
+		log.Printf("[INFO] Reconnect grace period expired - RoomID: %s, PlayerID: %s", room.ID, playerID)
+		if !room.HasClient(client) {
+			continue
+		}
+
+		if err := s.roomManager.LeaveRoom(room.ID, playerID); err != nil {
+			log.Printf("[ERROR] Failed to remove disconnected player - RoomID: %s, PlayerID: %s, Error: %v", room.ID, playerID, err)
+		}
+		s.handler.broadcastRoomState(room)
+	}
+}
+
+// ResumeSession reattaches a new connection to the seats its player still
+// has, whether the old connection dropped or is still open, and sends the
+// client the full state of each table.
+func (s *Server) ResumeSession(client *Client) {
+	playerID := client.User.Player.ID
+
+	s.mu.Lock()
+	if held, ok := s.heldSeats[playerID]; ok {
+		held.timer.Stop()
+		delete(s.heldSeats, playerID)
+	}
+	s.mu.Unlock()
+
+	resumed := make([]*Room, 0)
+	previousClients := make(map[*Client]bool)
+	for _, room := range s.roomManager.GetRoomsByPlayerID(playerID) {
+		if room.Game == nil || !room.Game.IsSeated(playerID) {
+			continue
+		}
+
+		previous := room.ReattachClient(client)
+		if previous != nil && previous != client {
+			client.ConnectCount = previous.ConnectCount + 1
+			previousClients[previous] = true
+		}
+		resumed = append(resumed, room)
+	}
+
+	// Closed only once every seat moved, so the old connection does not
+	// leave the tables it still had
+	for previous := range previousClients {
+		if previous.Connected() {
+			// The old connection is still open, typically after the
+			// player's network changed
+			previous.MarkDisconnected()
+			previous.Conn.Close()
+		}
+	}
+
+	for _, room := range resumed {
+		log.Printf("[INFO] Player reconnected - RoomID: %s, PlayerID: %s, ConnectCount: %d", room.ID, playerID, client.ConnectCount)
+
+		client.Broadcast(models.Response{
+			Type:   models.MessageTypeSessionResume,
+			RoomID: room.ID,
+			Data: models.MessageSessionResumeResponse{
+				RoomID: room.ID,
+				Player: client.User.Player,
+				State:  room.GetRoomStateForPlayer(playerID),
+			},
+			Timestamp: time.Now().UTC(),
+		})
+		s.handler.broadcastRoomState(room)
+	}
+}
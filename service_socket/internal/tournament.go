@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"errors"
+
+	"github.com/ahmetkoprulu/rtrp/game/models"
+)
+
+var (
+	ErrorTournamentNotRegistering = errors.New("tournament is not taking registrations")
+	ErrorTournamentRegistered     = errors.New("player is already registered")
+	ErrorTournamentNotRegistered  = errors.New("player is not registered")
+	ErrorTournamentFull           = errors.New("tournament is full")
+	ErrorTournamentRunning        = errors.New("tournament is running")
+	ErrorTournamentInvalid        = errors.New("tournament needs an id, a name, seats, a buy-in, a starting stack, blind levels and payouts that add up to 100")
+)
+
+type TournamentStatus string
+
+const (
+	TournamentStatusRegistering TournamentStatus = "registering"
+	TournamentStatusRunning     TournamentStatus = "running"
+	TournamentStatusFinished    TournamentStatus = "finished"
+	TournamentStatusCancelled   TournamentStatus = "cancelled" // Closed before it started, the buy-ins went back
+)
+
+// ITournament plays a tournament at the game's table. Tournament chips never
+// leave the table: the players are not cashed out but eliminated once they
+// have no chips left, and are paid for the place they finished in.
+type ITournament interface {
+	// RemoveBusted takes the players with no chips left off the table.
+	// Players who left keep their stacks in play until they are blinded
+	// out. The caller holds the game's Mu.
+	RemoveBusted(game *Game)
+	// CanDeal reports whether the table can deal its next hand. Tables wait
+	// for one another when the tournament plays hand for hand.
+	CanDeal(game *Game) bool
+}
+
+// DefaultBlindLevels are the blinds of tournaments that do not set their own.
+var DefaultBlindLevels = []models.BlindLevel{
+	{SmallBlind: 10, BigBlind: 20, DurationSeconds: 300},
+	{SmallBlind: 15, BigBlind: 30, DurationSeconds: 300},
+	{SmallBlind: 25, BigBlind: 50, DurationSeconds: 300},
+	{SmallBlind: 50, BigBlind: 100, DurationSeconds: 300},
+	{SmallBlind: 75, BigBlind: 150, DurationSeconds: 300},
+	{SmallBlind: 100, BigBlind: 200, DurationSeconds: 300},
+	{SmallBlind: 150, BigBlind: 300, DurationSeconds: 300},
+	{SmallBlind: 200, BigBlind: 400, DurationSeconds: 300},
+	{SmallBlind: 300, BigBlind: 600, DurationSeconds: 300},
+	{SmallBlind: 400, BigBlind: 800, DurationSeconds: 300},
+	{SmallBlind: 600, BigBlind: 1200, DurationSeconds: 300},
+	{SmallBlind: 1000, BigBlind: 2000, DurationSeconds: 300},
+}
+
+// DefaultPayouts is the share of the prize pool in percent paid to each
+// place, for tournaments that do not set their own.
+func DefaultPayouts(entrants int) []int {
+	switch {
+	case entrants <= 3:
+		return []int{100}
+	case entrants <= 6:
+		return []int{65, 35}
+	case entrants <= 10:
+		return []int{50, 30, 20}
+	case entrants <= 18:
+		return []int{40, 25, 17, 11, 7}
+	case entrants <= 30:
+		return []int{30, 20, 14, 10, 8, 6, 5, 4, 3}
+	default:
+		return []int{25, 15, 10, 8, 6, 5, 4, 3, 3, 3, 3, 3, 2, 2, 2, 2, 2, 2}
+	}
+}
+
+// prizes splits the prize pool by the payouts. What rounding leaves over goes
+// to the winner.
+func prizes(pool int, payouts []int) []int {
+	prizes := make([]int, len(payouts))
+	paid := 0
+	for i, percent := range payouts {
+		prizes[i] = pool * percent / 100
+		paid += prizes[i]
+	}
+
+	if len(prizes) > 0 {
+		prizes[0] += pool - paid
+	}
+
+	return prizes
+}
+
+// tournamentEntry is a registered player and the reservation holding their
+// buy-in. The reservation is settled with their prize.
+type tournamentEntry struct {
+	Client        *Client
+	ReservationID string
+}
+
+func validateTournament(levels []models.BlindLevel, payouts []int) error {
+	if !validBlindLevels(levels) {
+		return ErrorTournamentInvalid
+	}
+
+	total := 0
+	for _, percent := range payouts {
+		if percent <= 0 {
+			return ErrorTournamentInvalid
+		}
+		total += percent
+	}
+
+	if total != 100 {
+		return ErrorTournamentInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,1038 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/internal/mq"
+	"github.com/ahmetkoprulu/rtrp/game/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrorTournamentNotFound = errors.New("tournament not found")
+	ErrorTournamentExists   = errors.New("tournament already exists")
+	ErrorTournamentReEntry  = errors.New("player has no re-entries left")
+)
+
+// TournamentCloseDelay is how long the final table of a finished tournament
+// stays up for the players to see the results.
+const TournamentCloseDelay = 30 * time.Second
+
+// Tournament is a multi-table tournament. It starts at the definition's start
+// time on as many tables as the entrants fill. After each hand players are
+// moved so the tables differ by one player at most, and tables are broken as
+// the field shrinks. On the bubble the tables play hand for hand: each deals
+// one hand and waits for the others to finish theirs, so players busting in
+// the same round finish in the order of their stacks.
+type Tournament struct {
+	Definition       models.TournamentDefinition
+	Status           TournamentStatus
+	StartedAt        time.Time
+	LateRegistration bool // Entries are still taken while the tournament runs
+	HandForHand      bool
+
+	rm           *RoomManager
+	entries      []*multiTableEntry          // Every entry in the order taken, re-entries included
+	live         map[string]*multiTableEntry // The entry each player still plays, by player ID
+	busted       []*multiTableEntry          // Out of chips, waiting for their place
+	remaining    int
+	prizes       []int // By place, set once registration closes
+	results      []models.TournamentResult
+	tables       map[string]*tournamentTable // By room ID
+	tablesOpened int
+	waiting      []*GamePlayer  // Entrants with no table yet
+	roundHands   map[string]int // Hands the tables had dealt when the hand for hand round began
+	clock        *blindClock
+	startTimer   *time.Timer
+	mu           sync.Mutex
+	tidyMu       sync.Mutex
+}
+
+// multiTableEntry is one buy-in to the tournament. A player who re-enters
+// plays a new entry.
+type multiTableEntry struct {
+	tournamentEntry
+	Stack int // Chips at the start of the last hand
+}
+
+// tournamentTable is a table the tournament plays at and the players moved to
+// it, who take their seats before its next hand.
+type tournamentTable struct {
+	Room     *Room
+	count    int // Players seated and moving in
+	arrivals []*GamePlayer
+}
+
+type TournamentSummary struct {
+	ID               string                    `json:"id"`
+	Name             string                    `json:"name"`
+	Status           TournamentStatus          `json:"status"`
+	GameType         GameType                  `json:"game_type"`
+	BettingStructure string                    `json:"betting_structure"`
+	TableSize        int                       `json:"table_size"`
+	StartsAt         time.Time                 `json:"starts_at"`
+	Entries          int                       `json:"entries"`
+	Remaining        int                       `json:"remaining"`
+	BuyIn            int                       `json:"buy_in"`
+	PrizePool        int                       `json:"prize_pool"`
+	StartingStack    int                       `json:"starting_stack"`
+	LateRegistration bool                      `json:"late_registration"`
+	MaxReEntries     int                       `json:"max_re_entries"`
+	HandForHand      bool                      `json:"hand_for_hand"`
+	Level            int                       `json:"level"`
+	SmallBlind       int                       `json:"small_blind"`
+	BigBlind         int                       `json:"big_blind"`
+	Ante             int                       `json:"ante"`
+	Tables           []string                  `json:"tables"`
+	Results          []models.TournamentResult `json:"results"`
+}
+
+// TournamentLobby is every tournament players can register for or follow.
+type TournamentLobby struct {
+	SitAndGos   []*SitAndGoSummary   `json:"sit_and_gos"`
+	Tournaments []*TournamentSummary `json:"tournaments"`
+}
+
+// OpenTournament takes registrations for the tournament until it starts.
+// Tournament tables are not listed with the cash tables and are not kept
+// across restarts.
+func (rm *RoomManager) OpenTournament(definition models.TournamentDefinition) (*Tournament, error) {
+	levels, err := blindSchedule(definition.Levels, definition.BlindSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	definition.Levels = levels
+	if len(definition.Levels) == 0 {
+		definition.Levels = DefaultBlindLevels
+	}
+	if definition.MinEntrants == 0 {
+		definition.MinEntrants = 2
+	}
+
+	if definition.ID == "" || definition.Name == "" || definition.TableSize < 2 || definition.BuyIn <= 0 ||
+		definition.StartingStack <= 0 || definition.StartsAt.IsZero() || definition.MinEntrants < 2 ||
+		(definition.MaxEntrants > 0 && definition.MaxEntrants < definition.MinEntrants) ||
+		definition.LateRegistrationLevels < 0 || definition.LateRegistrationLevels > len(definition.Levels) ||
+		definition.MaxReEntries < 0 {
+		return nil, ErrorTournamentInvalid
+	}
+
+	switch GameType(definition.GameType) {
+	case GameTypeHoldem:
+	case GameTypeOmaha:
+		if definition.TableSize > MaxOmahaPlayers {
+			return nil, ErrorTournamentInvalid
+		}
+	default:
+		return nil, ErrorTournamentInvalid
+	}
+
+	if definition.BettingStructure != "" {
+		if _, err := NewBettingStructure(BettingStructureType(definition.BettingStructure), definition.Levels[0].BigBlind); err != nil {
+			return nil, ErrorTournamentInvalid
+		}
+	}
+
+	// Payouts left out are set by the number of entries once registration
+	// closes
+	payouts := definition.Payouts
+	if len(payouts) == 0 {
+		payouts = DefaultPayouts(definition.MinEntrants)
+	}
+	if err := validateTournament(definition.Levels, payouts); err != nil {
+		return nil, err
+	}
+
+	tournament := &Tournament{
+		Definition: definition,
+		Status:     TournamentStatusRegistering,
+		rm:         rm,
+		entries:    make([]*multiTableEntry, 0),
+		live:       make(map[string]*multiTableEntry),
+		results:    make([]models.TournamentResult, 0),
+		tables:     make(map[string]*tournamentTable),
+	}
+	tournament.clock = newBlindClock(definition.Levels, tournament.raiseBlinds)
+
+	rm.tournamentsMu.Lock()
+	defer rm.tournamentsMu.Unlock()
+
+	if _, ok := rm.tournaments[definition.ID]; ok {
+		return nil, ErrorTournamentExists
+	}
+
+	rm.tournaments[definition.ID] = tournament
+	tournament.startTimer = time.AfterFunc(time.Until(definition.StartsAt), tournament.start)
+	log.Printf("[INFO] Tournament open for registration - TournamentID: %s, StartsAt: %s, BuyIn: %d", definition.ID, definition.StartsAt.Format(time.RFC3339), definition.BuyIn)
+
+	return tournament, nil
+}
+
+func (rm *RoomManager) GetTournament(tournamentID string) (*Tournament, error) {
+	rm.tournamentsMu.Lock()
+	defer rm.tournamentsMu.Unlock()
+
+	tournament, ok := rm.tournaments[tournamentID]
+	if !ok {
+		return nil, ErrorTournamentNotFound
+	}
+
+	return tournament, nil
+}
+
+// GetTournaments returns the multi-table tournaments, the next to start
+// first.
+func (rm *RoomManager) GetTournaments() []*TournamentSummary {
+	rm.tournamentsMu.Lock()
+	tournaments := make([]*Tournament, 0, len(rm.tournaments))
+	for _, tournament := range rm.tournaments {
+		tournaments = append(tournaments, tournament)
+	}
+	rm.tournamentsMu.Unlock()
+
+	summaries := make([]*TournamentSummary, 0, len(tournaments))
+	for _, tournament := range tournaments {
+		summaries = append(summaries, tournament.Summary())
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if !summaries[i].StartsAt.Equal(summaries[j].StartsAt) {
+			return summaries[i].StartsAt.Before(summaries[j].StartsAt)
+		}
+		return summaries[i].ID < summaries[j].ID
+	})
+	return summaries
+}
+
+// GetTournamentLobby returns the Sit & Gos and the multi-table tournaments.
+func (rm *RoomManager) GetTournamentLobby() *TournamentLobby {
+	return &TournamentLobby{
+		SitAndGos:   rm.GetSitAndGos(),
+		Tournaments: rm.GetTournaments(),
+	}
+}
+
+// Register takes the player's buy-in from their wallet into the prize pool.
+// Once the tournament runs, players register while late registration is
+// open and busted players enter again while they have re-entries left.
+func (t *Tournament) Register(client *Client) error {
+	running, err := t.register(client)
+	if err != nil {
+		return err
+	}
+
+	if running {
+		t.openTables()
+	}
+
+	return nil
+}
+
+func (t *Tournament) register(client *Client) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	playerID := client.User.Player.ID
+	entered := 0
+	for _, entry := range t.entries {
+		if entry.Client.User.Player.ID == playerID {
+			entered++
+		}
+	}
+
+	switch {
+	case t.Status != TournamentStatusRegistering && (t.Status != TournamentStatusRunning || !t.LateRegistration):
+		return false, ErrorTournamentNotRegistering
+	case t.live[playerID] != nil:
+		return false, ErrorTournamentRegistered
+	case entered > t.Definition.MaxReEntries:
+		return false, ErrorTournamentReEntry
+	case t.Definition.MaxEntrants > 0 && len(t.entries) >= t.Definition.MaxEntrants:
+		return false, ErrorTournamentFull
+	case int(client.User.Player.Chips) < t.Definition.BuyIn:
+		return false, ErrorGameInsufficientChips
+	}
+
+	// The wallet is called holding mu, so no more entries are taken than the
+	// tournament allows
+	reservationID := uuid.New().String()
+	if err := reserveChips(t.rm.Wallet, reservationID, client, t.Definition.ID, mq.ChipTransactionBuyIn, t.Definition.BuyIn); err != nil {
+		return false, err
+	}
+
+	client.User.Player.Chips -= int64(t.Definition.BuyIn)
+	entry := &multiTableEntry{
+		tournamentEntry: tournamentEntry{Client: client, ReservationID: reservationID},
+		Stack:           t.Definition.StartingStack,
+	}
+	t.entries = append(t.entries, entry)
+	t.live[playerID] = entry
+	t.remaining++
+	log.Printf("[INFO] Player registered for tournament - TournamentID: %s, PlayerID: %s, Entry: %d, Entries: %d", t.Definition.ID, playerID, entered+1, len(t.entries))
+
+	if t.Status != TournamentStatusRunning {
+		return false, nil
+	}
+
+	t.waiting = append(t.waiting, t.newSeat(entry))
+	return true, nil
+}
+
+// Unregister gives the player their buy-in back while the tournament has not
+// started.
+func (t *Tournament) Unregister(playerID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.Status != TournamentStatusRegistering {
+		return ErrorTournamentNotRegistering
+	}
+
+	entry := t.live[playerID]
+	if entry == nil {
+		return ErrorTournamentNotRegistered
+	}
+
+	t.refund(entry)
+	log.Printf("[INFO] Player unregistered from tournament - TournamentID: %s, PlayerID: %s", t.Definition.ID, playerID)
+
+	return nil
+}
+
+// Cancel gives every registered player their buy-in back. A running
+// tournament can not be cancelled, it is played to the end.
+func (t *Tournament) Cancel() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch t.Status {
+	case TournamentStatusRunning:
+		return ErrorTournamentRunning
+	case TournamentStatusRegistering:
+		t.cancel()
+	}
+
+	return nil
+}
+
+// cancel refunds the entries. The caller holds mu.
+func (t *Tournament) cancel() {
+	t.startTimer.Stop()
+	for _, entry := range slices.Clone(t.entries) {
+		t.refund(entry)
+	}
+
+	t.Status = TournamentStatusCancelled
+	log.Printf("[INFO] Tournament cancelled - TournamentID: %s", t.Definition.ID)
+}
+
+// refund releases the entry's buy-in and drops it. The caller holds mu.
+func (t *Tournament) refund(entry *multiTableEntry) {
+	entry.Client.User.Player.Chips += int64(t.Definition.BuyIn)
+	reservationID := entry.ReservationID
+	retryWallet(t.rm.Wallet, reservationID, func(wallet IChipWallet) error {
+		return wallet.Release(reservationID)
+	})
+
+	t.entries = slices.DeleteFunc(t.entries, func(e *multiTableEntry) bool { return e == entry })
+	delete(t.live, entry.Client.User.Player.ID)
+	t.remaining--
+}
+
+// start seats the entrants at random across the tables they fill, or cancels
+// the tournament when too few registered.
+func (t *Tournament) start() {
+	t.mu.Lock()
+	if t.Status != TournamentStatusRegistering {
+		t.mu.Unlock()
+		return
+	}
+
+	if len(t.entries) < t.Definition.MinEntrants {
+		t.cancel()
+		t.mu.Unlock()
+		return
+	}
+
+	t.Status = TournamentStatusRunning
+	t.StartedAt = time.Now()
+	for _, i := range rand.Perm(len(t.entries)) {
+		t.waiting = append(t.waiting, t.newSeat(t.entries[i]))
+	}
+
+	t.LateRegistration = true
+	if t.Definition.LateRegistrationLevels == 0 {
+		t.closeRegistration()
+	}
+	t.mu.Unlock()
+
+	log.Printf("[INFO] Tournament started - TournamentID: %s, Entries: %d", t.Definition.ID, len(t.waiting))
+	t.clock.start()
+	t.openTables()
+}
+
+func (t *Tournament) IsRunning() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.Status == TournamentStatusRunning
+}
+
+// newSeat is the entry's place at a table with the starting stack. The caller
+// holds mu.
+func (t *Tournament) newSeat(entry *multiTableEntry) *GamePlayer {
+	return &GamePlayer{
+		Client:  entry.Client,
+		Balance: t.Definition.StartingStack,
+		Status:  GamePlayerStatusWaiting,
+	}
+}
+
+// openTables opens as many tables as the entrants with no seat need, and
+// sends them to the tables with the fewest players.
+func (t *Tournament) openTables() {
+	t.mu.Lock()
+	free := 0
+	for _, table := range t.tables {
+		free += max(t.Definition.TableSize-table.count, 0)
+	}
+	needed := 0
+	if short := len(t.waiting) - free; short > 0 {
+		needed = (short + t.Definition.TableSize - 1) / t.Definition.TableSize
+	}
+	first := t.tablesOpened + 1
+	t.tablesOpened += needed
+	t.mu.Unlock()
+
+	rooms := make([]*Room, 0, needed)
+	for number := first; number < first+needed; number++ {
+		room, err := t.openTable(number)
+		if err != nil {
+			log.Printf("[ERROR] Failed to open tournament table - TournamentID: %s, Table: %d, Error: %v", t.Definition.ID, number, err)
+			continue
+		}
+		rooms = append(rooms, room)
+	}
+
+	t.mu.Lock()
+	for _, room := range rooms {
+		t.tables[room.ID] = &tournamentTable{Room: room}
+	}
+	if len(rooms) > 0 {
+		t.updateHandForHand()
+	}
+
+	waiting := t.waiting
+	t.waiting = nil
+	for _, player := range waiting {
+		if !t.sendTo(player, nil) {
+			t.waiting = append(t.waiting, player)
+		}
+	}
+	t.mu.Unlock()
+
+	t.tidy()
+}
+
+// openTable sets up a table playing the tournament's blind clock.
+func (t *Tournament) openTable(number int) (*Room, error) {
+	room, err := t.rm.buildRoom(models.RoomDefinition{
+		ID:               fmt.Sprintf("%s_table_%d", t.Definition.ID, number),
+		Name:             fmt.Sprintf("%s - Table %d", t.Definition.Name, number),
+		GameType:         t.Definition.GameType,
+		BettingStructure: t.Definition.BettingStructure,
+		MinBet:           t.Definition.Levels[0].BigBlind,
+		MaxPlayers:       t.Definition.TableSize * 2, // Room for players moving in as others leave
+		MaxGamePlayers:   t.Definition.TableSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	room.Tournament = t
+	room.Game.Tournament = t
+	room.Game.BlindClock = t.clock
+	// Players away are folded and blinded off, they do not sit out
+	room.Game.ActionTimer.SitOutAfterTimeouts = 0
+
+	t.rm.RegisterRoom(room)
+	return room, nil
+}
+
+// sendTo moves the player to the table with the fewest players, other than
+// the one they leave. They take their seat before its next hand. The caller
+// holds mu.
+func (t *Tournament) sendTo(player *GamePlayer, from *Room) bool {
+	var to *tournamentTable
+	for _, table := range t.tables {
+		if table.Room == from {
+			continue
+		}
+		if to == nil || table.count < to.count || (table.count == to.count && table.Room.ID < to.Room.ID) {
+			to = table
+		}
+	}
+
+	if to == nil {
+		return false
+	}
+
+	// Moved players are dealt in at once, wherever the blinds are
+	player.Position = -1
+	player.Status = GamePlayerStatusWaiting
+	player.MissedBigBlind = false
+	player.MissedSmallBlind = false
+	to.arrivals = append(to.arrivals, player)
+	to.count++
+
+	go t.seat(player.Client, from, to.Room)
+	return true
+}
+
+// seat moves the player into the room of their new table and tells them.
+func (t *Tournament) seat(client *Client, from *Room, to *Room) {
+	playerID := client.User.Player.ID
+	if err := to.AddPlayer(client); err != nil {
+		log.Printf("[ERROR] Failed to move tournament player - TournamentID: %s, PlayerID: %s, RoomID: %s, Error: %v", t.Definition.ID, playerID, to.ID, err)
+	}
+
+	response := models.MessageTournamentSeatedResponse{
+		TournamentID: t.Definition.ID,
+		RoomID:       to.ID,
+	}
+	if from != nil {
+		from.RemovePlayer(playerID)
+		response.FromRoomID = from.ID
+	}
+
+	client.Broadcast(models.Response{
+		Type:      models.MessageTypeTournamentSeated,
+		RoomID:    to.ID,
+		Data:      response,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// CanDeal lets the table deal while the tournament runs. Hand for hand, each
+// table deals one hand a round.
+func (t *Tournament) CanDeal(game *Game) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.Status != TournamentStatusRunning {
+		return false
+	}
+
+	if !t.HandForHand {
+		return true
+	}
+
+	hands, ok := t.roundHands[game.Room.ID]
+	return ok && game.HandsDealt == hands
+}
+
+// RemoveBusted takes the players with no chips left off the table, seats the
+// players moved to it, then breaks or balances the table. Players who left
+// are blinded out before they are taken off. The caller holds the game's Mu.
+func (t *Tournament) RemoveBusted(game *Game) {
+	t.mu.Lock()
+	t.settle(game)
+	t.mu.Unlock()
+
+	// The tables left waiting are dealt in once the game's Mu is released
+	go t.tidy()
+}
+
+// settle brings the table up to date between hands. The caller holds the
+// game's Mu and mu.
+func (t *Tournament) settle(game *Game) {
+	busted := make([]*GamePlayer, 0)
+	game.Players = slices.DeleteFunc(game.Players, func(p *GamePlayer) bool {
+		if p.Balance > 0 {
+			return false
+		}
+
+		busted = append(busted, p)
+		return true
+	})
+
+	table := t.tables[game.Room.ID]
+	if t.Status != TournamentStatusRunning || table == nil {
+		return
+	}
+
+	// Players busting in the same hand finish in the order of the stacks
+	// they started it with
+	sort.SliceStable(busted, func(i, j int) bool {
+		return t.stack(busted[i]) < t.stack(busted[j])
+	})
+	for _, player := range busted {
+		entry := t.live[player.Client.User.Player.ID]
+		if entry == nil {
+			continue
+		}
+
+		delete(t.live, player.Client.User.Player.ID)
+		t.busted = append(t.busted, entry)
+		t.remaining--
+	}
+
+	if len(busted) > 0 && !t.LateRegistration && !t.HandForHand {
+		t.place()
+	}
+
+	if t.Status != TournamentStatusRunning {
+		return
+	}
+
+	t.seatArrivals(game, table)
+	table.count = len(game.Players) + len(table.arrivals)
+
+	if len(t.tables) > 1 && t.remaining <= (len(t.tables)-1)*t.Definition.TableSize && t.smallest(table) {
+		t.breakTable(game, table)
+		return
+	}
+
+	t.balance(game, table)
+
+	for _, player := range game.Players {
+		if entry := t.live[player.Client.User.Player.ID]; entry != nil {
+			entry.Stack = player.Balance
+		}
+	}
+}
+
+func (t *Tournament) stack(player *GamePlayer) int {
+	if entry := t.live[player.Client.User.Player.ID]; entry != nil {
+		return entry.Stack
+	}
+	return 0
+}
+
+// seatArrivals gives the players moved to the table the free seats. Those
+// left over wait for seats to free up. The caller holds the game's Mu and mu.
+func (t *Tournament) seatArrivals(game *Game, table *tournamentTable) {
+	taken := make(map[int]bool, len(game.Players))
+	for _, player := range game.Players {
+		taken[player.Position] = true
+	}
+
+	arrivals := table.arrivals
+	table.arrivals = nil
+	for _, player := range arrivals {
+		position := -1
+		for seat := 0; seat < game.MaxPlayers; seat++ {
+			if !taken[seat] {
+				position = seat
+				break
+			}
+		}
+
+		if position < 0 {
+			table.arrivals = append(table.arrivals, player)
+			continue
+		}
+
+		taken[position] = true
+		player.Position = position
+		game.Players = append(game.Players, player)
+	}
+}
+
+// smallest reports whether no other table has fewer players. The caller
+// holds mu.
+func (t *Tournament) smallest(table *tournamentTable) bool {
+	for _, other := range t.tables {
+		if other.count < table.count || (other.count == table.count && other.Room.ID < table.Room.ID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// breakTable sends the table's players to the other tables and takes it
+// down. The caller holds the game's Mu and mu.
+func (t *Tournament) breakTable(game *Game, table *tournamentTable) {
+	delete(t.tables, table.Room.ID)
+
+	players := append(slices.Clone(game.Players), table.arrivals...)
+	game.Players = game.Players[:0]
+	table.arrivals = nil
+	for _, player := range players {
+		t.sendTo(player, table.Room)
+	}
+
+	log.Printf("[INFO] Tournament table broken - TournamentID: %s, RoomID: %s, Players: %d, Tables: %d", t.Definition.ID, table.Room.ID, len(players), len(t.tables))
+	go t.closeTable(table.Room)
+
+	// A table broken hand for hand may end it, the round's busted players
+	// are placed then
+	t.updateHandForHand()
+	if !t.HandForHand && len(t.busted) > 0 {
+		sort.SliceStable(t.busted, func(i, j int) bool { return t.busted[i].Stack < t.busted[j].Stack })
+		t.place()
+	}
+}
+
+// balance moves players to the table with the fewest players while this one
+// has two more. The caller holds the game's Mu and mu.
+func (t *Tournament) balance(game *Game, table *tournamentTable) {
+	for len(game.Players) > 0 {
+		fewest := table.count
+		for _, other := range t.tables {
+			fewest = min(fewest, other.count)
+		}
+		if table.count <= fewest+1 {
+			return
+		}
+
+		i := rand.Intn(len(game.Players))
+		player := game.Players[i]
+		game.Players = slices.Delete(game.Players, i, i+1)
+		table.count--
+		t.sendTo(player, table.Room)
+
+		log.Printf("[INFO] Tournament player moved - TournamentID: %s, PlayerID: %s, FromRoomID: %s", t.Definition.ID, player.Client.User.Player.ID, table.Room.ID)
+	}
+}
+
+// closeTable takes down a table the tournament no longer plays at.
+func (t *Tournament) closeTable(room *Room) {
+	if _, err := t.rm.setRoomStatus(room.ID, RoomStatusClosed); err != nil {
+		if !errors.Is(err, ErrorRoomClosed) {
+			log.Printf("[ERROR] Failed to close tournament table - RoomID: %s, Error: %v", room.ID, err)
+		}
+		return
+	}
+
+	t.rm.takeDown(room)
+}
+
+// tidy brings the tables waiting between hands up to date and deals them
+// in. Hand for hand, the next round starts once every table is waiting.
+func (t *Tournament) tidy() {
+	t.tidyMu.Lock()
+	defer t.tidyMu.Unlock()
+
+	idle := make(map[string]int)
+	for _, room := range t.tableRooms() {
+		game := room.Game
+		game.Mu.Lock()
+		if game.Status == GameStatusWaiting {
+			t.mu.Lock()
+			t.settle(game)
+			t.mu.Unlock()
+			idle[room.ID] = game.HandsDealt
+		}
+		game.Mu.Unlock()
+	}
+
+	t.mu.Lock()
+	if t.Status == TournamentStatusRunning && t.HandForHand && len(idle) >= len(t.tables) {
+		t.completeRound(idle)
+	}
+	t.mu.Unlock()
+
+	for _, room := range t.tableRooms() {
+		game := room.Game
+		game.Mu.Lock()
+		if game.Status == GameStatusWaiting && game.Playable.CanStart() {
+			if err := game.Start(); err != nil {
+				log.Printf("[ERROR] Failed to deal tournament table - RoomID: %s, Error: %v", room.ID, err)
+			}
+		}
+		game.Mu.Unlock()
+	}
+}
+
+func (t *Tournament) tableRooms() []*Room {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rooms := make([]*Room, 0, len(t.tables))
+	for _, table := range t.tables {
+		rooms = append(rooms, table.Room)
+	}
+
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms
+}
+
+// completeRound places the players who busted in the hand for hand round
+// together, by the stacks they started their hands with, and begins the next
+// round from the hands the tables have dealt. The caller holds mu.
+func (t *Tournament) completeRound(hands map[string]int) {
+	for id := range t.tables {
+		if _, ok := hands[id]; !ok {
+			return
+		}
+	}
+
+	if len(t.busted) > 0 {
+		sort.SliceStable(t.busted, func(i, j int) bool { return t.busted[i].Stack < t.busted[j].Stack })
+		t.place()
+	}
+
+	if t.HandForHand {
+		t.roundHands = hands
+	}
+}
+
+// place gives the busted players their places, the first to bust the worst,
+// and finishes the tournament once one player is left. The caller holds mu.
+func (t *Tournament) place() {
+	eliminated := make([]models.TournamentResult, 0, len(t.busted)+1)
+	place := t.remaining + len(t.busted)
+	for _, entry := range t.busted {
+		eliminated = append(eliminated, t.pay(entry, place))
+		place--
+	}
+	t.busted = nil
+
+	finished := t.remaining <= 1
+	if finished {
+		for _, entry := range t.live {
+			t.pay(entry, 1)
+		}
+		t.finish()
+	} else {
+		t.updateHandForHand()
+	}
+
+	if len(eliminated) > 0 || finished {
+		go t.announce(eliminated, finished)
+	}
+}
+
+// pay records the place the entry finished in and settles its buy-in
+// reservation with the prize for it. The caller holds mu.
+func (t *Tournament) pay(entry *multiTableEntry, place int) models.TournamentResult {
+	playerID := entry.Client.User.Player.ID
+	result := models.TournamentResult{PlayerID: playerID, Place: place}
+	if place <= len(t.prizes) {
+		result.Prize = t.prizes[place-1]
+	}
+
+	entry.Client.User.Player.Chips += int64(result.Prize)
+	reservationID := entry.ReservationID
+	retryWallet(t.rm.Wallet, reservationID, func(wallet IChipWallet) error {
+		return wallet.Settle(reservationID, mq.ChipTransactionPrize, result.Prize)
+	})
+
+	t.results = append(t.results, result)
+	log.Printf("[INFO] Tournament place - TournamentID: %s, PlayerID: %s, Place: %d, Prize: %d", t.Definition.ID, playerID, place, result.Prize)
+
+	return result
+}
+
+// updateHandForHand plays hand for hand while one more player busting puts
+// everyone left in the money. The caller holds mu.
+func (t *Tournament) updateHandForHand() {
+	handForHand := !t.LateRegistration && len(t.tables) > 1 &&
+		t.remaining > len(t.prizes) && t.remaining <= len(t.prizes)+1
+	if handForHand == t.HandForHand {
+		return
+	}
+
+	t.HandForHand = handForHand
+	t.roundHands = nil
+	log.Printf("[INFO] Tournament hand for hand - TournamentID: %s, HandForHand: %t, Remaining: %d", t.Definition.ID, handForHand, t.remaining)
+
+	response := models.Response{
+		Type: models.MessageTypeTournamentHandForHand,
+		Data: models.MessageTournamentHandForHandResponse{
+			TournamentID: t.Definition.ID,
+			HandForHand:  handForHand,
+		},
+	}
+	go t.broadcast(response)
+}
+
+// closeRegistration takes no more entries and sets the prizes by the number
+// of entries taken. The caller holds mu.
+func (t *Tournament) closeRegistration() {
+	t.LateRegistration = false
+
+	payouts := t.Definition.Payouts
+	if len(payouts) == 0 || len(payouts) > len(t.entries) {
+		payouts = DefaultPayouts(len(t.entries))
+	}
+	t.prizes = prizes(t.Definition.BuyIn*len(t.entries), payouts)
+	log.Printf("[INFO] Tournament registration closed - TournamentID: %s, Entries: %d, Paid: %d", t.Definition.ID, len(t.entries), len(t.prizes))
+
+	if len(t.busted) > 0 || t.remaining <= 1 {
+		t.place()
+	} else {
+		t.updateHandForHand()
+	}
+}
+
+// raiseBlinds announces the level the tables play from their next hand on,
+// and closes late registration when its levels are over.
+func (t *Tournament) raiseBlinds(level int, blinds models.BlindLevel) {
+	t.mu.Lock()
+	if t.Status != TournamentStatusRunning {
+		t.mu.Unlock()
+		return
+	}
+
+	if t.LateRegistration && level >= t.Definition.LateRegistrationLevels {
+		t.closeRegistration()
+	}
+	t.mu.Unlock()
+
+	log.Printf("[INFO] Tournament blinds up - TournamentID: %s, Level: %d, SmallBlind: %d, BigBlind: %d, Ante: %d", t.Definition.ID, level+1, blinds.SmallBlind, blinds.BigBlind, blinds.Ante)
+	t.broadcast(models.Response{
+		Type: models.MessageTypeTournamentLevel,
+		Data: models.MessageTournamentLevelResponse{
+			TournamentID: t.Definition.ID,
+			Level:        level + 1,
+			SmallBlind:   blinds.SmallBlind,
+			BigBlind:     blinds.BigBlind,
+			Ante:         blinds.Ante,
+		},
+	})
+
+	t.tidy()
+}
+
+// finish ends the tournament once every place is paid, and takes the tables
+// down after TournamentCloseDelay. The caller holds mu.
+func (t *Tournament) finish() {
+	t.Status = TournamentStatusFinished
+	t.HandForHand = false
+	t.clock.stop()
+
+	rooms := make([]*Room, 0, len(t.tables))
+	for _, table := range t.tables {
+		rooms = append(rooms, table.Room)
+	}
+
+	log.Printf("[INFO] Tournament finished - TournamentID: %s, Entries: %d", t.Definition.ID, len(t.entries))
+	time.AfterFunc(TournamentCloseDelay, func() {
+		for _, room := range rooms {
+			t.closeTable(room)
+		}
+	})
+}
+
+func (t *Tournament) announce(eliminated []models.TournamentResult, finished bool) {
+	for _, result := range eliminated {
+		t.broadcast(models.Response{
+			Type: models.MessageTypeTournamentEliminated,
+			Data: models.MessageTournamentEliminatedResponse{
+				TournamentID:     t.Definition.ID,
+				TournamentResult: result,
+			},
+		})
+	}
+
+	if finished {
+		t.broadcast(models.Response{
+			Type: models.MessageTypeTournamentFinished,
+			Data: models.MessageTournamentFinishedResponse{
+				TournamentID: t.Definition.ID,
+				Results:      t.Summary().Results,
+			},
+		})
+	}
+}
+
+// broadcast tells every table of the tournament.
+func (t *Tournament) broadcast(response models.Response) {
+	response.Timestamp = time.Now().UTC()
+	for _, room := range t.tableRooms() {
+		response.RoomID = room.ID
+		room.BroadcastToRoom(response)
+	}
+}
+
+// Summary returns the entries, the blinds, the tables and the places paid so
+// far, the final standings once the tournament is over.
+func (t *Tournament) Summary() *TournamentSummary {
+	level, blinds, _ := t.clock.current()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tables := make([]string, 0, len(t.tables))
+	for id := range t.tables {
+		tables = append(tables, id)
+	}
+	sort.Strings(tables)
+
+	results := slices.Clone(t.results)
+	sort.Slice(results, func(i, j int) bool { return results[i].Place < results[j].Place })
+
+	return &TournamentSummary{
+		ID:               t.Definition.ID,
+		Name:             t.Definition.Name,
+		Status:           t.Status,
+		GameType:         GameType(t.Definition.GameType),
+		BettingStructure: t.Definition.BettingStructure,
+		TableSize:        t.Definition.TableSize,
+		StartsAt:         t.Definition.StartsAt,
+		Entries:          len(t.entries),
+		Remaining:        t.remaining,
+		BuyIn:            t.Definition.BuyIn,
+		PrizePool:        t.Definition.BuyIn * len(t.entries),
+		StartingStack:    t.Definition.StartingStack,
+		LateRegistration: t.LateRegistration,
+		MaxReEntries:     t.Definition.MaxReEntries,
+		HandForHand:      t.HandForHand,
+		Level:            level + 1,
+		SmallBlind:       blinds.SmallBlind,
+		BigBlind:         blinds.BigBlind,
+		Ante:             blinds.Ante,
+		Tables:           tables,
+		Results:          results,
+	}
+}
+
+func (h *MessageHandler) handleTournamentEntry(client *Client, msg models.MessageTournament) error {
+	tournament, err := h.roomManager.GetTournament(msg.TournamentID)
+	if err != nil {
+		return h.sendError(client, "", "Tournament not found")
+	}
+
+	if err := tournament.Register(client); err != nil {
+		return h.sendError(client, "", fmt.Sprintf("Failed to register: %v", err))
+	}
+
+	h.sendTournamentSummary(client, tournament, models.MessageTypeTournamentRegisterOk)
+	return nil
+}
+
+func (h *MessageHandler) handleTournamentWithdraw(client *Client, msg models.MessageTournament) error {
+	tournament, err := h.roomManager.GetTournament(msg.TournamentID)
+	if err != nil {
+		return h.sendError(client, "", "Tournament not found")
+	}
+
+	if err := tournament.Unregister(client.User.Player.ID); err != nil {
+		return h.sendError(client, "", fmt.Sprintf("Failed to unregister: %v", err))
+	}
+
+	h.sendTournamentSummary(client, tournament, models.MessageTypeTournamentUnregisterOk)
+	return nil
+}
+
+func (h *MessageHandler) sendTournamentSummary(client *Client, tournament *Tournament, messageType models.MessageType) {
+	client.Broadcast(models.Response{
+		Type: messageType,
+		Data: models.MessageTournamentResponse{
+			TournamentID: tournament.Definition.ID,
+			Tournament:   tournament.Summary(),
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
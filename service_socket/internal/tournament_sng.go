@@ -0,0 +1,552 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ahmetkoprulu/rtrp/game/internal/mq"
+	"github.com/ahmetkoprulu/rtrp/game/models"
+	"github.com/google/uuid"
+)
+
+var ErrorTournamentTable = errors.New("room is a tournament table")
+
+// SitAndGoCloseDelay is how long the table of a finished Sit & Go stays up
+// for the players to see the results.
+const SitAndGoCloseDelay = 30 * time.Second
+
+// DefaultSitAndGos are opened for registration when the server starts.
+var DefaultSitAndGos = []models.SitAndGoDefinition{
+	{
+		ID:               "sng_nlh_6",
+		Name:             "Six-Max Sit & Go",
+		GameType:         int(GameTypeHoldem),
+		BettingStructure: string(BettingNoLimit),
+		Seats:            6,
+		BuyIn:            100,
+		StartingStack:    1500,
+	},
+}
+
+// SitAndGo is a single table tournament. Players register with a buy-in from
+// their wallet and the tournament starts once every seat is taken. The
+// players play for tournament chips until one of them has them all.
+type SitAndGo struct {
+	Definition models.SitAndGoDefinition
+	Room       *Room
+	Status     TournamentStatus
+	StartedAt  time.Time
+
+	entries    map[string]*tournamentEntry
+	registered []string // Player IDs in the order they registered
+	prizes     []int    // By place, set when the tournament starts
+	results    []models.TournamentResult
+	handStacks map[string]int // Stacks at the start of the last hand
+	clock      *blindClock
+	onStart    func()
+	onFinish   func()
+	mu         sync.Mutex
+}
+
+type SitAndGoSummary struct {
+	RoomID           string                    `json:"room_id"`
+	Name             string                    `json:"name"`
+	Status           TournamentStatus          `json:"status"`
+	GameType         GameType                  `json:"game_type"`
+	BettingStructure string                    `json:"betting_structure"`
+	Seats            int                       `json:"seats"`
+	Registered       int                       `json:"registered"`
+	BuyIn            int                       `json:"buy_in"`
+	PrizePool        int                       `json:"prize_pool"`
+	StartingStack    int                       `json:"starting_stack"`
+	Level            int                       `json:"level"`
+	SmallBlind       int                       `json:"small_blind"`
+	BigBlind         int                       `json:"big_blind"`
+	Ante             int                       `json:"ante"`
+	Results          []models.TournamentResult `json:"results"`
+}
+
+// OpenSitAndGo opens a table taking registrations for the Sit & Go. Once it
+// starts another one opens from the same definition. Sit & Go tables are not
+// listed with the cash tables and are not kept across restarts.
+func (rm *RoomManager) OpenSitAndGo(definition models.SitAndGoDefinition) (*Room, error) {
+	levels, err := blindSchedule(definition.Levels, definition.BlindSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	definition.Levels = levels
+	if len(definition.Levels) == 0 {
+		definition.Levels = DefaultBlindLevels
+	}
+	if len(definition.Payouts) == 0 {
+		definition.Payouts = DefaultPayouts(definition.Seats)
+	}
+
+	if definition.ID == "" || definition.Name == "" || definition.Seats < 2 || definition.BuyIn <= 0 ||
+		definition.StartingStack <= 0 || len(definition.Payouts) > definition.Seats {
+		return nil, ErrorTournamentInvalid
+	}
+
+	if err := validateTournament(definition.Levels, definition.Payouts); err != nil {
+		return nil, err
+	}
+
+	room, err := rm.buildRoom(models.RoomDefinition{
+		ID:               fmt.Sprintf("%s_%s", definition.ID, uuid.New().String()[:8]),
+		Name:             definition.Name,
+		GameType:         definition.GameType,
+		BettingStructure: definition.BettingStructure,
+		MinBet:           definition.Levels[0].BigBlind,
+		MaxPlayers:       definition.Seats * 2, // Room for players who register as the last seats go
+		MaxGamePlayers:   definition.Seats,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sng := &SitAndGo{
+		Definition: definition,
+		Room:       room,
+		Status:     TournamentStatusRegistering,
+		entries:    make(map[string]*tournamentEntry),
+		registered: make([]string, 0, definition.Seats),
+		results:    make([]models.TournamentResult, 0, definition.Seats),
+		handStacks: make(map[string]int),
+	}
+	sng.clock = newBlindClock(definition.Levels, sng.raiseBlinds)
+	room.Game.BlindClock = sng.clock
+	sng.onStart = func() {
+		if _, err := rm.OpenSitAndGo(definition); err != nil {
+			log.Printf("[ERROR] Failed to open next sit and go - ID: %s, Error: %v", definition.ID, err)
+		}
+	}
+	sng.onFinish = func() {
+		time.AfterFunc(SitAndGoCloseDelay, func() {
+			if _, err := rm.CloseRoom(room.ID); err != nil && !errors.Is(err, ErrorRoomClosed) {
+				log.Printf("[ERROR] Failed to close finished sit and go - RoomID: %s, Error: %v", room.ID, err)
+			}
+		})
+	}
+
+	room.SitAndGo = sng
+	room.Game.Tournament = sng
+	// Players away are folded and blinded off, they do not sit out
+	room.Game.ActionTimer.SitOutAfterTimeouts = 0
+
+	rm.RegisterRoom(room)
+	log.Printf("[INFO] Sit and go open for registration - RoomID: %s, Seats: %d, BuyIn: %d", room.ID, definition.Seats, definition.BuyIn)
+
+	return room, nil
+}
+
+// GetSitAndGos returns the Sit & Go tables that have not closed yet.
+func (rm *RoomManager) GetSitAndGos() []*SitAndGoSummary {
+	rm.mu.RLock()
+	sitAndGos := make([]*SitAndGo, 0)
+	for _, room := range rm.rooms {
+		if room.SitAndGo != nil && room.GetStatus() != RoomStatusClosed {
+			sitAndGos = append(sitAndGos, room.SitAndGo)
+		}
+	}
+	rm.mu.RUnlock()
+
+	summaries := make([]*SitAndGoSummary, 0, len(sitAndGos))
+	for _, sng := range sitAndGos {
+		summaries = append(summaries, sng.Summary())
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].RoomID < summaries[j].RoomID })
+	return summaries
+}
+
+// Register takes the player's buy-in from their wallet into the prize pool.
+// The tournament starts when the player takes the last seat.
+func (s *SitAndGo) Register(client *Client) error {
+	full, err := s.register(client)
+	if err != nil {
+		return err
+	}
+
+	if full {
+		s.start()
+	}
+
+	return nil
+}
+
+func (s *SitAndGo) register(client *Client) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	playerID := client.User.Player.ID
+	switch {
+	case s.Status != TournamentStatusRegistering:
+		return false, ErrorTournamentNotRegistering
+	case s.entries[playerID] != nil:
+		return false, ErrorTournamentRegistered
+	case len(s.registered) >= s.Definition.Seats:
+		return false, ErrorTournamentFull
+	case int(client.User.Player.Chips) < s.Definition.BuyIn:
+		return false, ErrorGameInsufficientChips
+	}
+
+	// The wallet is called holding mu, so no more players pay in than there
+	// are seats
+	reservationID := uuid.New().String()
+	if err := s.Room.Game.reserveChips(reservationID, client, mq.ChipTransactionBuyIn, s.Definition.BuyIn); err != nil {
+		return false, err
+	}
+
+	client.User.Player.Chips -= int64(s.Definition.BuyIn)
+	s.entries[playerID] = &tournamentEntry{Client: client, ReservationID: reservationID}
+	s.registered = append(s.registered, playerID)
+	log.Printf("[INFO] Player registered for sit and go - RoomID: %s, PlayerID: %s, Registered: %d/%d", s.Room.ID, playerID, len(s.registered), s.Definition.Seats)
+
+	if len(s.registered) < s.Definition.Seats {
+		return false, nil
+	}
+
+	s.Status = TournamentStatusRunning
+	s.StartedAt = time.Now()
+	s.prizes = prizes(s.Definition.BuyIn*len(s.registered), s.Definition.Payouts)
+	s.clock.start()
+	return true, nil
+}
+
+// Unregister gives the player their buy-in back while the tournament has not
+// started.
+func (s *SitAndGo) Unregister(playerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Status != TournamentStatusRegistering {
+		return ErrorTournamentNotRegistering
+	}
+
+	if s.entries[playerID] == nil {
+		return ErrorTournamentNotRegistered
+	}
+
+	s.refund(playerID)
+	log.Printf("[INFO] Player unregistered from sit and go - RoomID: %s, PlayerID: %s", s.Room.ID, playerID)
+
+	return nil
+}
+
+// Cancel gives every registered player their buy-in back. A running
+// tournament can not be cancelled, it is played to the end.
+func (s *SitAndGo) Cancel() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.Status {
+	case TournamentStatusRunning:
+		return ErrorTournamentRunning
+	case TournamentStatusRegistering:
+		for _, playerID := range slices.Clone(s.registered) {
+			s.refund(playerID)
+		}
+		s.Status = TournamentStatusCancelled
+		log.Printf("[INFO] Sit and go cancelled - RoomID: %s", s.Room.ID)
+	}
+
+	return nil
+}
+
+// refund releases the player's buy-in and drops their registration. The
+// caller holds mu.
+func (s *SitAndGo) refund(playerID string) {
+	entry := s.entries[playerID]
+	entry.Client.User.Player.Chips += int64(s.Definition.BuyIn)
+	s.Room.Game.releaseChips(entry.ReservationID)
+
+	delete(s.entries, playerID)
+	s.registered = slices.DeleteFunc(s.registered, func(id string) bool { return id == playerID })
+}
+
+// start seats the registered players at random with the starting stack and
+// deals the first hand.
+func (s *SitAndGo) start() {
+	s.mu.Lock()
+	entries := make([]*tournamentEntry, 0, len(s.registered))
+	for _, playerID := range s.registered {
+		entries = append(entries, s.entries[playerID])
+	}
+	s.mu.Unlock()
+
+	// Registered players who left the room are let back in to follow their
+	// table and to get their seat back when they reconnect
+	for _, entry := range entries {
+		if !s.Room.HasPlayer(entry.Client.User.Player.ID) {
+			s.Room.AddPlayer(entry.Client)
+		}
+	}
+
+	game := s.Room.Game
+	positions := rand.Perm(game.MaxPlayers)
+
+	game.Mu.Lock()
+	for i, entry := range entries {
+		game.Players = append(game.Players, &GamePlayer{
+			Position: positions[i],
+			Client:   entry.Client,
+			Balance:  s.Definition.StartingStack,
+			Status:   GamePlayerStatusWaiting,
+		})
+	}
+
+	if err := game.Start(); err != nil {
+		log.Printf("[ERROR] Failed to start sit and go - RoomID: %s, Error: %v", s.Room.ID, err)
+	}
+	game.Mu.Unlock()
+
+	log.Printf("[INFO] Sit and go started - RoomID: %s, Players: %d", s.Room.ID, len(entries))
+	go s.onStart()
+}
+
+// raiseBlinds announces the level the table plays from its next hand on.
+func (s *SitAndGo) raiseBlinds(level int, blinds models.BlindLevel) {
+	s.mu.Lock()
+	running := s.Status == TournamentStatusRunning
+	s.mu.Unlock()
+	if !running {
+		return
+	}
+
+	log.Printf("[INFO] Sit and go blinds up - RoomID: %s, Level: %d, SmallBlind: %d, BigBlind: %d, Ante: %d", s.Room.ID, level+1, blinds.SmallBlind, blinds.BigBlind, blinds.Ante)
+	s.Room.BroadcastToRoom(models.Response{
+		Type:   models.MessageTypeTournamentLevel,
+		RoomID: s.Room.ID,
+		Data: models.MessageTournamentLevelResponse{
+			RoomID:     s.Room.ID,
+			Level:      level + 1,
+			SmallBlind: blinds.SmallBlind,
+			BigBlind:   blinds.BigBlind,
+			Ante:       blinds.Ante,
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// RemoveBusted eliminates the players with no chips left. Players who left
+// the table are blinded out before they are. Players busting in the same
+// hand finish in the order of the stacks they started it with. The caller
+// holds the game's Mu.
+func (s *SitAndGo) RemoveBusted(game *Game) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	busted := make([]*GamePlayer, 0)
+	game.Players = slices.DeleteFunc(game.Players, func(p *GamePlayer) bool {
+		if p.Balance > 0 {
+			return false
+		}
+
+		busted = append(busted, p)
+		return true
+	})
+
+	if s.Status != TournamentStatusRunning {
+		return
+	}
+
+	sort.SliceStable(busted, func(i, j int) bool {
+		return s.handStacks[busted[i].Client.User.Player.ID] < s.handStacks[busted[j].Client.User.Player.ID]
+	})
+
+	eliminated := make([]models.TournamentResult, 0, len(busted)+1)
+	place := len(game.Players) + len(busted)
+	for _, player := range busted {
+		eliminated = append(eliminated, s.pay(game, player, place))
+		place--
+	}
+
+	finished := len(game.Players) <= 1
+	if finished {
+		// The winner keeps the seat until the table closes
+		if len(game.Players) == 1 {
+			s.pay(game, game.Players[0], 1)
+		}
+		s.finish()
+	}
+
+	s.handStacks = make(map[string]int, len(game.Players))
+	for _, player := range game.Players {
+		s.handStacks[player.Client.User.Player.ID] = player.Balance
+	}
+
+	if len(eliminated) > 0 || finished {
+		// The room is told once the game's Mu is released
+		go s.announce(eliminated, finished)
+	}
+}
+
+// CanDeal lets the table deal every hand, it plays on its own.
+func (s *SitAndGo) CanDeal(game *Game) bool {
+	return true
+}
+
+// pay records the place the player finished in and settles their buy-in
+// reservation with the prize for it, nothing for places out of the money.
+// The caller holds mu.
+func (s *SitAndGo) pay(game *Game, player *GamePlayer, place int) models.TournamentResult {
+	playerID := player.Client.User.Player.ID
+	result := models.TournamentResult{PlayerID: playerID, Place: place}
+	if place <= len(s.prizes) {
+		result.Prize = s.prizes[place-1]
+	}
+
+	player.Client.User.Player.Chips += int64(result.Prize)
+	if entry := s.entries[playerID]; entry != nil {
+		reservationID := entry.ReservationID
+		game.retryWallet(reservationID, func(wallet IChipWallet) error {
+			return wallet.Settle(reservationID, mq.ChipTransactionPrize, result.Prize)
+		})
+	}
+
+	s.results = append(s.results, result)
+	log.Printf("[INFO] Sit and go place - RoomID: %s, PlayerID: %s, Place: %d, Prize: %d", s.Room.ID, playerID, place, result.Prize)
+
+	return result
+}
+
+// finish ends the tournament once every place is paid. The caller holds mu.
+func (s *SitAndGo) finish() {
+	s.Status = TournamentStatusFinished
+	s.clock.stop()
+
+	log.Printf("[INFO] Sit and go finished - RoomID: %s", s.Room.ID)
+	go s.onFinish()
+}
+
+func (s *SitAndGo) announce(eliminated []models.TournamentResult, finished bool) {
+	for _, result := range eliminated {
+		s.Room.BroadcastToRoom(models.Response{
+			Type:   models.MessageTypeTournamentEliminated,
+			RoomID: s.Room.ID,
+			Data: models.MessageTournamentEliminatedResponse{
+				RoomID:           s.Room.ID,
+				TournamentResult: result,
+			},
+			Timestamp: time.Now().UTC(),
+		})
+	}
+
+	if finished {
+		s.Room.BroadcastToRoom(models.Response{
+			Type:   models.MessageTypeTournamentFinished,
+			RoomID: s.Room.ID,
+			Data: models.MessageTournamentFinishedResponse{
+				RoomID:  s.Room.ID,
+				Results: s.Summary().Results,
+			},
+			Timestamp: time.Now().UTC(),
+		})
+	}
+}
+
+// Summary returns the registrations, the blinds and the places paid so far.
+func (s *SitAndGo) Summary() *SitAndGoSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	level, blinds, _ := s.clock.current()
+	results := slices.Clone(s.results)
+	sort.Slice(results, func(i, j int) bool { return results[i].Place < results[j].Place })
+
+	return &SitAndGoSummary{
+		RoomID:           s.Room.ID,
+		Name:             s.Definition.Name,
+		Status:           s.Status,
+		GameType:         GameType(s.Definition.GameType),
+		BettingStructure: s.Definition.BettingStructure,
+		Seats:            s.Definition.Seats,
+		Registered:       len(s.registered),
+		BuyIn:            s.Definition.BuyIn,
+		PrizePool:        s.Definition.BuyIn * len(s.registered),
+		StartingStack:    s.Definition.StartingStack,
+		Level:            level + 1,
+		SmallBlind:       blinds.SmallBlind,
+		BigBlind:         blinds.BigBlind,
+		Ante:             blinds.Ante,
+		Results:          results,
+	}
+}
+
+func (h *MessageHandler) handleTournamentRegister(client *Client, msg models.MessageTournament) error {
+	if msg.TournamentID != "" {
+		return h.handleTournamentEntry(client, msg)
+	}
+
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil || room.SitAndGo == nil {
+		return h.sendError(client, msg.RoomID, "Tournament not found")
+	}
+
+	playerID := client.User.Player.ID
+	joined := false
+	if !room.HasPlayer(playerID) {
+		if err := h.server.JoinRoom(room.ID, client); err != nil {
+			return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to join room: %v", err))
+		}
+		joined = true
+	}
+
+	if err := room.SitAndGo.Register(client); err != nil {
+		if joined {
+			room.RemovePlayer(playerID)
+		}
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to register: %v", err))
+	}
+
+	h.sendTournamentUpdate(client, room, models.MessageTypeTournamentRegisterOk)
+	return nil
+}
+
+func (h *MessageHandler) handleTournamentUnregister(client *Client, msg models.MessageTournament) error {
+	if msg.TournamentID != "" {
+		return h.handleTournamentWithdraw(client, msg)
+	}
+
+	room := h.server.GetRoom(msg.RoomID)
+	if room == nil || room.SitAndGo == nil {
+		return h.sendError(client, msg.RoomID, "Tournament not found")
+	}
+
+	if err := room.SitAndGo.Unregister(client.User.Player.ID); err != nil {
+		return h.sendError(client, msg.RoomID, fmt.Sprintf("Failed to unregister: %v", err))
+	}
+
+	h.sendTournamentUpdate(client, room, models.MessageTypeTournamentUnregisterOk)
+	return nil
+}
+
+// sendTournamentUpdate answers the player and tells the room how the
+// registrations stand.
+func (h *MessageHandler) sendTournamentUpdate(client *Client, room *Room, messageType models.MessageType) {
+	data := models.MessageTournamentResponse{
+		RoomID:     room.ID,
+		Tournament: room.SitAndGo.Summary(),
+	}
+
+	client.Broadcast(models.Response{
+		Type:      messageType,
+		RoomID:    room.ID,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	})
+
+	room.BroadcastToRoom(models.Response{
+		Type:      models.MessageTypeTournamentUpdate,
+		RoomID:    room.ID,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -8,4 +8,6 @@ type Config struct {
 	ServerPort  string
 	BaseUrl     string
 	ApiUrl      string
+	AdminToken  string
+	InstanceID  string // Names this game server to the wallet, must stay the same across restarts
 }
@@ -1,9 +1,9 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
-	"math/rand"
-	"time"
 )
 
 var (
@@ -50,8 +50,25 @@ const (
 	Ace   Value = 14
 )
 
-// NewDeck creates and returns a new deck of cards
+// NewDeck creates and returns a new shuffled deck of cards
 func NewDeck() *Deck {
+	deck := NewOrderedDeck()
+	deck.Shuffle()
+	return deck
+}
+
+// NewSeededDeck returns a deck shuffled deterministically from the given seeds.
+// The same seeds always produce the same card order, which is what allows a
+// revealed server seed to be checked against the order the hand was dealt in.
+func NewSeededDeck(serverSeed, clientSeed string) *Deck {
+	deck := NewOrderedDeck()
+	deck.ShuffleWithSeed(serverSeed, clientSeed)
+	return deck
+}
+
+// NewOrderedDeck returns an unshuffled deck, suits in the order hearts,
+// diamonds, clubs, spades and values from two to ace within each suit.
+func NewOrderedDeck() *Deck {
 	deck := &Deck{Cards: make([]Card, 0, 52)}
 	suits := []Suit{Hearts, Diamonds, Clubs, Spades}
 	values := []Value{Two, Three, Four, Five, Six, Seven, Eight, Nine, Ten, Jack, Queen, King, Ace}
@@ -66,19 +83,60 @@ func NewDeck() *Deck {
 		}
 	}
 
-	deck.Shuffle()
 	return deck
 }
 
-// Shuffle randomizes the order of cards in the deck
+// Shuffle randomizes the order of cards in the deck using crypto/rand
 func (d *Deck) Shuffle() {
-	rand.Seed(time.Now().UnixNano())
+	d.shuffle(func(n uint64) uint64 {
+		return uniform(n, func() uint64 {
+			var buf [8]byte
+			if _, err := rand.Read(buf[:]); err != nil {
+				panic("deck: crypto/rand unavailable: " + err.Error())
+			}
+			return binary.BigEndian.Uint64(buf[:])
+		})
+	})
+}
+
+// ShuffleWithSeed shuffles the deck with a Fisher-Yates shuffle driven by the
+// SHA-256 stream of the given seeds (see SeedStream).
+func (d *Deck) ShuffleWithSeed(serverSeed, clientSeed string) {
+	stream := NewSeedStream(serverSeed, clientSeed)
+	d.shuffle(func(n uint64) uint64 {
+		return uniform(n, stream.Uint64)
+	})
+}
+
+func (d *Deck) shuffle(intn func(n uint64) uint64) {
 	for i := len(d.Cards) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+		j := intn(uint64(i + 1))
 		d.Cards[i], d.Cards[j] = d.Cards[j], d.Cards[i]
 	}
 }
 
+// uniform returns a value in [0, n) without modulo bias by rejecting draws
+// from the incomplete range at the top of uint64.
+func uniform(n uint64, next func() uint64) uint64 {
+	limit := ^uint64(0) - (^uint64(0) % n)
+	for {
+		if v := next(); v < limit {
+			return v % n
+		}
+	}
+}
+
+// HiddenCards returns n face-down cards that carry no suit or value, used to
+// show opponents how many cards a player holds without revealing them.
+func HiddenCards(n int) []Card {
+	cards := make([]Card, n)
+	for i := range cards {
+		cards[i].Hidden = true
+	}
+
+	return cards
+}
+
 // Draw removes and returns the top card from the deck
 func (d *Deck) Draw() (Card, error) {
 	if len(d.Cards) == 0 {
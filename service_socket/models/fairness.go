@@ -0,0 +1,86 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+)
+
+// ServerSeedSize is the number of random bytes in a server seed.
+const ServerSeedSize = 32
+
+// ShuffleProof is what players need to verify a hand's deck. Before the hand
+// only the commitment and client seed are published; the server seed is added
+// once the hand is over.
+type ShuffleProof struct {
+	HandID     string `json:"hand_id"`
+	Commitment string `json:"commitment"`
+	ClientSeed string `json:"client_seed"`
+	ServerSeed string `json:"server_seed,omitempty"`
+}
+
+// NewServerSeed returns a hex encoded seed read from crypto/rand.
+func NewServerSeed() (string, error) {
+	seed := make([]byte, ServerSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(seed), nil
+}
+
+// Commitment returns the hex encoded SHA-256 of serverSeed + ":" + clientSeed.
+func Commitment(serverSeed, clientSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed + ":" + clientSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether the revealed server seed matches the commitment.
+func (p ShuffleProof) Verify() bool {
+	if p.ServerSeed == "" {
+		return false
+	}
+
+	expected := Commitment(p.ServerSeed, p.ClientSeed)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(p.Commitment)) == 1
+}
+
+// Deck rebuilds the deck the proof was committed to. Cards are drawn from the
+// end of Deck.Cards.
+func (p ShuffleProof) Deck() *Deck {
+	return NewSeededDeck(p.ServerSeed, p.ClientSeed)
+}
+
+// SeedStream is a deterministic stream of 64-bit values. Block i is
+// SHA-256(serverSeed + ":" + clientSeed + ":" + i) and each block yields four
+// big-endian uint64 values.
+type SeedStream struct {
+	serverSeed string
+	clientSeed string
+	counter    uint64
+	block      [sha256.Size]byte
+	offset     int
+}
+
+func NewSeedStream(serverSeed, clientSeed string) *SeedStream {
+	return &SeedStream{
+		serverSeed: serverSeed,
+		clientSeed: clientSeed,
+		offset:     sha256.Size,
+	}
+}
+
+func (s *SeedStream) Uint64() uint64 {
+	if s.offset+8 > sha256.Size {
+		s.block = sha256.Sum256([]byte(s.serverSeed + ":" + s.clientSeed + ":" + strconv.FormatUint(s.counter, 10)))
+		s.counter++
+		s.offset = 0
+	}
+
+	v := binary.BigEndian.Uint64(s.block[s.offset : s.offset+8])
+	s.offset += 8
+	return v
+}
@@ -9,18 +9,51 @@ import (
 type MessageType string
 
 const (
-	MessageTypeRoomInfo         MessageType = "room_info"
-	MessageTypeJoinRoom         MessageType = "room_join"
-	MessageTypeJoinRoomOk       MessageType = "room_join_ok"
-	MessageTypeLeaveRoom        MessageType = "room_leave"
-	MessageTypeLeaveRoomOk      MessageType = "room_leave_ok"
-	MessageTypeJoinGame         MessageType = "game_join"
-	MessageTypeJoinGameOk       MessageType = "game_join_ok"
-	MessageTypeLeaveGame        MessageType = "game_leave"
-	MessageTypeLeaveGameOk      MessageType = "game_leave_ok"
-	MessageTypeGameAction       MessageType = "game_action"
-	MessageTypeGameHoldemAction MessageType = "game_holdem_action"
-	MessageTypeError            MessageType = "error"
+	MessageTypeRoomInfo               MessageType = "room_info"
+	MessageTypeJoinRoom               MessageType = "room_join"
+	MessageTypeJoinRoomOk             MessageType = "room_join_ok"
+	MessageTypeLeaveRoom              MessageType = "room_leave"
+	MessageTypeLeaveRoomOk            MessageType = "room_leave_ok"
+	MessageTypeJoinGame               MessageType = "game_join"
+	MessageTypeJoinGameOk             MessageType = "game_join_ok"
+	MessageTypeLeaveGame              MessageType = "game_leave"
+	MessageTypeLeaveGameOk            MessageType = "game_leave_ok"
+	MessageTypeGameAction             MessageType = "game_action"
+	MessageTypeGameHoldemAction       MessageType = "game_holdem_action"
+	MessageTypeGameClientSeed         MessageType = "game_client_seed"
+	MessageTypeSpectateJoin           MessageType = "spectate_join"
+	MessageTypeSpectateJoinOk         MessageType = "spectate_join_ok"
+	MessageTypeSpectateLeave          MessageType = "spectate_leave"
+	MessageTypeSpectateLeaveOk        MessageType = "spectate_leave_ok"
+	MessageTypeSessionResume          MessageType = "session_resume"
+	MessageTypeSitOut                 MessageType = "sit_out"
+	MessageTypeSitIn                  MessageType = "sit_in"
+	MessageTypeTopUp                  MessageType = "top_up"
+	MessageTypeRoomClosed             MessageType = "room_closed"
+	MessageTypeQuickSeat              MessageType = "quick_seat"
+	MessageTypeQuickSeatQueued        MessageType = "quick_seat_queued"
+	MessageTypeQuickSeatFound         MessageType = "quick_seat_found"
+	MessageTypeQuickSeatExpired       MessageType = "quick_seat_expired"
+	MessageTypeQuickSeatCancel        MessageType = "quick_seat_cancel"
+	MessageTypeQuickSeatCancelOk      MessageType = "quick_seat_cancel_ok"
+	MessageTypePrivateCreate          MessageType = "private_room_create"
+	MessageTypePrivateCreateOk        MessageType = "private_room_create_ok"
+	MessageTypePrivateKick            MessageType = "private_room_kick"
+	MessageTypePrivateKicked          MessageType = "private_room_kicked"
+	MessageTypePrivatePause           MessageType = "private_room_pause"
+	MessageTypePrivateResume          MessageType = "private_room_resume"
+	MessageTypePrivateBlinds          MessageType = "private_room_blinds"
+	MessageTypeTournamentRegister     MessageType = "tournament_register"
+	MessageTypeTournamentRegisterOk   MessageType = "tournament_register_ok"
+	MessageTypeTournamentUnregister   MessageType = "tournament_unregister"
+	MessageTypeTournamentUnregisterOk MessageType = "tournament_unregister_ok"
+	MessageTypeTournamentUpdate       MessageType = "tournament_update"
+	MessageTypeTournamentLevel        MessageType = "tournament_level"
+	MessageTypeTournamentEliminated   MessageType = "tournament_eliminated"
+	MessageTypeTournamentFinished     MessageType = "tournament_finished"
+	MessageTypeTournamentSeated       MessageType = "tournament_seated"
+	MessageTypeTournamentHandForHand  MessageType = "tournament_hand_for_hand"
+	MessageTypeError                  MessageType = "error"
 )
 
 // Message represents a WebSocket message
@@ -31,6 +64,7 @@ type Message struct {
 
 type Response struct {
 	Type      MessageType `json:"type"`
+	RoomID    string      `json:"room_id,omitempty"` // Room the message is about, empty for messages about no room
 	PlayerID  string      `json:"player_id"`
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
@@ -44,6 +78,8 @@ type MessageRoomInfo struct {
 type MessageJoinRoom struct {
 	RoomID   string `json:"room_id"`
 	PlayerID string `json:"player_id"`
+	JoinCode string `json:"join_code"` // Finds a private room without its ID
+	Password string `json:"password"`  // Lets into a private room by its ID
 }
 
 type MessageJoinRoomResponse struct {
@@ -65,10 +101,22 @@ type MessageLeaveRoomResponse struct {
 }
 
 // Message Game
+
+// BlindEntry is how a player who joins or comes back between the blinds is
+// dealt in. Waiting for the big blind is the default.
+type BlindEntry string
+
+const (
+	BlindEntryWaitForBigBlind BlindEntry = "wait_for_big_blind"
+	BlindEntryPostNow         BlindEntry = "post_now"
+)
+
 type MessageJoinGame struct {
-	RoomID   string `json:"room_id"`
-	PlayerID string `json:"player_id"`
-	Position int    `json:"position"`
+	RoomID     string     `json:"room_id"`
+	PlayerID   string     `json:"player_id"`
+	Position   int        `json:"position"`
+	BlindEntry BlindEntry `json:"blind_entry"`
+	BuyIn      int        `json:"buy_in"` // Zero buys in for the most the player can
 }
 
 type MessageJoinGameResponse struct {
@@ -89,6 +137,184 @@ type MessageLeaveGameResponse struct {
 	State    interface{} `json:"state"`
 }
 
+type MessageSitOut struct {
+	RoomID   string `json:"room_id"`
+	PlayerID string `json:"player_id"`
+}
+
+type MessageSitOutResponse struct {
+	RoomID   string      `json:"room_id"`
+	PlayerID string      `json:"player_id"`
+	State    interface{} `json:"state"`
+}
+
+type MessageSitIn struct {
+	RoomID     string     `json:"room_id"`
+	PlayerID   string     `json:"player_id"`
+	BlindEntry BlindEntry `json:"blind_entry"`
+}
+
+type MessageSitInResponse struct {
+	RoomID   string      `json:"room_id"`
+	PlayerID string      `json:"player_id"`
+	State    interface{} `json:"state"`
+}
+
+type MessageTopUp struct {
+	RoomID   string `json:"room_id"`
+	PlayerID string `json:"player_id"`
+	Amount   int    `json:"amount"`
+}
+
+type MessageTopUpResponse struct {
+	RoomID   string      `json:"room_id"`
+	PlayerID string      `json:"player_id"`
+	Amount   int         `json:"amount"`
+	State    interface{} `json:"state"`
+}
+
+// MessageQuickSeat asks for a seat at the best table of the game type within
+// the stakes. Zero stakes and table size match any.
+type MessageQuickSeat struct {
+	GameType   int        `json:"game_type"`
+	MinStake   int        `json:"min_stake"`  // Lowest min bet
+	MaxStake   int        `json:"max_stake"`  // Highest min bet
+	TableSize  int        `json:"table_size"` // Preferred number of seats
+	BlindEntry BlindEntry `json:"blind_entry"`
+	BuyIn      int        `json:"buy_in"` // Zero buys in for the most the player can
+}
+
+// MessageQuickSeatQueuedResponse is sent when no table has a seat yet. The
+// player is seated once one does, until the ticket expires.
+type MessageQuickSeatQueuedResponse struct {
+	TicketID  string    `json:"ticket_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MessageQuickSeatFoundResponse is sent when the player got a seat, with an
+// empty ticket when they were seated at once.
+type MessageQuickSeatFoundResponse struct {
+	TicketID string `json:"ticket_id"`
+	RoomID   string `json:"room_id"`
+	Position int    `json:"position"`
+}
+
+type MessageQuickSeatCancel struct {
+	TicketID string `json:"ticket_id"`
+}
+
+// MessagePrivateCreate opens a private room hosted by the player. Only players
+// with the join code, or the password when one is set, can get in.
+type MessagePrivateCreate struct {
+	Name             string `json:"name"`
+	GameType         int    `json:"game_type"`
+	BettingStructure string `json:"betting_structure"`
+	MinBet           int    `json:"min_bet"`
+	MaxGamePlayers   int    `json:"max_game_players"`
+	BlindSchedule    string `json:"blind_schedule"` // Preset the blinds rise by, empty keeps them at the min bet
+	Password         string `json:"password"`
+}
+
+type MessagePrivateCreateResponse struct {
+	RoomID   string      `json:"room_id"`
+	JoinCode string      `json:"join_code"`
+	State    interface{} `json:"state"`
+}
+
+// MessagePrivateHost is a host control on a private room: a kick, a pause, a
+// resume or a change of blinds.
+type MessagePrivateHost struct {
+	RoomID     string `json:"room_id"`
+	PlayerID   string `json:"player_id"`   // Player to kick
+	SmallBlind int    `json:"small_blind"` // Zero is half the big blind
+	BigBlind   int    `json:"big_blind"`
+	Ante       int    `json:"ante"`
+}
+
+type MessagePrivateHostResponse struct {
+	RoomID     string      `json:"room_id"`
+	PlayerID   string      `json:"player_id,omitempty"`
+	SmallBlind int         `json:"small_blind,omitempty"`
+	BigBlind   int         `json:"big_blind,omitempty"`
+	Ante       int         `json:"ante,omitempty"`
+	State      interface{} `json:"state"`
+}
+
+// MessageTournament registers the player for the tournament played in the
+// room or for the multi-table tournament, or takes them off it while it has
+// not started.
+type MessageTournament struct {
+	RoomID       string `json:"room_id"`
+	TournamentID string `json:"tournament_id"` // Multi-table tournaments, which have no room before they start
+}
+
+type MessageTournamentResponse struct {
+	RoomID       string      `json:"room_id"`
+	TournamentID string      `json:"tournament_id,omitempty"`
+	Tournament   interface{} `json:"tournament"`
+}
+
+type MessageTournamentLevelResponse struct {
+	RoomID       string `json:"room_id"`
+	TournamentID string `json:"tournament_id,omitempty"`
+	Level        int    `json:"level"`
+	SmallBlind   int    `json:"small_blind"`
+	BigBlind     int    `json:"big_blind"`
+	Ante         int    `json:"ante"`
+}
+
+type MessageTournamentEliminatedResponse struct {
+	RoomID       string `json:"room_id"`
+	TournamentID string `json:"tournament_id,omitempty"`
+	TournamentResult
+}
+
+type MessageTournamentFinishedResponse struct {
+	RoomID       string             `json:"room_id"`
+	TournamentID string             `json:"tournament_id,omitempty"`
+	Results      []TournamentResult `json:"results"`
+}
+
+// MessageTournamentSeatedResponse tells a player the table they play at in a
+// multi-table tournament. FromRoomID is the table they were moved from, empty
+// when they take their first seat.
+type MessageTournamentSeatedResponse struct {
+	TournamentID string `json:"tournament_id"`
+	RoomID       string `json:"room_id"`
+	FromRoomID   string `json:"from_room_id,omitempty"`
+}
+
+// MessageTournamentHandForHandResponse tells the tables whether they wait for
+// one another to finish each hand before dealing the next.
+type MessageTournamentHandForHandResponse struct {
+	TournamentID string `json:"tournament_id"`
+	HandForHand  bool   `json:"hand_for_hand"`
+}
+
+// MessageSessionResumeResponse is sent to a player who reconnected to a
+// table they are seated at, with the full state including their own cards.
+type MessageSessionResumeResponse struct {
+	RoomID string      `json:"room_id"`
+	Player *Player     `json:"player"`
+	State  interface{} `json:"state"`
+}
+
+// Message Spectator
+type MessageSpectateJoin struct {
+	RoomID string `json:"room_id"`
+}
+
+type MessageSpectateLeave struct {
+	RoomID string `json:"room_id"`
+}
+
+type MessageSpectateResponse struct {
+	RoomID     string      `json:"room_id"`
+	Player     *Player     `json:"player"`
+	Spectators int         `json:"spectators"`
+	State      interface{} `json:"state"`
+}
+
 type MessageGameAction struct {
 	RoomID   string          `json:"room_id"`
 	PlayerID string          `json:"player_id"`
@@ -102,3 +328,8 @@ type MessageGameActionResponse struct {
 	GameType int             `json:"game_type"`
 	Data     json.RawMessage `json:"data"`
 }
+
+type MessageGameClientSeed struct {
+	RoomID string `json:"room_id"`
+	Seed   string `json:"seed"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// RoomDefinition is how a table is set up. The API keeps the definitions so
+// the tables survive restarts. Zero timers and buy-ins leave the server
+// defaults, and tables without a blind schedule play the min bet as the big
+// blind.
+type RoomDefinition struct {
+	ID                   string       `json:"id"`
+	Name                 string       `json:"name"`
+	GameType             int          `json:"game_type"`
+	BettingStructure     string       `json:"betting_structure"`
+	MinBet               int          `json:"min_bet"`
+	MaxPlayers           int          `json:"max_players"`
+	MaxGamePlayers       int          `json:"max_game_players"`
+	ActionTimeoutSeconds int          `json:"action_timeout_seconds"`
+	TimeBankSeconds      int          `json:"time_bank_seconds"`
+	MinBuyIn             int          `json:"min_buy_in"`
+	MaxBuyIn             int          `json:"max_buy_in"`
+	BlindSchedule        string       `json:"blind_schedule,omitempty"` // Preset scaled to start at the min bet
+	BlindLevels          []BlindLevel `json:"blind_levels,omitempty"`   // Played as they are, instead of the preset
+	Status               string       `json:"status"`
+}
+
+// RoomPool keeps tables of one stake and game type open as players come and
+// go. The tables are set up from the template, numbered after the pool's ID.
+type RoomPool struct {
+	ID                 string         `json:"id"`
+	Template           RoomDefinition `json:"template"`
+	MinOpenTables      int            `json:"min_open_tables"` // Tables with an empty seat kept open
+	MaxTables          int            `json:"max_tables"`
+	IdleTimeoutSeconds int            `json:"idle_timeout_seconds"` // Zero leaves the server default
+}
+
+// SitAndGoDefinition is how a single table tournament is set up. It starts as
+// soon as every seat is taken, and a new one opens for registration.
+type SitAndGoDefinition struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	GameType         int          `json:"game_type"`
+	BettingStructure string       `json:"betting_structure"`
+	Seats            int          `json:"seats"`
+	BuyIn            int          `json:"buy_in"`         // Taken from the wallet into the prize pool
+	StartingStack    int          `json:"starting_stack"` // Tournament chips, worth nothing off the table
+	BlindSchedule    string       `json:"blind_schedule"` // Preset played when Levels is empty
+	Levels           []BlindLevel `json:"levels"`         // Empty leaves the preset or the server's levels
+	Payouts          []int        `json:"payouts"`        // Percent of the prize pool by place, empty leaves the server's structure
+}
+
+// TournamentDefinition is how a multi-table tournament is set up. It starts at
+// StartsAt on as many tables as the entrants fill, and the players are moved
+// between them as the field shrinks.
+type TournamentDefinition struct {
+	ID                     string       `json:"id"`
+	Name                   string       `json:"name"`
+	GameType               int          `json:"game_type"`
+	BettingStructure       string       `json:"betting_structure"`
+	TableSize              int          `json:"table_size"`
+	MinEntrants            int          `json:"min_entrants"` // Fewer entrants at the start cancel the tournament
+	MaxEntrants            int          `json:"max_entrants"` // Entries taken in all, re-entries included, zero takes any number
+	BuyIn                  int          `json:"buy_in"`
+	StartingStack          int          `json:"starting_stack"`
+	StartsAt               time.Time    `json:"starts_at"`
+	LateRegistrationLevels int          `json:"late_registration_levels"` // Levels players can still register in, zero closes registration at the start
+	MaxReEntries           int          `json:"max_re_entries"`           // Times a busted player can buy in again while registration is open
+	BlindSchedule          string       `json:"blind_schedule"`           // Preset played when Levels is empty
+	Levels                 []BlindLevel `json:"levels"`                   // Empty leaves the preset or the server's levels
+	Payouts                []int        `json:"payouts"`                  // Percent of the prize pool by place, empty pays by the number of entries
+}
+
+// BlindLevel is a stage of a blind schedule. The last level lasts until the
+// tournament is over, or for as long as the table is open.
+type BlindLevel struct {
+	SmallBlind      int `json:"small_blind"`
+	BigBlind        int `json:"big_blind"`
+	Ante            int `json:"ante"` // Posted by every player dealt in, zero plays no ante
+	DurationSeconds int `json:"duration_seconds"`
+}
+
+// TournamentResult is where a player finished and what they won.
+type TournamentResult struct {
+	PlayerID string `json:"player_id"`
+	Place    int    `json:"place"`
+	Prize    int    `json:"prize"`
+}